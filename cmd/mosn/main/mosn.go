@@ -25,14 +25,19 @@ import (
 	_ "github.com/alipay/sofa-mosn/pkg/buffer"
 	_ "github.com/alipay/sofa-mosn/pkg/filter/network/proxy"
 	_ "github.com/alipay/sofa-mosn/pkg/filter/network/tcpproxy"
+	_ "github.com/alipay/sofa-mosn/pkg/filter/stream/cors"
+	_ "github.com/alipay/sofa-mosn/pkg/filter/stream/globalratelimit"
 	_ "github.com/alipay/sofa-mosn/pkg/filter/stream/healthcheck/sofarpc"
+	_ "github.com/alipay/sofa-mosn/pkg/filter/stream/ratelimit"
 	_ "github.com/alipay/sofa-mosn/pkg/network"
 	_ "github.com/alipay/sofa-mosn/pkg/protocol"
 	_ "github.com/alipay/sofa-mosn/pkg/protocol/sofarpc/codec"
+	_ "github.com/alipay/sofa-mosn/pkg/stats/statsd"
 	_ "github.com/alipay/sofa-mosn/pkg/stream/http"
 	_ "github.com/alipay/sofa-mosn/pkg/stream/http2"
 	_ "github.com/alipay/sofa-mosn/pkg/stream/sofarpc"
 	_ "github.com/alipay/sofa-mosn/pkg/stream/xprotocol"
+	_ "github.com/alipay/sofa-mosn/pkg/trace/zipkin"
 	_ "github.com/alipay/sofa-mosn/pkg/upstream/healthcheck"
 	_ "github.com/alipay/sofa-mosn/pkg/xds"
 	"github.com/urfave/cli"
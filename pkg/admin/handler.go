@@ -0,0 +1,349 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/alipay/sofa-mosn/pkg/config"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/runtime"
+	"github.com/alipay/sofa-mosn/pkg/server"
+	"github.com/alipay/sofa-mosn/pkg/types"
+	xdsv2 "github.com/alipay/sofa-mosn/pkg/xds/v2"
+	"github.com/rcrowley/go-metrics"
+)
+
+func (s *Server) handleConfigDump(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	writeJSON(w, s.config)
+}
+
+func (s *Server) handleListeners(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	listeners := []ListenerInfo{}
+
+	if srv := server.GetServer(); srv != nil {
+		for _, l := range srv.Handler().ListListeners(nil) {
+			listeners = append(listeners, newListenerInfo(l))
+		}
+	}
+
+	writeJSON(w, listeners)
+}
+
+func (s *Server) handleClusters(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	clusters := []ClusterInfo{}
+
+	if s.clusterManager != nil {
+		for _, c := range s.clusterManager.Clusters() {
+			clusters = append(clusters, newClusterInfo(c))
+		}
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+
+	writeJSON(w, clusters)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	stats := []MetricInfo{}
+
+	metrics.DefaultRegistry.Each(func(name string, i interface{}) {
+		if mi, ok := newMetricInfo(name, i); ok {
+			stats = append(stats, mi)
+		}
+	})
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+
+	writeJSON(w, stats)
+}
+
+// handleXdsVersions reports the accepted version and the last nonce of each resource type subscribed by xds
+func (s *Server) handleXdsVersions(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	versions := []XdsVersionInfo{}
+	for _, v := range xdsv2.GetResourceVersions() {
+		versions = append(versions, XdsVersionInfo{
+			TypeURL:     v.TypeURL,
+			Version:     v.Version,
+			Nonce:       v.Nonce,
+			ErrorDetail: v.ErrorDetail,
+		})
+	}
+
+	writeJSON(w, versions)
+}
+
+// handleUpdateLogLevel changes the default logger's level, for example:
+// POST /api/v1/update_loglevel?level=DEBUG
+func (s *Server) handleUpdateLogLevel(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	levelName := r.URL.Query().Get("level")
+	level, ok := config.GetLogLevel(levelName)
+
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported log level: %s", levelName), http.StatusBadRequest)
+		return
+	}
+
+	log.DefaultLogger.Level = level
+	log.DefaultLogger.Infof("admin api update default log level to %s", levelName)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleDrainListeners stops all listeners accepting new connections,
+// accepted connections are kept
+func (s *Server) handleDrainListeners(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	log.DefaultLogger.Infof("admin api drain listeners")
+	server.StopAccept()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleRuntime(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	writeJSON(w, newRuntimeInfo(runtime.Snapshot()))
+}
+
+// handleRuntimeModify sets the values in the runtime admin layer, an empty value removes the key, for example:
+// POST /api/v1/runtime_modify?fault.http.abort.abort_percent=50&routing.traffic_shift.foo=
+func (s *Server) handleRuntimeModify(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	query := r.URL.Query()
+	if len(query) == 0 {
+		http.Error(w, "no runtime value to modify", http.StatusBadRequest)
+		return
+	}
+
+	values := make(map[string]string, len(query))
+	for k := range query {
+		values[k] = query.Get(k)
+	}
+
+	runtime.DefaultLoader().MergeValues(values)
+	log.DefaultLogger.Infof("admin api modify runtime values: %v", values)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleRuntimeReload reloads the runtime disk layers, the admin layer is kept
+func (s *Server) handleRuntimeReload(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	if err := runtime.DefaultLoader().Reload(); err != nil {
+		log.DefaultLogger.Errorf("admin api reload runtime failed: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.DefaultLogger.Errorf("admin api marshal response failed: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func newListenerInfo(l types.Listener) ListenerInfo {
+	info := ListenerInfo{
+		Name:                    l.Name(),
+		Address:                 l.Addr().String(),
+		PerConnBufferLimitBytes: l.PerConnBufferLimitBytes(),
+	}
+
+	if lc := l.Config(); lc != nil {
+		info.BindToPort = lc.BindToPort
+
+		for _, fc := range lc.FilterChains {
+			for _, f := range fc.Filters {
+				info.NetworkFilters = append(info.NetworkFilters, f.Name)
+			}
+		}
+
+		for _, f := range lc.StreamFilters {
+			info.StreamFilters = append(info.StreamFilters, f.Name)
+		}
+	}
+
+	return info
+}
+
+func newClusterInfo(c types.Cluster) ClusterInfo {
+	info := ClusterInfo{
+		Name:   c.Info().Name(),
+		LbType: string(c.Info().LbType()),
+		Hosts:  []HostInfo{},
+	}
+
+	for _, hostSet := range c.PrioritySet().HostSetsByPriority() {
+		for _, host := range hostSet.Hosts() {
+			info.Hosts = append(info.Hosts, newHostInfo(hostSet.Priority(), host))
+		}
+	}
+
+	return info
+}
+
+func newHostInfo(priority uint32, host types.Host) HostInfo {
+	info := HostInfo{
+		Address:  host.AddressString(),
+		Hostname: host.Hostname(),
+		Priority: priority,
+		Weight:   host.Weight(),
+		Healthy:  host.Health(),
+	}
+
+	if host.ContainHealthFlag(types.FAILED_ACTIVE_HC) {
+		info.HealthFlags = append(info.HealthFlags, FailedActiveHealthCheck)
+	}
+
+	if host.ContainHealthFlag(types.FAILED_OUTLIER_CHECK) {
+		info.HealthFlags = append(info.HealthFlags, FailedOutlierCheck)
+	}
+
+	return info
+}
+
+func newMetricInfo(name string, i interface{}) (MetricInfo, bool) {
+	switch metric := i.(type) {
+	case metrics.Counter:
+		return MetricInfo{
+			Name:  name,
+			Type:  "counter",
+			Count: metric.Count(),
+		}, true
+	case metrics.Gauge:
+		return MetricInfo{
+			Name:  name,
+			Type:  "gauge",
+			Value: metric.Value(),
+		}, true
+	case metrics.Histogram:
+		h := metric.Snapshot()
+		ps := h.Percentiles([]float64{0.5, 0.99})
+
+		return MetricInfo{
+			Name:  name,
+			Type:  "histogram",
+			Count: h.Count(),
+			Min:   h.Min(),
+			Max:   h.Max(),
+			Mean:  h.Mean(),
+			P50:   ps[0],
+			P99:   ps[1],
+		}, true
+	}
+
+	return MetricInfo{}, false
+}
+
+func newRuntimeInfo(snapshot types.RuntimeSnapshot) RuntimeInfo {
+	layers := snapshot.Layers()
+	info := RuntimeInfo{
+		Layers:  make([]string, 0, len(layers)),
+		Entries: []RuntimeEntry{},
+	}
+
+	keys := make(map[string]bool)
+	for i, layer := range layers {
+		info.Layers = append(info.Layers, layer.Name)
+
+		for k := range layer.Values {
+			if keys[k] {
+				continue
+			}
+			keys[k] = true
+
+			// the former layers have no such key
+			entry := RuntimeEntry{
+				Key:         k,
+				LayerValues: make([]string, len(layers)),
+			}
+			for j := i; j < len(layers); j++ {
+				entry.LayerValues[j] = layers[j].Values[k]
+			}
+			entry.FinalValue, _ = snapshot.Get(k)
+
+			info.Entries = append(info.Entries, entry)
+		}
+	}
+
+	sort.Slice(info.Entries, func(i, j int) bool {
+		return info.Entries[i].Key < info.Entries[j].Key
+	})
+
+	return info
+}
@@ -0,0 +1,107 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/alipay/sofa-mosn/pkg/config"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/stats/prometheus"
+	"github.com/alipay/sofa-mosn/pkg/types"
+	"github.com/json-iterator/go"
+	"github.com/rcrowley/go-metrics"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Server is the admin api server, it exposes mosn's runtime state
+// and some operations over http
+type Server struct {
+	config         *config.MOSNConfig
+	clusterManager types.ClusterManager
+	mux            *http.ServeMux
+	server         *http.Server
+}
+
+// NewServer creates an admin server on the in-memory mosn config and the cluster manager
+func NewServer(c *config.MOSNConfig, clusterManager types.ClusterManager) *Server {
+	s := &Server{
+		config:         c,
+		clusterManager: clusterManager,
+		mux:            http.NewServeMux(),
+	}
+
+	// read-only apis
+	s.mux.HandleFunc(ConfigDumpPath, s.handleConfigDump)
+	s.mux.HandleFunc(ListenersPath, s.handleListeners)
+	s.mux.HandleFunc(ClustersPath, s.handleClusters)
+	s.mux.HandleFunc(StatsPath, s.handleStats)
+	s.mux.HandleFunc(RuntimePath, s.handleRuntime)
+	s.mux.HandleFunc(XdsVersionsPath, s.handleXdsVersions)
+	s.mux.Handle(PrometheusPath, prometheus.Handler(metrics.DefaultRegistry))
+
+	// mutating apis
+	s.mux.HandleFunc(LogLevelPath, s.handleUpdateLogLevel)
+	s.mux.HandleFunc(DrainListenersPath, s.handleDrainListeners)
+	s.mux.HandleFunc(RuntimeModifyPath, s.handleRuntimeModify)
+	s.mux.HandleFunc(RuntimeReloadPath, s.handleRuntimeReload)
+
+	return s
+}
+
+// Handle registers an extra handler for the given path
+func (s *Server) Handle(path string, handler http.Handler) {
+	s.mux.Handle(path, handler)
+}
+
+// Handler returns the http handler serves all admin apis
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Start starts the admin server on the configured address
+func (s *Server) Start() {
+	if s.config == nil || s.config.Admin.Address == "" {
+		log.DefaultLogger.Infof("admin server address is not configured, admin server disabled")
+		return
+	}
+
+	s.server = &http.Server{
+		Addr:    s.config.Admin.Address,
+		Handler: s.mux,
+	}
+
+	go func() {
+		log.DefaultLogger.Infof("admin server listen on %s", s.config.Admin.Address)
+
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.DefaultLogger.Errorf("admin server stopped: %v", err)
+		}
+	}()
+}
+
+// Close stops the admin server
+func (s *Server) Close() error {
+	if s.server == nil {
+		return nil
+	}
+
+	return s.server.Shutdown(context.Background())
+}
@@ -0,0 +1,226 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/config"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/runtime"
+	"github.com/alipay/sofa-mosn/pkg/types"
+	"github.com/alipay/sofa-mosn/pkg/upstream/cluster"
+	"github.com/rcrowley/go-metrics"
+)
+
+func newTestServer() (*Server, types.ClusterManager) {
+	clusters := []v2.Cluster{
+		{
+			Name:        "admin_test_cluster",
+			ClusterType: v2.SIMPLE_CLUSTER,
+			LbType:      v2.LB_RANDOM,
+		},
+	}
+	hosts := map[string][]v2.Host{
+		"admin_test_cluster": {
+			{Address: "127.0.0.1:8080", Hostname: "h1", Weight: 1},
+			{Address: "127.0.0.1:8081", Hostname: "h2", Weight: 1},
+		},
+	}
+	cm := cluster.NewClusterManager(nil, clusters, hosts, true, false)
+
+	c := &config.MOSNConfig{
+		Admin: config.AdminConfig{
+			Address: "127.0.0.1:34901",
+		},
+	}
+
+	return NewServer(c, cm), cm
+}
+
+func doRequest(s *Server, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestConfigDump(t *testing.T) {
+	s, cm := newTestServer()
+	defer cm.Destory()
+
+	w := doRequest(s, http.MethodGet, ConfigDumpPath)
+	if w.Code != http.StatusOK {
+		t.Fatalf("config dump status code = %d", w.Code)
+	}
+
+	got := &config.MOSNConfig{}
+	if err := json.Unmarshal(w.Body.Bytes(), got); err != nil {
+		t.Fatalf("config dump response is not a mosn config: %v", err)
+	}
+	if got.Admin.Address != "127.0.0.1:34901" {
+		t.Errorf("config dump admin address = %s", got.Admin.Address)
+	}
+}
+
+func TestClusters(t *testing.T) {
+	s, cm := newTestServer()
+	defer cm.Destory()
+
+	for _, c := range cm.Clusters() {
+		for _, h := range c.PrioritySet().GetOrCreateHostSet(0).Hosts() {
+			if h.AddressString() == "127.0.0.1:8081" {
+				h.SetHealthFlag(types.FAILED_ACTIVE_HC)
+			}
+		}
+	}
+
+	w := doRequest(s, http.MethodGet, ClustersPath)
+	if w.Code != http.StatusOK {
+		t.Fatalf("clusters status code = %d", w.Code)
+	}
+
+	var clusters []ClusterInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &clusters); err != nil {
+		t.Fatalf("unmarshal clusters failed: %v", err)
+	}
+	if len(clusters) != 1 || clusters[0].Name != "admin_test_cluster" {
+		t.Fatalf("unexpected clusters: %+v", clusters)
+	}
+	if len(clusters[0].Hosts) != 2 {
+		t.Fatalf("unexpected hosts: %+v", clusters[0].Hosts)
+	}
+
+	for _, h := range clusters[0].Hosts {
+		switch h.Address {
+		case "127.0.0.1:8080":
+			if !h.Healthy || len(h.HealthFlags) != 0 {
+				t.Errorf("host %s should be healthy, got %+v", h.Address, h)
+			}
+		case "127.0.0.1:8081":
+			if h.Healthy || len(h.HealthFlags) != 1 || h.HealthFlags[0] != FailedActiveHealthCheck {
+				t.Errorf("host %s should fail active health check, got %+v", h.Address, h)
+			}
+		default:
+			t.Errorf("unexpected host %s", h.Address)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	s, cm := newTestServer()
+	defer cm.Destory()
+
+	metrics.GetOrRegisterCounter("admin_test.counter", nil).Inc(3)
+	metrics.GetOrRegisterGauge("admin_test.gauge", nil).Update(7)
+
+	w := doRequest(s, http.MethodGet, StatsPath)
+	if w.Code != http.StatusOK {
+		t.Fatalf("stats status code = %d", w.Code)
+	}
+
+	var stats []MetricInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("unmarshal stats failed: %v", err)
+	}
+
+	found := 0
+	for _, m := range stats {
+		switch m.Name {
+		case "admin_test.counter":
+			found++
+			if m.Type != "counter" || m.Count != 3 {
+				t.Errorf("unexpected counter: %+v", m)
+			}
+		case "admin_test.gauge":
+			found++
+			if m.Type != "gauge" || m.Value != 7 {
+				t.Errorf("unexpected gauge: %+v", m)
+			}
+		}
+	}
+	if found != 2 {
+		t.Errorf("expected metrics not found in %s", w.Body.String())
+	}
+}
+
+func TestUpdateLogLevel(t *testing.T) {
+	s, cm := newTestServer()
+	defer cm.Destory()
+
+	origin := log.DefaultLogger.Level
+	defer func() {
+		log.DefaultLogger.Level = origin
+	}()
+
+	if w := doRequest(s, http.MethodGet, LogLevelPath+"?level=DEBUG"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("update log level by GET should not be allowed, got %d", w.Code)
+	}
+
+	if w := doRequest(s, http.MethodPost, LogLevelPath+"?level=UNKNOWN"); w.Code != http.StatusBadRequest {
+		t.Errorf("update unknown log level should be rejected, got %d", w.Code)
+	}
+
+	if w := doRequest(s, http.MethodPost, LogLevelPath+"?level=TRACE"); w.Code != http.StatusOK {
+		t.Errorf("update log level failed, got %d: %s", w.Code, strings.TrimSpace(w.Body.String()))
+	}
+	if log.DefaultLogger.Level != log.TRACE {
+		t.Errorf("log level is not updated, got %d", log.DefaultLogger.Level)
+	}
+}
+
+func TestRuntime(t *testing.T) {
+	s, cm := newTestServer()
+	defer cm.Destory()
+
+	if w := doRequest(s, http.MethodPost, RuntimeModifyPath); w.Code != http.StatusBadRequest {
+		t.Errorf("modify runtime without values should be rejected, got %d", w.Code)
+	}
+
+	if w := doRequest(s, http.MethodPost, RuntimeModifyPath+"?admin_test.percent=30"); w.Code != http.StatusOK {
+		t.Fatalf("modify runtime failed, got %d: %s", w.Code, strings.TrimSpace(w.Body.String()))
+	}
+	defer doRequest(s, http.MethodPost, RuntimeModifyPath+"?admin_test.percent=")
+
+	w := doRequest(s, http.MethodGet, RuntimePath)
+	if w.Code != http.StatusOK {
+		t.Fatalf("runtime status code = %d", w.Code)
+	}
+
+	var info RuntimeInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("unmarshal runtime failed: %v", err)
+	}
+	if len(info.Layers) != 1 || info.Layers[0] != runtime.AdminLayer {
+		t.Errorf("unexpected runtime layers: %v", info.Layers)
+	}
+
+	if len(info.Entries) != 1 {
+		t.Fatalf("unexpected runtime entries: %+v", info.Entries)
+	}
+	if entry := info.Entries[0]; entry.Key != "admin_test.percent" || entry.FinalValue != "30" || len(entry.LayerValues) != 1 || entry.LayerValues[0] != "30" {
+		t.Errorf("unexpected runtime entry: %+v", entry)
+	}
+
+	if w := doRequest(s, http.MethodPost, RuntimeReloadPath); w.Code != http.StatusOK {
+		t.Errorf("reload runtime failed, got %d", w.Code)
+	}
+}
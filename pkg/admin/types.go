@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package admin
+
+// Admin api paths
+const (
+	ConfigDumpPath     = "/api/v1/config_dump"
+	ListenersPath      = "/api/v1/listeners"
+	ClustersPath       = "/api/v1/clusters"
+	StatsPath          = "/api/v1/stats"
+	LogLevelPath       = "/api/v1/update_loglevel"
+	DrainListenersPath = "/api/v1/drain_listeners"
+	RuntimePath        = "/api/v1/runtime"
+	RuntimeModifyPath  = "/api/v1/runtime_modify"
+	RuntimeReloadPath  = "/api/v1/runtime_reload"
+	XdsVersionsPath    = "/api/v1/xds_versions"
+
+	// PrometheusPath is the default metrics path scraped by prometheus
+	PrometheusPath = "/metrics"
+)
+
+// Health flag names reported by the clusters api
+const (
+	FailedActiveHealthCheck = "failed_active_hc"
+	FailedOutlierCheck      = "failed_outlier_check"
+)
+
+// ListenerInfo is the listener snapshot reported by the listeners api
+type ListenerInfo struct {
+	Name                    string   `json:"name"`
+	Address                 string   `json:"address"`
+	BindToPort              bool     `json:"bind_port"`
+	PerConnBufferLimitBytes uint32   `json:"per_conn_buffer_limit_bytes"`
+	NetworkFilters          []string `json:"network_filters,omitempty"`
+	StreamFilters           []string `json:"stream_filters,omitempty"`
+}
+
+// ClusterInfo is the cluster snapshot reported by the clusters api
+type ClusterInfo struct {
+	Name   string     `json:"name"`
+	LbType string     `json:"lb_type"`
+	Hosts  []HostInfo `json:"hosts"`
+}
+
+// HostInfo is the host snapshot reported by the clusters api
+type HostInfo struct {
+	Address     string   `json:"address"`
+	Hostname    string   `json:"hostname,omitempty"`
+	Priority    uint32   `json:"priority"`
+	Weight      uint32   `json:"weight"`
+	Healthy     bool     `json:"healthy"`
+	HealthFlags []string `json:"health_flags,omitempty"`
+}
+
+// MetricInfo is a metric snapshot reported by the stats api
+type MetricInfo struct {
+	Name  string  `json:"name"`
+	Type  string  `json:"type"`
+	Count int64   `json:"count"`
+	Value int64   `json:"value,omitempty"`
+	Min   int64   `json:"min,omitempty"`
+	Max   int64   `json:"max,omitempty"`
+	Mean  float64 `json:"mean,omitempty"`
+	P50   float64 `json:"p50,omitempty"`
+	P99   float64 `json:"p99,omitempty"`
+}
+
+// RuntimeInfo is the runtime snapshot reported by the runtime api
+type RuntimeInfo struct {
+	Layers  []string       `json:"layers"`
+	Entries []RuntimeEntry `json:"entries"`
+}
+
+// RuntimeEntry is a runtime value reported by the runtime api,
+// LayerValues are in the same order as the layers, empty if the layer has no such key
+type RuntimeEntry struct {
+	Key         string   `json:"key"`
+	FinalValue  string   `json:"final_value"`
+	LayerValues []string `json:"layer_values"`
+}
+
+// XdsVersionInfo is the discovery state of a resource type reported by the xds versions api,
+// Version is the last accepted version, ErrorDetail is set if the last response is rejected
+type XdsVersionInfo struct {
+	TypeURL     string `json:"type_url"`
+	Version     string `json:"version"`
+	Nonce       string `json:"nonce"`
+	ErrorDetail string `json:"error_detail,omitempty"`
+}
@@ -36,6 +36,21 @@ const (
 	X_PROXY                     = "x_proxy"
 )
 
+// Stats Sink's Name
+const (
+	STATSD_SINK = "statsd"
+)
+
+// Health Checker's Protocol, the others are the same as stream protocols
+const (
+	TCP_HEALTH_CHECK = "tcp"
+)
+
+// Trace Reporter's Name
+const (
+	ZIPKIN_REPORTER = "zipkin"
+)
+
 // ClusterType
 type ClusterType string
 
@@ -52,8 +67,11 @@ type LbType string
 
 // Group of load balancer type
 const (
-	LB_RANDOM     LbType = "LB_RANDOM"
-	LB_ROUNDROBIN LbType = "LB_ROUNDROBIN"
+	LB_RANDOM       LbType = "LB_RANDOM"
+	LB_ROUNDROBIN   LbType = "LB_ROUNDROBIN"
+	LB_RINGHASH     LbType = "LB_RINGHASH"
+	LB_MAGLEV       LbType = "LB_MAGLEV"
+	LB_LEASTREQUEST LbType = "LB_LEASTREQUEST"
 )
 
 // Cluster class
@@ -68,6 +86,7 @@ type Cluster struct {
 	HealthCheck          HealthCheck
 	Spec                 ClusterSpecInfo
 	LBSubSetConfig       LBSubsetConfig
+	ZoneAware            ZoneAware
 	TLS                  TLSConfig
 	Hosts                []Host
 }
@@ -101,6 +120,13 @@ type OutlierDetection struct {
 	SuccessRateStdevFactor             uint32
 }
 
+// ZoneAware prefers the hosts in the same zone as mosn, the local zone comes from the service registry application info.
+// The traffic falls back to all zones if the healthy percentage of hosts in the local zone is below FallbackThreshold.
+type ZoneAware struct {
+	Enable            bool
+	FallbackThreshold uint32
+}
+
 // RoutingPriority
 type RoutingPriority string
 
@@ -112,11 +138,14 @@ const (
 
 // Host with MetaData
 type Host struct {
-	Address    string
-	Hostname   string
-	Weight     uint32
-	MetaData   Metadata
-	TLSDisable bool
+	Address        string
+	Hostname       string
+	Weight         uint32
+	MetaData       Metadata
+	TLSDisable     bool
+	Priority       uint32
+	Locality       string // in the form of region/zone/sub_zone
+	LocalityWeight uint32 // the weight of the host's locality, locality weighted lb is enabled if it is not zero
 }
 
 // ListenerConfig with FilterChains
@@ -190,9 +219,77 @@ type RPCProxy struct {
 }
 
 // FaultInject
+// The faults are only injected into the requests matching all the Headers and routed to the UpstreamCluster if they are set.
+// The aborted requests are replied with AbortStatus, which is converted to a bolt status for sofa rpc
+// unless AbortBoltStatus is set.
+// In the network filter, the connections in ResetPercent are closed after ResetAfterBytes bytes are read and written,
+// or after ResetAfterInterval, and the connections in BlackHolePercent are accepted but never read.
 type FaultInject struct {
-	DelayPercent  uint32
-	DelayDuration uint64
+	DelayPercent    uint32
+	DelayDuration   uint64
+	AbortPercent    uint32
+	AbortStatus     int
+	AbortBoltStatus *int16
+	Headers         []HeaderMatcher
+	UpstreamCluster string
+
+	// used by the network filter only, the bandwidth is in bytes per second
+	ReadBandwidth      uint64
+	WriteBandwidth     uint64
+	ResetPercent       uint32
+	ResetAfterBytes    uint64
+	ResetAfterInterval time.Duration
+	BlackHolePercent   uint32
+}
+
+// RateLimitFilter limits the requests locally with token buckets,
+// the descriptors of a request are generated by the rate limits of the route and the virtual host in the same Stage.
+type RateLimitFilter struct {
+	StatPrefix string
+	Stage      uint32
+	Limits     []LocalRateLimit
+}
+
+// LocalRateLimit is a token bucket shared by the requests whose descriptor matches Descriptor.
+// An entry without value matches any value and each value has its own bucket, e.g. limits per remote address.
+type LocalRateLimit struct {
+	Descriptor    []RateLimitDescriptorEntry
+	MaxTokens     uint32
+	TokensPerFill uint32
+	FillInterval  time.Duration
+}
+
+// RateLimitDescriptorEntry is a key-value pair of the rate limit descriptor
+type RateLimitDescriptorEntry struct {
+	Key   string
+	Value string
+}
+
+// GlobalRateLimitFilter limits the requests by an external rate limit service speaking the envoy rls protocol,
+// the requests are allowed if the service fails or times out, unless FailureModeDeny is set.
+type GlobalRateLimitFilter struct {
+	Domain          string
+	Address         string
+	Stage           uint32
+	Timeout         time.Duration
+	FailureModeDeny bool
+	StatPrefix      string
+}
+
+// StatsdSink pushes metrics to a statsd server over udp
+// DogStatsD enables the DogStatsD tag extension, tags are parsed from metrics namespace
+type StatsdSink struct {
+	Address   string
+	Prefix    string
+	DogStatsD bool
+}
+
+// ZipkinReporter reports spans to a zipkin collector in json over http
+type ZipkinReporter struct {
+	CollectorEndpoint string
+	ServiceName       string
+	BatchSize         int
+	FlushInterval     time.Duration
 }
 
 // XProxyExtendConfig
@@ -207,7 +304,9 @@ type Proxy struct {
 	SupportDynamicRoute bool
 	BasicRoutes         []*BasicServiceRoute
 	VirtualHosts        []*VirtualHost
+	RouterConfigName    string // the routers are subscribed from RDS by the name if it is set, VirtualHosts are ignored
 	ValidateClusters    bool
+	InternalOnlyHeaders []string
 	ExtendConfig        map[string]interface{}
 }
 
@@ -219,10 +318,19 @@ type BasicServiceRoute struct {
 	RetryPolicy   *RetryPolicy
 }
 
+// RetryPolicy
+// The request is retried in the RetryConditions, 5xx by default, with exponential backoff and jitter.
+// The retries of a cluster are limited to RetryBudgetPercent of its active requests if it is set.
 type RetryPolicy struct {
-	RetryOn      bool
-	RetryTimeout time.Duration
-	NumRetries   uint32
+	RetryOn              bool
+	RetryTimeout         time.Duration
+	NumRetries           uint32
+	RetryConditions      []string
+	RetriableStatusCodes []uint32
+	BackOffBaseInterval  time.Duration
+	BackOffMaxInterval   time.Duration
+	RetryBudgetPercent   uint32
+	AvoidPreviousHosts   bool
 }
 
 // HealthCheck
@@ -236,6 +344,8 @@ type HealthCheck struct {
 	UnhealthyThreshold uint32
 	CheckPath          string
 	ServiceName        string
+	TCPSend            []byte   // used by tcp health check, connect only if empty
+	TCPReceive         [][]byte // used by tcp health check, each block should be found in the response in order
 }
 
 // HealthCheckFilter
@@ -264,7 +374,7 @@ type ApplicationInfo struct {
 	AntShareCloud bool
 	DataCenter    string
 	AppName       string
-	Zone          string
+	Zone          string // the zone mosn deployed in, used by zone aware routing
 }
 
 type PublishInfo struct {
@@ -304,16 +414,30 @@ type VirtualHost struct {
 	Routers         []Router
 	RequireTLS      string
 	VirtualClusters []VirtualCluster
+	RateLimits      []RateLimit
+	Cors            *CorsPolicy
+
+	RequestHeadersToAdd     []*HeaderValueOption
+	RequestHeadersToRemove  []string
+	ResponseHeadersToAdd    []*HeaderValueOption
+	ResponseHeadersToRemove []string
 }
 
 // Router, the list of routes that will be matched, in order, for incoming requests.
 // The first route that matches will be used.
 type Router struct {
-	Match     RouterMatch
-	Route     RouteAction
-	Redirect  RedirectAction
-	Metadata  Metadata
-	Decorator Decorator
+	Name           string
+	Match          RouterMatch
+	Route          RouteAction
+	Redirect       RedirectAction
+	DirectResponse *DirectResponseAction
+	Metadata       Metadata
+	Decorator      Decorator
+
+	RequestHeadersToAdd     []*HeaderValueOption
+	RequestHeadersToRemove  []string
+	ResponseHeadersToAdd    []*HeaderValueOption
+	ResponseHeadersToRemove []string
 }
 
 // Decorator
@@ -321,21 +445,43 @@ type Decorator string
 
 // RedirectAction
 // Return a redirect.
+// The route is a redirect route if any of the scheme, host, path and prefix is set.
+// PathRedirect replaces the whole path, while PrefixRewrite replaces the matched prefix of a prefix route.
 type RedirectAction struct {
-	HostRedirect string
-	PathRedirect string
-	ResponseCode uint32
+	SchemeRedirect string
+	HostRedirect   string
+	PathRedirect   string
+	PrefixRewrite  string
+	ResponseCode   uint32
+	StripQuery     bool
+}
+
+// DirectResponseAction
+// Return the status and the body directly without any upstream
+type DirectResponseAction struct {
+	StatusCode uint32
+	Body       string
+}
+
+// HeaderValueOption
+// The header to add, the value is appended to the existing value if Append is true, otherwise the header is set.
+// The value can contain request info variables, such as %DownstreamRemoteAddress%
+type HeaderValueOption struct {
+	Key    string
+	Value  string
+	Append bool
 }
 
 // RouterMatch
 // Route matching parameters
 type RouterMatch struct {
-	Prefix        string
-	Path          string
-	Regex         string
-	CaseSensitive bool
-	Runtime       RuntimeUInt32
-	Headers       []HeaderMatcher
+	Prefix          string
+	Path            string
+	Regex           string
+	CaseSensitive   bool
+	Runtime         RuntimeUInt32
+	Headers         []HeaderMatcher
+	QueryParameters []QueryParameterMatcher
 }
 
 // RouteAction
@@ -347,6 +493,78 @@ type RouteAction struct {
 	MetadataMatch    Metadata
 	Timeout          time.Duration
 	RetryPolicy      *RetryPolicy
+	HashPolicy       []HashPolicy
+	ShadowPolicy     *ShadowPolicy
+	RateLimits       []RateLimit
+	HedgePolicy      *HedgePolicy
+	PrefixRewrite    string
+	RegexRewrite     *RegexRewrite
+	HostRewrite      string
+	AutoHostRewrite  bool
+	Cors             *CorsPolicy
+}
+
+// CorsPolicy specifies the cross origin resource sharing of the virtual host or the route,
+// the route's policy takes precedence over the virtual host's one
+type CorsPolicy struct {
+	AllowOrigin      []string
+	AllowMethods     string
+	AllowHeaders     string
+	ExposeHeaders    string
+	MaxAge           string
+	AllowCredentials bool
+	Enabled          bool
+}
+
+// RegexRewrite rewrites the path, the matched parts of the pattern are replaced by the substitution,
+// which can refer to the capture groups like regexp.Regexp.ReplaceAllString
+type RegexRewrite struct {
+	Pattern      string
+	Substitution string
+}
+
+// RateLimit generates a descriptor for rate limiting, each action contributes an entry of the descriptor.
+// The descriptor is not generated if any of the actions can not be applied, e.g. the header is not present.
+type RateLimit struct {
+	Stage      uint32
+	DisableKey string
+	Actions    []RateLimitAction
+}
+
+// RateLimitAction, one of the actions should be set
+type RateLimitAction struct {
+	GenericKey         string // ("generic_key", GenericKey)
+	DestinationCluster bool   // ("destination_cluster", the cluster of the route)
+	RequestHeader      string // (DescriptorKey, the value of the header)
+	DescriptorKey      string
+	RemoteAddress      bool // ("remote_address", the downstream ip)
+	Service            bool // ("service", the service of sofa rpc)
+}
+
+// ShadowPolicy mirrors the requests to the shadow cluster in a fire and forget manner,
+// Percent of requests are mirrored and the responses are discarded.
+// The percent is read from the runtime key RuntimeKey if it is set.
+type ShadowPolicy struct {
+	Cluster    string
+	RuntimeKey string
+	Percent    uint32
+}
+
+// HedgePolicy sends a hedged request to another host if no response is received within the hedge delay,
+// the first response is used and the other request is reset.
+type HedgePolicy struct {
+	HedgeDelay time.Duration
+}
+
+// HashPolicy specifies the hash key used by consistent hash load balancers,
+// one of the header, the cookie and the source ip should be set.
+// If more than one policies are configured, the keys are combined.
+type HashPolicy struct {
+	Header     string
+	Cookie     string
+	CookiePath string
+	CookieTTL  time.Duration // the cookie will be generated if not present and the ttl is not zero
+	SourceIP   bool
 }
 
 // WeightedCluster.
@@ -355,7 +573,7 @@ type RouteAction struct {
 // clusters based on weights assigned to each cluster
 type WeightedCluster struct {
 	Cluster          ClusterWeight
-	RuntimeKeyPrefix string // not used currently
+	RuntimeKeyPrefix string // the weight is read from the runtime key RuntimeKeyPrefix.ClusterName if it is set
 }
 
 // ClusterWeight.
@@ -368,7 +586,8 @@ type ClusterWeight struct {
 }
 
 // RuntimeUInt32
-// Indicates that the route should additionally match on a runtime key
+// Indicates that the route should additionally match on a runtime key,
+// the percentage of requests matched is read from the RuntimeKey and defaults to DefaultValue
 type RuntimeUInt32 struct {
 	DefaultValue uint32
 	RuntimeKey   string
@@ -381,6 +600,14 @@ type HeaderMatcher struct {
 	Regex bool
 }
 
+// QueryParameterMatcher specifies a query parameter that the route should match on.
+// The value is matched exactly, or as a regex if Regex is true, an empty value matches any present parameter
+type QueryParameterMatcher struct {
+	Name  string
+	Value string
+	Regex bool
+}
+
 // VirtualCluster is a way of specifying a regex matching rule against certain important endpoints
 // such that statistics are generated explicitly for the matched requests
 type VirtualCluster struct {
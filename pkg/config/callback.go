@@ -18,19 +18,31 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/alipay/sofa-mosn/pkg/api/v2"
 	"github.com/alipay/sofa-mosn/pkg/filter"
 	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/router"
 	"github.com/alipay/sofa-mosn/pkg/server"
 	"github.com/alipay/sofa-mosn/pkg/types"
 	clusterAdapter "github.com/alipay/sofa-mosn/pkg/upstream/cluster"
 	pb "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 )
 
-// OnAddOrUpdateListeners called by XdsClient when listeners config refresh
-func (config *MOSNConfig) OnAddOrUpdateListeners(listeners []*pb.Listener) {
+// xdsListener is a listener converted from xds with the filter factories created
+type xdsListener struct {
+	config         *v2.ListenerConfig
+	networkFilters []types.NetworkFilterChainFactory
+	streamFilters  []types.StreamFilterChainFactory
+}
+
+// convertXdsListeners converts and validates the listeners, an error is returned if any listener is invalid.
+// The listeners not supported are skipped
+func convertXdsListeners(listeners []*pb.Listener) ([]*xdsListener, error) {
+	xdsListeners := make([]*xdsListener, 0, len(listeners))
+	names := make(map[string]bool, len(listeners))
 
 	for _, listener := range listeners {
 		mosnListener := convertListenerConfig(listener)
@@ -38,45 +50,86 @@ func (config *MOSNConfig) OnAddOrUpdateListeners(listeners []*pb.Listener) {
 			continue
 		}
 
-		var streamFilters []types.StreamFilterChainFactory
-		var networkFilters []types.NetworkFilterChainFactory
+		if names[mosnListener.Name] {
+			return nil, fmt.Errorf("xds listener name = %s is duplicated", mosnListener.Name)
+		}
+		names[mosnListener.Name] = true
+
+		if mosnListener.Addr == nil {
+			return nil, fmt.Errorf("xds listener address is invalid, listener name = %s", mosnListener.Name)
+		}
+
+		l := &xdsListener{
+			config: mosnListener,
+		}
 
 		if !mosnListener.HandOffRestoredDestinationConnections {
 			for _, filterChain := range mosnListener.FilterChains {
 				for _, f := range filterChain.Filters {
 					nfcf, err := filter.CreateNetworkFilterChainFactory(f.Name, f.Config, true)
 					if err != nil {
-						log.DefaultLogger.Errorf("parse network filter failed,error:", err.Error())
-						continue
+						return nil, fmt.Errorf("xds parse network filter failed, listener name = %s, error: %v", mosnListener.Name, err)
 					}
-					networkFilters = append(networkFilters, nfcf)
+					l.networkFilters = append(l.networkFilters, nfcf)
 				}
 			}
 
-			streamFilters = GetStreamFilters(mosnListener.StreamFilters)
+			if len(l.networkFilters) == 0 {
+				return nil, fmt.Errorf("xds client update listener error: proxy needed in network filters, listener name = %s", mosnListener.Name)
+			}
 
-			if len(networkFilters) == 0 {
-				log.DefaultLogger.Errorf("xds client update listener error: proxy needed in network filters")
-				continue
+			for _, f := range mosnListener.StreamFilters {
+				sfcf, err := filter.CreateStreamFilterChainFactory(f.Name, f.Config)
+				if err != nil {
+					return nil, fmt.Errorf("xds parse stream filter failed, listener name = %s, error: %v", mosnListener.Name, err)
+				}
+				l.streamFilters = append(l.streamFilters, sfcf)
 			}
 		}
 
-		if listenerAdapter := server.GetListenerAdapterInstance(); listenerAdapter == nil {
-			// if listenerAdapter is nil, return directly
-			log.DefaultLogger.Errorf("listenerAdapter is nil and hasn't been initiated at this time")
-			return
-		} else {
-			log.DefaultLogger.Debugf("listenerAdapter.AddOrUpdateListener called, with mosn Listener:%+v, networkFilters:%+v, streamFilters: %+v",
-				listeners, networkFilters, streamFilters)
+		xdsListeners = append(xdsListeners, l)
+	}
 
-			if err := listenerAdapter.AddOrUpdateListener("", mosnListener, networkFilters, streamFilters); err == nil {
-				log.DefaultLogger.Debugf("xds AddOrUpdateListener success,listener address = %s", mosnListener.Addr.String())
-			} else {
-				log.DefaultLogger.Errorf("xds AddOrUpdateListener failure,listener address = %s, msg = %s ",
-					mosnListener.Addr.String(), err.Error())
-			}
+	return xdsListeners, nil
+}
+
+// OnAddOrUpdateListeners called by XdsClient when listeners config refresh
+// All the listeners are validated before updating, none of them is updated if any listener is invalid
+func (config *MOSNConfig) OnAddOrUpdateListeners(listeners []*pb.Listener) error {
+	xdsListeners, err := convertXdsListeners(listeners)
+	if err != nil {
+		log.DefaultLogger.Errorf("xds OnAddOrUpdateListeners rejected, error: %v", err)
+		return err
+	}
+
+	if len(xdsListeners) == 0 {
+		return nil
+	}
+
+	listenerAdapter := server.GetListenerAdapterInstance()
+	if listenerAdapter == nil {
+		// if listenerAdapter is nil, return directly
+		log.DefaultLogger.Errorf("listenerAdapter is nil and hasn't been initiated at this time")
+		return errors.New("listenerAdapter is nil and hasn't been initiated at this time")
+	}
+
+	var errGlobal error
+
+	for _, l := range xdsListeners {
+		log.DefaultLogger.Debugf("listenerAdapter.AddOrUpdateListener called, with mosn Listener:%+v, networkFilters:%+v, streamFilters: %+v",
+			l.config, l.networkFilters, l.streamFilters)
+
+		if err := listenerAdapter.AddOrUpdateListener("", l.config, l.networkFilters, l.streamFilters); err == nil {
+			log.DefaultLogger.Debugf("xds AddOrUpdateListener success,listener address = %s", l.config.Addr.String())
+		} else {
+			log.DefaultLogger.Errorf("xds AddOrUpdateListener failure,listener address = %s, msg = %s ",
+				l.config.Addr.String(), err.Error())
+			errGlobal = fmt.Errorf("xds AddOrUpdateListener failure, listener address = %s, error: %v",
+				l.config.Addr.String(), err)
 		}
 	}
+
+	return errGlobal
 }
 
 func (config *MOSNConfig) OnDeleteListeners(listeners []*pb.Listener) {
@@ -101,27 +154,94 @@ func (config *MOSNConfig) OnDeleteListeners(listeners []*pb.Listener) {
 	}
 }
 
+// OnUpdateRoutes called by XdsClient when route configs refresh
+// The routers referenced by the listeners are swapped, the listeners are not rebuilt.
+// All the route configs are validated before updating, the previous routers are kept if any route config is invalid
+func (config *MOSNConfig) OnUpdateRoutes(routeConfigs []*pb.RouteConfiguration) error {
+	routersMap := make(map[string]types.Routers, len(routeConfigs))
+
+	for _, routeConfig := range routeConfigs {
+		if _, ok := routersMap[routeConfig.Name]; ok {
+			log.DefaultLogger.Errorf("xds OnUpdateRoutes rejected, router config name = %s is duplicated", routeConfig.Name)
+			return fmt.Errorf("xds OnUpdateRoutes rejected, router config name = %s is duplicated", routeConfig.Name)
+		}
+
+		routers, err := router.NewRouteMatcher(convertRouterConfig(routeConfig))
+		if err != nil {
+			log.DefaultLogger.Errorf("xds OnUpdateRoutes failed, router config name = %s, error: %v", routeConfig.Name, err)
+			return fmt.Errorf("xds OnUpdateRoutes failed, router config name = %s, error: %v", routeConfig.Name, err)
+		}
+		routersMap[routeConfig.Name] = routers
+	}
+
+	for name, routers := range routersMap {
+		router.RoutersManager.AddOrUpdateRouters(name, routers)
+		log.DefaultLogger.Debugf("xds OnUpdateRoutes success, router config name = %s", name)
+	}
+
+	return nil
+}
+
+// validateClusters checks the clusters converted from xds, an error is returned if any cluster is invalid
+func validateClusters(clusters []*v2.Cluster) error {
+	names := make(map[string]bool, len(clusters))
+
+	for _, cluster := range clusters {
+		if cluster.Name == "" {
+			return errors.New("xds cluster name is required")
+		}
+
+		if names[cluster.Name] {
+			return fmt.Errorf("xds cluster name = %s is duplicated", cluster.Name)
+		}
+		names[cluster.Name] = true
+	}
+
+	return nil
+}
+
 // OnUpdateClusters called by XdsClient when clusters config refresh
-// Can be used to update and add clusters
-func (config *MOSNConfig) OnUpdateClusters(clusters []*pb.Cluster) {
+// Can be used to update and add clusters.
+// All the clusters are validated before updating, none of them is updated if any cluster is invalid
+func (config *MOSNConfig) OnUpdateClusters(clusters []*pb.Cluster) error {
 	mosnClusters := convertClustersConfig(clusters)
 
+	if err := validateClusters(mosnClusters); err != nil {
+		log.DefaultLogger.Errorf("xds OnUpdateClusters rejected, error: %v", err)
+		return err
+	}
+
+	if len(mosnClusters) == 0 {
+		return nil
+	}
+
+	clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
+	if clusterMngAdapter == nil {
+		log.DefaultLogger.Errorf("xds OnUpdateClusters failed: clusterMngAdapter nil")
+		return errors.New("xds OnUpdateClusters failed: clusterMngAdapter nil")
+	}
+
+	var errGlobal error
+
 	for _, cluster := range mosnClusters {
 		log.DefaultLogger.Debugf("cluster: %+v\n", cluster)
 		var err error
 		if cluster.ClusterType == v2.EDS_CLUSTER {
-			err = clusterAdapter.GetClusterMngAdapterInstance().TriggerClusterAddOrUpdate(*cluster)
+			err = clusterMngAdapter.TriggerClusterAddOrUpdate(*cluster)
 		} else {
-			err = clusterAdapter.GetClusterMngAdapterInstance().TriggerClusterAndHostsAddOrUpdate(*cluster, cluster.Hosts)
+			err = clusterMngAdapter.TriggerClusterAndHostsAddOrUpdate(*cluster, cluster.Hosts)
 		}
 
 		if err != nil {
 			log.DefaultLogger.Errorf("xds OnUpdateClusters failed,cluster name = %s, error:", cluster.Name, err.Error())
+			errGlobal = fmt.Errorf("xds OnUpdateClusters failed, cluster name = %s, error: %v", cluster.Name, err)
 
 		} else {
 			log.DefaultLogger.Debugf("xds OnUpdateClusters success,cluster name = %s", cluster.Name)
 		}
 	}
+
+	return errGlobal
 }
 
 // OnDeleteClusters called by XdsClient when need to delete clusters
@@ -151,26 +271,34 @@ func (config *MOSNConfig) OnUpdateEndpoints(loadAssignments []*pb.ClusterLoadAss
 	for _, loadAssignment := range loadAssignments {
 		clusterName := loadAssignment.ClusterName
 
+		// the hosts of all localities are updated together, as the update replaces all hosts of the cluster
+		var hosts []v2.Host
 		for _, endpoints := range loadAssignment.Endpoints {
-			hosts := convertEndpointsConfig(&endpoints)
+			localityHosts := convertEndpointsConfig(&endpoints)
 			log.DefaultLogger.Debugf("xds client update endpoints: cluster: %s, priority: %d", loadAssignment.ClusterName, endpoints.Priority)
-			for index, host := range hosts {
+			for index, host := range localityHosts {
 				log.DefaultLogger.Debugf("host[%d] is : %+v", index, host)
 			}
+			hosts = append(hosts, localityHosts...)
+		}
 
-			clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
-			if clusterMngAdapter == nil {
-				log.DefaultLogger.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v:", hosts)
-				errGlobal = fmt.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v:", hosts)
-			}
+		if len(loadAssignment.Endpoints) == 0 {
+			continue
+		}
 
-			if err := clusterAdapter.GetClusterMngAdapterInstance().TriggerClusterHostUpdate(clusterName, hosts); err != nil {
-				log.DefaultLogger.Errorf("xds client update Error = %s, hosts are %+v:", err.Error(), hosts)
-				errGlobal = fmt.Errorf("xds client update Error = %s, hosts are %+v:", err.Error(), hosts)
+		clusterMngAdapter := clusterAdapter.GetClusterMngAdapterInstance()
+		if clusterMngAdapter == nil {
+			log.DefaultLogger.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v:", hosts)
+			errGlobal = fmt.Errorf("xds client update Error: clusterMngAdapter nil , hosts are %+v:", hosts)
+			continue
+		}
 
-			} else {
-				log.DefaultLogger.Debugf("xds client update host success,hosts are %+v:", hosts)
-			}
+		if err := clusterMngAdapter.TriggerClusterHostUpdate(clusterName, hosts); err != nil {
+			log.DefaultLogger.Errorf("xds client update Error = %s, hosts are %+v:", err.Error(), hosts)
+			errGlobal = fmt.Errorf("xds client update Error = %s, hosts are %+v:", err.Error(), hosts)
+
+		} else {
+			log.DefaultLogger.Debugf("xds client update host success,hosts are %+v:", hosts)
 		}
 	}
 
@@ -18,9 +18,16 @@
 package config
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/router"
+	clusterAdapter "github.com/alipay/sofa-mosn/pkg/upstream/cluster"
 	pb "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	xdscore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	xdsroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/gogo/protobuf/types"
 	"github.com/json-iterator/go"
 )
 
@@ -141,6 +148,79 @@ func TestMOSNConfig_OnUpdateClusters(t *testing.T) {
 	}
 }
 
+// the clusters are not updated if any cluster in the response is invalid
+func TestMOSNConfig_OnUpdateClustersInvalid(t *testing.T) {
+	cm := clusterAdapter.NewClusterManager(nil, nil, nil, true, false)
+	defer cm.RemovePrimaryCluster("callback_test_cluster")
+
+	config := &MOSNConfig{}
+	valid := &pb.Cluster{Name: "callback_test_cluster", Type: pb.Cluster_STATIC}
+
+	if err := config.OnUpdateClusters([]*pb.Cluster{valid, {Name: ""}}); err == nil {
+		t.Fatal("clusters with an invalid cluster should be rejected")
+	}
+	if cm.ClusterExist("callback_test_cluster") {
+		t.Fatal("valid cluster should not be added if any cluster is invalid")
+	}
+
+	if err := config.OnUpdateClusters([]*pb.Cluster{valid, valid}); err == nil {
+		t.Fatal("clusters with duplicate names should be rejected")
+	}
+	if cm.ClusterExist("callback_test_cluster") {
+		t.Fatal("valid cluster should not be added if any cluster is invalid")
+	}
+
+	if err := config.OnUpdateClusters([]*pb.Cluster{valid}); err != nil {
+		t.Fatalf("update clusters failed: %v", err)
+	}
+	if !cm.ClusterExist("callback_test_cluster") {
+		t.Error("valid cluster should be added")
+	}
+}
+
+// the listeners are not updated if any listener in the response is invalid
+func TestMOSNConfig_OnAddOrUpdateListenersInvalid(t *testing.T) {
+	valid := &pb.Listener{
+		Name: "virtual",
+		Address: xdscore.Address{
+			Address: &xdscore.Address_SocketAddress{
+				SocketAddress: &xdscore.SocketAddress{
+					Address:       "127.0.0.1",
+					PortSpecifier: &xdscore.SocketAddress_PortValue{PortValue: 15001},
+				},
+			},
+		},
+		UseOriginalDst: &types.BoolValue{Value: true},
+	}
+	invalid := &pb.Listener{
+		Name: "callback_test_invalid",
+		Address: xdscore.Address{
+			Address: &xdscore.Address_SocketAddress{
+				SocketAddress: &xdscore.SocketAddress{
+					Address:       "127.0.0.1",
+					PortSpecifier: &xdscore.SocketAddress_NamedPort{NamedPort: "http"},
+				},
+			},
+		},
+	}
+
+	if xdsListeners, err := convertXdsListeners([]*pb.Listener{valid}); err != nil || len(xdsListeners) != 1 {
+		t.Fatalf("expected valid listener converted, got %v, error: %v", xdsListeners, err)
+	}
+
+	// the listener adapter is not initiated, the update fails if any listener is applied
+	config := &MOSNConfig{}
+	err := config.OnAddOrUpdateListeners([]*pb.Listener{valid, invalid})
+	if err == nil || !strings.Contains(err.Error(), "callback_test_invalid") {
+		t.Errorf("expected listeners rejected by the invalid listener, got error: %v", err)
+	}
+
+	err = config.OnAddOrUpdateListeners([]*pb.Listener{valid, valid})
+	if err == nil || !strings.Contains(err.Error(), "duplicated") {
+		t.Errorf("expected listeners with duplicate names rejected, got error: %v", err)
+	}
+}
+
 func TestMOSNConfig_OnDeleteListeners(t *testing.T) {
 	type fields struct {
 		Servers             []ServerConfig
@@ -204,3 +284,73 @@ func TestMOSNConfig_OnAddOrUpdateListeners(t *testing.T) {
 		})
 	}
 }
+
+func TestMOSNConfig_OnUpdateRoutes(t *testing.T) {
+	newRouteConfig := func(cluster string, domains ...string) *pb.RouteConfiguration {
+		return &pb.RouteConfiguration{
+			Name: "callback_test_routes",
+			VirtualHosts: []xdsroute.VirtualHost{
+				{
+					Name:    "vh",
+					Domains: domains,
+					Routes: []xdsroute.Route{
+						{
+							Match: xdsroute.RouteMatch{
+								PathSpecifier: &xdsroute.RouteMatch_Prefix{Prefix: "/"},
+							},
+							Action: &xdsroute.Route_Route{
+								Route: &xdsroute.RouteAction{
+									ClusterSpecifier: &xdsroute.RouteAction_Cluster{Cluster: cluster},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	headers := map[string]string{
+		protocol.MosnHeaderHostKey: "test.com",
+		protocol.MosnHeaderPathKey: "/",
+	}
+	// the routers are referenced by the proxy before the routes are received
+	routers := router.RoutersManager.GetRouters("callback_test_routes")
+	if routers.Route(headers, 1) != nil {
+		t.Fatal("routers should route nothing before the routes are received")
+	}
+
+	config := &MOSNConfig{}
+	if err := config.OnUpdateRoutes([]*pb.RouteConfiguration{newRouteConfig("c1", "*")}); err != nil {
+		t.Fatalf("update routes failed: %v", err)
+	}
+	if route := routers.Route(headers, 1); route == nil || route.RouteRule().ClusterName() != "c1" {
+		t.Fatalf("expected routed to c1, got %v", route)
+	}
+
+	if err := config.OnUpdateRoutes([]*pb.RouteConfiguration{newRouteConfig("c2", "*")}); err != nil {
+		t.Fatalf("update routes failed: %v", err)
+	}
+	if route := routers.Route(headers, 1); route == nil || route.RouteRule().ClusterName() != "c2" {
+		t.Fatalf("expected routed to c2 after the routes are swapped, got %v", route)
+	}
+
+	// the invalid routes are rejected and the previous routers are kept
+	if err := config.OnUpdateRoutes([]*pb.RouteConfiguration{newRouteConfig("c3", "*", "*")}); err == nil {
+		t.Fatal("routes with duplicate domains should be rejected")
+	}
+	if route := routers.Route(headers, 1); route == nil || route.RouteRule().ClusterName() != "c2" {
+		t.Errorf("expected previous routers are kept, got %v", route)
+	}
+
+	// none of the route configs is updated if any of them is invalid
+	otherConfig := newRouteConfig("c4", "*")
+	otherConfig.Name = "callback_test_other_routes"
+	otherRouters := router.RoutersManager.GetRouters("callback_test_other_routes")
+	if err := config.OnUpdateRoutes([]*pb.RouteConfiguration{otherConfig, newRouteConfig("c3", "*", "*")}); err == nil {
+		t.Fatal("route configs with an invalid route config should be rejected")
+	}
+	if route := otherRouters.Route(headers, 1); route != nil {
+		t.Errorf("expected valid route config not updated, got %v", route)
+	}
+}
@@ -55,7 +55,9 @@ type Proxy struct {
 	SupportDynamicRoute bool                    `json:"support_dynamic_route"`
 	BasicRoutes         []*v2.BasicServiceRoute `json:"basic_routes"` //not used anymore. todo: delete related logic
 	VirtualHosts        []*VirtualHost          `json:"virtual_hosts"`
+	RouterConfigName    string                  `json:"router_config_name,omitempty"` //routers are subscribed from RDS if it is set
 	ValidateClusters    bool                    `json:"validate_clusters"`
+	InternalOnlyHeaders []string                `json:"internal_only_headers,omitempty"`
 	ExtendConfig        map[string]interface{}  `json:"extend_config"`
 }
 
@@ -67,6 +69,13 @@ type VirtualHost struct {
 	Routers         []Router         `json:"routers"`
 	RequireTLS      string           `json:"require_tls"`
 	VirtualClusters []VirtualCluster `json:"virtual_clusters"`
+	RateLimits      []RateLimit      `json:"rate_limits,omitempty"`
+	Cors            *CorsPolicy      `json:"cors,omitempty"`
+
+	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
+	RequestHeadersToRemove  []string             `json:"request_headers_to_remove,omitempty"`
+	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
+	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
 }
 
 // VirtualCluster is a way of specifying a regex matching rule against certain important endpoints
@@ -80,11 +89,18 @@ type VirtualCluster struct {
 // Router, the list of routes that will be matched, in order, for incoming requests.
 // The first route that matches will be used.
 type Router struct {
-	Match     RouterMatch    `json:"match"`
-	Route     RouteAction    `json:"route"`
-	Redirect  RedirectAction `json:"redirect"`
-	Metadata  Metadata       `json:"metadata"`
-	Decorator Decorator      `json:"decorator"`
+	Name           string                `json:"name,omitempty"`
+	Match          RouterMatch           `json:"match"`
+	Route          RouteAction           `json:"route"`
+	Redirect       RedirectAction        `json:"redirect"`
+	DirectResponse *DirectResponseAction `json:"direct_response,omitempty"`
+	Metadata       Metadata              `json:"metadata"`
+	Decorator      Decorator             `json:"decorator"`
+
+	RequestHeadersToAdd     []*HeaderValueOption `json:"request_headers_to_add,omitempty"`
+	RequestHeadersToRemove  []string             `json:"request_headers_to_remove,omitempty"`
+	ResponseHeadersToAdd    []*HeaderValueOption `json:"response_headers_to_add,omitempty"`
+	ResponseHeadersToRemove []string             `json:"response_headers_to_remove,omitempty"`
 }
 
 // Decorator
@@ -92,21 +108,42 @@ type Decorator string
 
 // RedirectAction
 // Return a redirect.
+// The response code is one of 301, 302, 303, 307 and 308, 301 is used if not set.
 type RedirectAction struct {
-	HostRedirect string `json:"host_redirect"`
-	PathRedirect string `json:"path_redirect"`
-	ResponseCode uint32 `json:"response_code"`
+	SchemeRedirect string `json:"scheme_redirect,omitempty"`
+	HostRedirect   string `json:"host_redirect"`
+	PathRedirect   string `json:"path_redirect"`
+	PrefixRewrite  string `json:"prefix_rewrite,omitempty"`
+	ResponseCode   uint32 `json:"response_code"`
+	StripQuery     bool   `json:"strip_query,omitempty"`
+}
+
+// DirectResponseAction
+// Return the status and the body directly without any upstream
+type DirectResponseAction struct {
+	Status uint32 `json:"status"`
+	Body   string `json:"body,omitempty"`
+}
+
+// HeaderValueOption
+// The header to add, the value is appended to the existing value by default, set append to false to overwrite it.
+// The value can contain request info variables, such as %DownstreamRemoteAddress%
+type HeaderValueOption struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Append *bool  `json:"append,omitempty"`
 }
 
 // RouterMatch
 // Route matching parameters
 type RouterMatch struct {
-	Prefix        string          `json:"prefix"`
-	Path          string          `json:"path"`
-	Regex         string          `json:"regex"`
-	CaseSensitive bool            `json:"case_sensitive"`
-	Runtime       RuntimeUInt32   `json:"runtime"`
-	Headers       []HeaderMatcher `json:"headers"`
+	Prefix          string                  `json:"prefix"`
+	Path            string                  `json:"path"`
+	Regex           string                  `json:"regex"`
+	CaseSensitive   bool                    `json:"case_sensitive"`
+	Runtime         RuntimeUInt32           `json:"runtime"`
+	Headers         []HeaderMatcher         `json:"headers"`
+	QueryParameters []QueryParameterMatcher `json:"query_parameters,omitempty"`
 }
 
 // HeaderMatcher specifies a set of headers that the route should match on.
@@ -116,6 +153,14 @@ type HeaderMatcher struct {
 	Regex bool   `json:"regex"`
 }
 
+// QueryParameterMatcher specifies a query parameter that the route should match on.
+// The value is matched exactly, or as a regex if regex is true, an empty value matches any present parameter
+type QueryParameterMatcher struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+	Regex bool   `json:"regex,omitempty"`
+}
+
 // RuntimeUInt32
 // Indicates that the route should additionally match on a runtime key
 type RuntimeUInt32 struct {
@@ -132,6 +177,116 @@ type RouteAction struct {
 	MetadataMatch    Metadata          `json:"metadata_match"`
 	Timeout          time.Duration     `json:"timeout"`
 	RetryPolicy      *RetryPolicy      `json:"retry_policy"`
+	HashPolicy       []HashPolicy      `json:"hash_policy,omitempty"`
+	ShadowPolicy     *ShadowPolicy     `json:"shadow_policy,omitempty"`
+	RateLimits       []RateLimit       `json:"rate_limits,omitempty"`
+	HedgePolicy      *HedgePolicy      `json:"hedge_policy,omitempty"`
+	PrefixRewrite    string            `json:"prefix_rewrite,omitempty"`
+	RegexRewrite     *RegexRewrite     `json:"regex_rewrite,omitempty"`
+	HostRewrite      string            `json:"host_rewrite,omitempty"`
+	AutoHostRewrite  bool              `json:"auto_host_rewrite,omitempty"`
+	Cors             *CorsPolicy       `json:"cors,omitempty"`
+}
+
+// CorsPolicy
+// Cross origin resource sharing policy, "*" in allow_origin allows any origin.
+// The policy is enabled by default, max_age is in seconds
+type CorsPolicy struct {
+	AllowOrigin      []string `json:"allow_origin"`
+	AllowMethods     string   `json:"allow_methods,omitempty"`
+	AllowHeaders     string   `json:"allow_headers,omitempty"`
+	ExposeHeaders    string   `json:"expose_headers,omitempty"`
+	MaxAge           string   `json:"max_age,omitempty"`
+	AllowCredentials bool     `json:"allow_credentials,omitempty"`
+	Enabled          *bool    `json:"enabled,omitempty"`
+}
+
+// RegexRewrite
+// Rewrites the path by replacing the matched parts of the pattern with the substitution,
+// the substitution can refer to the capture groups, such as $1
+type RegexRewrite struct {
+	Pattern      string `json:"pattern"`
+	Substitution string `json:"substitution"`
+}
+
+// RateLimit
+// Generates a descriptor for the rate limit filter, each action contributes an entry of the descriptor
+type RateLimit struct {
+	Stage      uint32            `json:"stage,omitempty"`
+	DisableKey string            `json:"disable_key,omitempty"`
+	Actions    []RateLimitAction `json:"actions"`
+}
+
+// RateLimitAction
+// One of the actions is required
+type RateLimitAction struct {
+	GenericKey         *GenericKeyAction         `json:"generic_key,omitempty"`
+	DestinationCluster *DestinationClusterAction `json:"destination_cluster,omitempty"`
+	RequestHeaders     *RequestHeadersAction     `json:"request_headers,omitempty"`
+	RemoteAddress      *RemoteAddressAction      `json:"remote_address,omitempty"`
+	Service            *ServiceAction            `json:"service,omitempty"`
+}
+
+// GenericKeyAction generates the entry ("generic_key", descriptor_value)
+type GenericKeyAction struct {
+	DescriptorValue string `json:"descriptor_value"`
+}
+
+// DestinationClusterAction generates the entry ("destination_cluster", the cluster of the route)
+type DestinationClusterAction struct{}
+
+// RequestHeadersAction generates the entry (descriptor_key, the value of the header)
+type RequestHeadersAction struct {
+	HeaderName    string `json:"header_name"`
+	DescriptorKey string `json:"descriptor_key"`
+}
+
+// RemoteAddressAction generates the entry ("remote_address", the downstream ip)
+type RemoteAddressAction struct{}
+
+// ServiceAction generates the entry ("service", the service of sofa rpc request)
+type ServiceAction struct{}
+
+// ShadowPolicy
+// Mirrors the requests to the shadow cluster, the responses of the shadow cluster are discarded.
+// Percent is the percentage of requests to be mirrored, all requests are mirrored if it is not set.
+type ShadowPolicy struct {
+	Cluster    string  `json:"cluster"`
+	RuntimeKey string  `json:"runtime_key,omitempty"`
+	Percent    *uint32 `json:"percent,omitempty"`
+}
+
+// HedgePolicy
+// Sends a hedged request to another host if no response is received within the hedge delay,
+// it is intended for idempotent requests only
+type HedgePolicy struct {
+	HedgeDelay DurationConfig `json:"hedge_delay"`
+}
+
+// HashPolicy
+// Specifies the hash key used by consistent hash load balancers
+type HashPolicy struct {
+	Header               *HeaderHashPolicy     `json:"header,omitempty"`
+	Cookie               *CookieHashPolicy     `json:"cookie,omitempty"`
+	ConnectionProperties *ConnectionHashPolicy `json:"connection_properties,omitempty"`
+}
+
+// HeaderHashPolicy hashes on the value of the request header
+type HeaderHashPolicy struct {
+	HeaderName string `json:"header_name"`
+}
+
+// CookieHashPolicy hashes on the value of the cookie,
+// the cookie will be generated if not present and the ttl is set
+type CookieHashPolicy struct {
+	Name string         `json:"name"`
+	Path string         `json:"path,omitempty"`
+	TTL  DurationConfig `json:"ttl,omitempty"`
+}
+
+// ConnectionHashPolicy hashes on the properties of the downstream connection
+type ConnectionHashPolicy struct {
+	SourceIP bool `json:"source_ip"`
 }
 
 // WeightedCluster.
@@ -140,7 +295,7 @@ type RouteAction struct {
 // clusters based on weights assigned to each cluster
 type WeightedCluster struct {
 	Cluster          ClusterWeight `json:"cluster"`
-	RuntimeKeyPrefix string        `json:"runtime_key_prefix"` // not used currently
+	RuntimeKeyPrefix string        `json:"runtime_key_prefix"` // the weight is read from the runtime key runtime_key_prefix.cluster_name
 }
 
 // ClusterWeight.
@@ -159,10 +314,63 @@ type FilterConfig struct {
 	Config map[string]interface{} `json:"config,omitempty"`
 }
 
+// RateLimitFilterConfig for the local rate limit stream filter
+// Limits are token buckets for the requests with matched descriptors, the request is limited if any bucket is empty.
+// The stats of the filter are in the namespace ratelimit.<stat_prefix>
+type RateLimitFilterConfig struct {
+	StatPrefix string                 `json:"stat_prefix,omitempty"`
+	Stage      uint32                 `json:"stage,omitempty"`
+	Limits     []LocalRateLimitConfig `json:"limits"`
+}
+
+// LocalRateLimitConfig
+// The bucket is filled with tokens_per_fill tokens every fill_interval, up to max_tokens.
+// tokens_per_fill is max_tokens by default. The descriptor entry without value matches any value
+// and each value has its own bucket.
+type LocalRateLimitConfig struct {
+	Descriptor    []RateLimitDescriptorEntry `json:"descriptor"`
+	MaxTokens     uint32                     `json:"max_tokens"`
+	TokensPerFill uint32                     `json:"tokens_per_fill,omitempty"`
+	FillInterval  DurationConfig             `json:"fill_interval"`
+}
+
+// RateLimitDescriptorEntry
+type RateLimitDescriptorEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+// GlobalRateLimitFilterConfig for the global rate limit stream filter
+// The descriptors are sent to the rate limit service at address in the domain, the timeout is 20ms by default.
+// The stats of the filter are in the namespace globalratelimit.<stat_prefix>
+type GlobalRateLimitFilterConfig struct {
+	Domain          string         `json:"domain"`
+	Address         string         `json:"address"`
+	Stage           uint32         `json:"stage,omitempty"`
+	Timeout         DurationConfig `json:"timeout,omitempty"`
+	FailureModeDeny bool           `json:"failure_mode_deny,omitempty"`
+	StatPrefix      string         `json:"stat_prefix,omitempty"`
+}
+
+// RetryPolicy
+// retry_conditions are the names of types.RetryConditionNames, e.g. "5xx", "connect-failure", "server-busy".
 type RetryPolicy struct {
-	RetryOn      bool          `json:"retry_on"`
-	RetryTimeout time.Duration `json:"retry_timeout"`
-	NumRetries   uint32        `json:"num_retries"`
+	RetryOn              bool          `json:"retry_on"`
+	RetryTimeout         time.Duration `json:"retry_timeout"`
+	NumRetries           uint32        `json:"num_retries"`
+	RetryConditions      []string      `json:"retry_conditions,omitempty"`
+	RetriableStatusCodes []uint32      `json:"retriable_status_codes,omitempty"`
+	BackOff              *RetryBackOff `json:"back_off,omitempty"`
+	RetryBudgetPercent   uint32        `json:"retry_budget_percent,omitempty"`
+	AvoidPreviousHosts   bool          `json:"avoid_previous_hosts,omitempty"`
+}
+
+// RetryBackOff
+// The retry is delayed by a random interval in [0, base_interval * 2^retries), no more than max_interval.
+// base_interval is 25ms by default and max_interval is 10 times base_interval by default.
+type RetryBackOff struct {
+	BaseInterval DurationConfig `json:"base_interval,omitempty"`
+	MaxInterval  DurationConfig `json:"max_interval,omitempty"`
 }
 
 // AccessLogConfig for making up access log
@@ -228,11 +436,14 @@ type ServerConfig struct {
 
 // HostConfig
 type HostConfig struct {
-	Address    string   `json:"address,omitempty"`
-	Hostname   string   `json:"hostname,omitempty"`
-	Weight     uint32   `json:"weight,omitempty"`
-	MetaData   Metadata `json:"metadata"`
-	TLSDisable bool     `json:"tls_disable"`
+	Address        string   `json:"address,omitempty"`
+	Hostname       string   `json:"hostname,omitempty"`
+	Weight         uint32   `json:"weight,omitempty"`
+	MetaData       Metadata `json:"metadata"`
+	TLSDisable     bool     `json:"tls_disable"`
+	Priority       uint32   `json:"priority,omitempty"`
+	Locality       string   `json:"locality,omitempty"`
+	LocalityWeight uint32   `json:"locality_weight,omitempty"`
 }
 
 // ClusterHealthCheckConfig for health checking
@@ -245,6 +456,23 @@ type ClusterHealthCheckConfig struct {
 	UnhealthyThreshold uint32         `json:"unhealthy_threshold"`
 	CheckPath          string         `json:"check_path,omitempty"`
 	ServiceName        string         `json:"service_name,omitempty"`
+	TCPSend            string         `json:"tcp_send,omitempty"`    // hex encoded payload
+	TCPReceive         []string       `json:"tcp_receive,omitempty"` // hex encoded payloads
+}
+
+// OutlierDetectionConfig for ejecting the failing hosts passively, disabled if not configured
+type OutlierDetectionConfig struct {
+	Consecutive5xx                     uint32         `json:"consecutive_5xx,omitempty"`
+	Interval                           DurationConfig `json:"interval,omitempty"`
+	BaseEjectionTime                   DurationConfig `json:"base_ejection_time,omitempty"`
+	MaxEjectionPercent                 uint32         `json:"max_ejection_percent,omitempty"`
+	ConsecutiveGatewayFailure          uint32         `json:"consecutive_gateway_failure,omitempty"`
+	EnforcingConsecutive5xx            uint32         `json:"enforcing_consecutive_5xx,omitempty"`
+	EnforcingConsecutiveGatewayFailure uint32         `json:"enforcing_consecutive_gateway_failure,omitempty"`
+	EnforcingSuccessRate               uint32         `json:"enforcing_success_rate,omitempty"`
+	SuccessRateMinimumHosts            uint32         `json:"success_rate_minimum_hosts,omitempty"`
+	SuccessRateRequestVolume           uint32         `json:"success_rate_request_volume,omitempty"`
+	SuccessRateStdevFactor             uint32         `json:"success_rate_stdev_factor,omitempty"`
 }
 
 // ClusterSpecConfig
@@ -268,10 +496,12 @@ type ClusterConfig struct {
 	MaxRequestPerConn    uint32                   `json:"max_request_per_conn"`
 	ConnBufferLimitBytes uint32                   `json:"conn_buffer_limit_bytes"`
 	CircuitBreakers      []*CircuitBreakerConfig  `json:"circuit_breakers"`
+	OutlierDetection     OutlierDetectionConfig   `json:"outlier_detection,omitempty"`
 	HealthCheck          ClusterHealthCheckConfig `json:"health_check,omitempty"`
 	ClusterSpecConfig    ClusterSpecConfig        `json:"spec,omitempty"` //	ClusterSpecConfig
 	Hosts                []HostConfig             `json:"hosts,omitempty"`
 	LBSubsetConfig       LBSubsetConfig           `json:"lb_subset_config"`
+	ZoneAware            ZoneAwareConfig          `json:"zone_aware,omitempty"`
 	TLS                  TLSConfig                `json:"tls_context,omitempty"`
 }
 
@@ -281,6 +511,13 @@ type LBSubsetConfig struct {
 	SubsetSelectors [][]string        `json:"subset_selectors"`
 }
 
+// ZoneAwareConfig prefers the hosts in the local zone, the zone of a host is taken from its metadata "zone",
+// or the zone of its locality. FallbackThreshold is the minimum healthy percentage of hosts in the local zone, 50 by default
+type ZoneAwareConfig struct {
+	Enable            bool   `json:"enable,omitempty"`
+	FallbackThreshold uint32 `json:"fallback_threshold,omitempty"`
+}
+
 // CircuitBreakerConfig for realizing circuit breaker for cluster
 type CircuitBreakerConfig struct {
 	Priority           string `json:"priority"`
@@ -300,6 +537,13 @@ type ClusterManagerConfig struct {
 	Clusters               []ClusterConfig `json:"clusters,omitempty"`
 }
 
+// StatsSinkConfig for making up a stats sink
+// Type is the sink's type
+type StatsSinkConfig struct {
+	Type   string                 `json:"type"`
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
 // ServiceRegistryConfig
 // not used currently
 type ServiceRegistryConfig struct {
@@ -312,6 +556,7 @@ type ServiceAppInfoConfig struct {
 	AntShareCloud bool   `json:"ant_share_cloud"`
 	DataCenter    string `json:"data_center,omitempty"`
 	AppName       string `json:"app_name,omitempty"`
+	Zone          string `json:"zone,omitempty"`
 }
 
 // ServicePubInfoConfig
@@ -332,16 +577,45 @@ type TCPProxyConfig struct {
 	Routes []TCPRouteConfig `json:"routes,omitempty"`
 }
 
+// AdminConfig for the admin api server
+// Address is the listen address of the admin server, admin server is disabled if it is empty
+type AdminConfig struct {
+	Address string `json:"address,omitempty"`
+}
+
+// TracingConfig for the tracing driver
+// SampleRate is the fraction of new traces which are sampled, all traces are sampled if it is not set
+// Reporter is the type of the span reporter, Config is passed to the reporter
+type TracingConfig struct {
+	Enable     bool                   `json:"enable,omitempty"`
+	SampleRate float64                `json:"sample_rate,omitempty"`
+	Reporter   string                 `json:"reporter,omitempty"`
+	Config     map[string]interface{} `json:"config"`
+}
+
+// RuntimeConfig for the runtime values
+// SymlinkRoot is the root directory of the disk layers, the runtime has the admin layer only if it is empty
+// Subdirectory is the disk layer, OverrideSubdirectory is the override layer, both are relative to the SymlinkRoot
+type RuntimeConfig struct {
+	SymlinkRoot          string `json:"symlink_root,omitempty"`
+	Subdirectory         string `json:"subdirectory,omitempty"`
+	OverrideSubdirectory string `json:"override_subdirectory,omitempty"`
+}
+
 // MOSNConfig make up mosn to start the mosn project
 // Servers contains the listener, filter and so on
 // ClusterManager used to manage the upstream
 type MOSNConfig struct {
-	Servers         []ServerConfig        `json:"servers,omitempty"`         //server config
-	ClusterManager  ClusterManagerConfig  `json:"cluster_manager,omitempty"` //cluster config
-	ServiceRegistry ServiceRegistryConfig `json:"service_registry"`          //service registry config, used by service discovery module
-	//tracing config
-	RawDynamicResources jsoniter.RawMessage `json:"dynamic_resources,omitempty"` //dynamic_resources raw message
-	RawStaticResources  jsoniter.RawMessage `json:"static_resources,omitempty"`  //static_resources raw message
+	Servers             []ServerConfig        `json:"servers,omitempty"`              //server config
+	ClusterManager      ClusterManagerConfig  `json:"cluster_manager,omitempty"`      //cluster config
+	StatsSinks          []StatsSinkConfig     `json:"stats_sinks,omitempty"`          //stats sinks config, metrics are pushed to sinks
+	StatsFlushInterval  DurationConfig        `json:"stats_flush_interval,omitempty"` //interval of flushing metrics to stats sinks
+	ServiceRegistry     ServiceRegistryConfig `json:"service_registry"`               //service registry config, used by service discovery module
+	Admin               AdminConfig           `json:"admin,omitempty"`                //admin api server config
+	Tracing             TracingConfig         `json:"tracing,omitempty"`              //tracing config
+	Runtime             RuntimeConfig         `json:"runtime,omitempty"`              //runtime config, the runtime values are loaded from disk
+	RawDynamicResources jsoniter.RawMessage   `json:"dynamic_resources,omitempty"`    //dynamic_resources raw message
+	RawStaticResources  jsoniter.RawMessage   `json:"static_resources,omitempty"`     //static_resources raw message
 }
 
 // Mode is mosn's starting type
@@ -18,6 +18,8 @@
 package config
 
 import (
+	"encoding/hex"
+
 	"github.com/alipay/sofa-mosn/pkg/api/v2"
 	"github.com/alipay/sofa-mosn/pkg/log"
 )
@@ -44,6 +46,7 @@ func ResetServiceRegistryInfo(appInfo v2.ApplicationInfo, subServiceList []strin
 		AntShareCloud: appInfo.AntShareCloud,
 		DataCenter:    appInfo.DataCenter,
 		AppName:       appInfo.AppName,
+		Zone:          appInfo.Zone,
 	}
 
 	// reset servicePubInfo
@@ -149,11 +152,32 @@ func convertClusterConfig(cluster v2.Cluster) ClusterConfig {
 		LbType:               string(cluster.LbType),
 		MaxRequestPerConn:    cluster.MaxRequestPerConn,
 		ConnBufferLimitBytes: cluster.ConnBufferLimitBytes,
+		OutlierDetection:     convertOutlierDetectionConfig(cluster.OutlierDetection),
+		ZoneAware: ZoneAwareConfig{
+			Enable:            cluster.ZoneAware.Enable,
+			FallbackThreshold: cluster.ZoneAware.FallbackThreshold,
+		},
 		HealthCheck:          convertClusterHealthCheck(cluster.HealthCheck),
 		ClusterSpecConfig:    convertClusterSpec(cluster.Spec),
 	}
 }
 
+func convertOutlierDetectionConfig(od v2.OutlierDetection) OutlierDetectionConfig {
+	return OutlierDetectionConfig{
+		Consecutive5xx:                     od.Consecutive5xx,
+		Interval:                           DurationConfig{od.Interval},
+		BaseEjectionTime:                   DurationConfig{od.BaseEjectionTime},
+		MaxEjectionPercent:                 od.MaxEjectionPercent,
+		ConsecutiveGatewayFailure:          od.ConsecutiveGatewayFailure,
+		EnforcingConsecutive5xx:            od.EnforcingConsecutive5xx,
+		EnforcingConsecutiveGatewayFailure: od.EnforcingConsecutiveGatewayFailure,
+		EnforcingSuccessRate:               od.EnforcingSuccessRate,
+		SuccessRateMinimumHosts:            od.SuccessRateMinimumHosts,
+		SuccessRateRequestVolume:           od.SuccessRateRequestVolume,
+		SuccessRateStdevFactor:             od.SuccessRateStdevFactor,
+	}
+}
+
 func convertClusterSpec(clusterSpec v2.ClusterSpecInfo) ClusterSpecConfig {
 	var specs []SubscribeSpecConfig
 
@@ -170,6 +194,10 @@ func convertClusterSpec(clusterSpec v2.ClusterSpecInfo) ClusterSpecConfig {
 
 // used to convert config's hc to v2 api
 func convertClusterHealthCheck(cchc v2.HealthCheck) ClusterHealthCheckConfig {
+	var receive []string
+	for _, r := range cchc.TCPReceive {
+		receive = append(receive, hex.EncodeToString(r))
+	}
 
 	return ClusterHealthCheckConfig{
 		Protocol:           cchc.Protocol,
@@ -180,6 +208,8 @@ func convertClusterHealthCheck(cchc v2.HealthCheck) ClusterHealthCheckConfig {
 		IntervalJitter:     DurationConfig{cchc.IntervalJitter},
 		CheckPath:          cchc.CheckPath,
 		ServiceName:        cchc.ServiceName,
+		TCPSend:            hex.EncodeToString(cchc.TCPSend),
+		TCPReceive:         receive,
 	}
 }
 
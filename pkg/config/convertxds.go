@@ -18,8 +18,10 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
 	"net"
+	"net/http"
 	"strings"
 	"time"
 
@@ -144,14 +146,19 @@ func convertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.H
 			continue
 		}
 		host := v2.Host{
-			Address:  address,
-			MetaData: convertMeta(xdsHost.Metadata),
+			Address:        address,
+			MetaData:       convertMeta(xdsHost.Metadata),
+			Priority:       xdsEndpoint.GetPriority(),
+			Locality:       convertLocality(xdsEndpoint.GetLocality()),
+			LocalityWeight: xdsEndpoint.GetLoadBalancingWeight().GetValue(),
 		}
 
 		if weight := xdsHost.GetLoadBalancingWeight().GetValue(); weight < MinHostWeight {
 			host.Weight = MinHostWeight
 		} else if weight > MaxHostWeight {
 			host.Weight = MaxHostWeight
+		} else {
+			host.Weight = weight
 		}
 
 		hosts = append(hosts, host)
@@ -159,6 +166,14 @@ func convertEndpointsConfig(xdsEndpoint *xdsendpoint.LocalityLbEndpoints) []v2.H
 	return hosts
 }
 
+func convertLocality(xdsLocality *xdscore.Locality) string {
+	if xdsLocality == nil {
+		return ""
+	}
+
+	return strings.Join([]string{xdsLocality.GetRegion(), xdsLocality.GetZone(), xdsLocality.GetSubZone()}, "/")
+}
+
 // todo: more filter type support
 func isSupport(xdsListener *xdsapi.Listener) bool {
 	if xdsListener == nil {
@@ -288,6 +303,8 @@ func convertFilterConfig(name string, s *types.Struct) map[string]interface{} {
 			UpstreamProtocol:    string(protocol.HTTP1),
 			SupportDynamicRoute: true,
 			VirtualHosts:        convertVirtualHosts(filterConfig.GetRouteConfig()),
+			RouterConfigName:    filterConfig.GetRds().GetRouteConfigName(),
+			InternalOnlyHeaders: filterConfig.GetRouteConfig().GetInternalOnlyHeaders(),
 		}
 		return structs.Map(proxyConfig)
 	} else if name == v2.RPC_PROXY {
@@ -298,6 +315,8 @@ func convertFilterConfig(name string, s *types.Struct) map[string]interface{} {
 			UpstreamProtocol:    string(protocol.SofaRPC),
 			SupportDynamicRoute: true,
 			VirtualHosts:        convertVirtualHosts(filterConfig.GetRouteConfig()),
+			RouterConfigName:    filterConfig.GetRds().GetRouteConfigName(),
+			InternalOnlyHeaders: filterConfig.GetRouteConfig().GetInternalOnlyHeaders(),
 		}
 		return structs.Map(proxyConfig)
 	} else if name == v2.X_PROXY {
@@ -310,6 +329,8 @@ func convertFilterConfig(name string, s *types.Struct) map[string]interface{} {
 			UpstreamProtocol:    string(protocol.Xprotocol),
 			SupportDynamicRoute: true,
 			VirtualHosts:        convertVirtualHosts(filterConfig.GetRouteConfig()),
+			RouterConfigName:    filterConfig.GetRds().GetRouteConfigName(),
+			InternalOnlyHeaders: filterConfig.GetRouteConfig().GetInternalOnlyHeaders(),
 			ExtendConfig:        convertXProxyExtendConfig(filterConfig),
 		}
 		return structs.Map(proxyConfig)
@@ -326,6 +347,57 @@ func convertXProxyExtendConfig(config *xdsxproxy.XProxy) map[string]interface{}
 	return structs.Map(extendConfig)
 }
 
+// GetRouterConfigNames returns the names of the route configurations referenced by the listeners,
+// which should be subscribed from RDS
+func GetRouterConfigNames(xdsListeners []*xdsapi.Listener) []string {
+	var names []string
+	found := make(map[string]bool)
+
+	for _, xdsListener := range xdsListeners {
+		if !isSupport(xdsListener) {
+			continue
+		}
+		for _, xdsFilterChain := range xdsListener.GetFilterChains() {
+			for _, xdsFilter := range xdsFilterChain.GetFilters() {
+				name := getRouterConfigName(xdsFilter.GetName(), xdsFilter.GetConfig())
+				if name != "" && !found[name] {
+					found[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+func getRouterConfigName(name string, s *types.Struct) string {
+	if s == nil {
+		return ""
+	}
+	if name == xdsutil.HTTPConnectionManager || name == v2.RPC_PROXY {
+		filterConfig := &xdshttp.HttpConnectionManager{}
+		xdsutil.StructToMessage(s, filterConfig)
+		return filterConfig.GetRds().GetRouteConfigName()
+	} else if name == v2.X_PROXY {
+		filterConfig := &xdsxproxy.XProxy{}
+		xdsutil.StructToMessage(s, filterConfig)
+		return filterConfig.GetRds().GetRouteConfigName()
+	}
+
+	return ""
+}
+
+// convertRouterConfig converts the route configuration subscribed from RDS,
+// the result is used to create the routers only
+func convertRouterConfig(xdsRouteConfig *xdsapi.RouteConfiguration) *v2.Proxy {
+	return &v2.Proxy{
+		Name:                xdsRouteConfig.GetName(),
+		VirtualHosts:        convertVirtualHosts(xdsRouteConfig),
+		InternalOnlyHeaders: xdsRouteConfig.GetInternalOnlyHeaders(),
+	}
+}
+
 func convertVirtualHosts(xdsRouteConfig *xdsapi.RouteConfiguration) []*v2.VirtualHost {
 	if xdsRouteConfig == nil {
 		return nil
@@ -339,7 +411,20 @@ func convertVirtualHosts(xdsRouteConfig *xdsapi.RouteConfiguration) []*v2.Virtua
 			Routers:         convertRoutes(xdsVirtualHost.GetRoutes()),
 			RequireTLS:      xdsVirtualHost.GetRequireTls().String(),
 			VirtualClusters: convertVirtualClusters(xdsVirtualHost.GetVirtualClusters()),
+			RateLimits:      convertRateLimits(xdsVirtualHost.GetRateLimits()),
+			Cors:            convertCorsPolicy(xdsVirtualHost.GetCors()),
+
+			RequestHeadersToAdd:     convertHeaderValueOptions(xdsVirtualHost.GetRequestHeadersToAdd()),
+			ResponseHeadersToAdd:    convertHeaderValueOptions(xdsVirtualHost.GetResponseHeadersToAdd()),
+			ResponseHeadersToRemove: xdsVirtualHost.GetResponseHeadersToRemove(),
 		}
+		// the headers of the route configuration are applied to every virtual host
+		virtualHost.RequestHeadersToAdd = append(virtualHost.RequestHeadersToAdd,
+			convertHeaderValueOptions(xdsRouteConfig.GetRequestHeadersToAdd())...)
+		virtualHost.ResponseHeadersToAdd = append(virtualHost.ResponseHeadersToAdd,
+			convertHeaderValueOptions(xdsRouteConfig.GetResponseHeadersToAdd())...)
+		virtualHost.ResponseHeadersToRemove = append(virtualHost.ResponseHeadersToRemove,
+			xdsRouteConfig.GetResponseHeadersToRemove()...)
 		virtualHosts = append(virtualHosts, virtualHost)
 	}
 
@@ -357,7 +442,11 @@ func convertRoutes(xdsRoutes []xdsroute.Route) []v2.Router {
 				Match:     convertRouteMatch(xdsRoute.GetMatch()),
 				Route:     convertRouteAction(xdsRouteAction),
 				Metadata:  convertMeta(xdsRoute.GetMetadata()),
-				Decorator: v2.Decorator(xdsRoute.GetDecorator().String()),
+				Decorator: v2.Decorator(xdsRoute.GetDecorator().GetOperation()),
+
+				RequestHeadersToAdd:     convertHeaderValueOptions(xdsRouteAction.GetRequestHeadersToAdd()),
+				ResponseHeadersToAdd:    convertHeaderValueOptions(xdsRouteAction.GetResponseHeadersToAdd()),
+				ResponseHeadersToRemove: xdsRouteAction.GetResponseHeadersToRemove(),
 			}
 			routes = append(routes, route)
 		} else if xdsRouteAction := xdsRoute.GetRedirect(); xdsRouteAction != nil {
@@ -365,28 +454,75 @@ func convertRoutes(xdsRoutes []xdsroute.Route) []v2.Router {
 				Match:     convertRouteMatch(xdsRoute.GetMatch()),
 				Redirect:  convertRedirectAction(xdsRouteAction),
 				Metadata:  convertMeta(xdsRoute.GetMetadata()),
-				Decorator: v2.Decorator(xdsRoute.GetDecorator().String()),
+				Decorator: v2.Decorator(xdsRoute.GetDecorator().GetOperation()),
+			}
+			routes = append(routes, route)
+		} else if xdsDirectResponse := xdsRoute.GetDirectResponse(); xdsDirectResponse != nil {
+			route := v2.Router{
+				Match:          convertRouteMatch(xdsRoute.GetMatch()),
+				DirectResponse: convertDirectResponseAction(xdsDirectResponse),
+				Metadata:       convertMeta(xdsRoute.GetMetadata()),
+				Decorator:      v2.Decorator(xdsRoute.GetDecorator().GetOperation()),
 			}
 			routes = append(routes, route)
 		} else {
-			log.DefaultLogger.Errorf("unsupported route actin, just Route and Redirect support yet, ignore this route")
+			log.DefaultLogger.Errorf("unsupported route actin, just Route, Redirect and DirectResponse support yet, ignore this route")
 			continue
 		}
 	}
 	return routes
 }
 
+func convertHeaderValueOptions(xdsHeaderValueOptions []*xdscore.HeaderValueOption) []*v2.HeaderValueOption {
+	if len(xdsHeaderValueOptions) == 0 {
+		return nil
+	}
+	options := make([]*v2.HeaderValueOption, 0, len(xdsHeaderValueOptions))
+	for _, xdsHeaderValueOption := range xdsHeaderValueOptions {
+		xdsHeader := xdsHeaderValueOption.GetHeader()
+		if xdsHeader == nil {
+			continue
+		}
+		option := &v2.HeaderValueOption{
+			Key:    xdsHeader.GetKey(),
+			Value:  xdsHeader.GetValue(),
+			Append: true,
+		}
+		if xdsAppend := xdsHeaderValueOption.GetAppend(); xdsAppend != nil {
+			option.Append = xdsAppend.GetValue()
+		}
+		options = append(options, option)
+	}
+	return options
+}
+
 func convertRouteMatch(xdsRouteMatch xdsroute.RouteMatch) v2.RouterMatch {
 	return v2.RouterMatch{
-		Prefix:        xdsRouteMatch.GetPrefix(),
-		Path:          xdsRouteMatch.GetPath(),
-		Regex:         xdsRouteMatch.GetRegex(),
-		CaseSensitive: xdsRouteMatch.GetCaseSensitive().GetValue(),
-		Runtime:       convertRuntime(xdsRouteMatch.GetRuntime()),
-		Headers:       convertHeaders(xdsRouteMatch.GetHeaders()),
+		Prefix:          xdsRouteMatch.GetPrefix(),
+		Path:            xdsRouteMatch.GetPath(),
+		Regex:           xdsRouteMatch.GetRegex(),
+		CaseSensitive:   xdsRouteMatch.GetCaseSensitive().GetValue(),
+		Runtime:         convertRuntime(xdsRouteMatch.GetRuntime()),
+		Headers:         convertHeaders(xdsRouteMatch.GetHeaders()),
+		QueryParameters: convertQueryParameterMatchers(xdsRouteMatch.GetQueryParameters()),
 	}
 }
 
+func convertQueryParameterMatchers(xdsQueryParameters []*xdsroute.QueryParameterMatcher) []v2.QueryParameterMatcher {
+	if len(xdsQueryParameters) == 0 {
+		return nil
+	}
+	queryParameterMatchers := make([]v2.QueryParameterMatcher, 0, len(xdsQueryParameters))
+	for _, xdsQueryParameter := range xdsQueryParameters {
+		queryParameterMatchers = append(queryParameterMatchers, v2.QueryParameterMatcher{
+			Name:  xdsQueryParameter.GetName(),
+			Value: xdsQueryParameter.GetValue(),
+			Regex: xdsQueryParameter.GetRegex().GetValue(),
+		})
+	}
+	return queryParameterMatchers
+}
+
 func convertRuntime(xdsRuntime *xdscore.RuntimeUInt32) v2.RuntimeUInt32 {
 	if xdsRuntime == nil {
 		return v2.RuntimeUInt32{}
@@ -435,9 +571,121 @@ func convertRouteAction(xdsRouteAction *xdsroute.RouteAction) v2.RouteAction {
 		MetadataMatch:    convertMeta(xdsRouteAction.GetMetadataMatch()),
 		Timeout:          convertTimeDurPoint2TimeDur(xdsRouteAction.GetTimeout()),
 		RetryPolicy:      convertRetryPolicy(xdsRouteAction.GetRetryPolicy()),
+		HashPolicy:       convertHashPolicy(xdsRouteAction.GetHashPolicy()),
+		ShadowPolicy:     convertShadowPolicy(xdsRouteAction.GetRequestMirrorPolicy()),
+		RateLimits:       convertRateLimits(xdsRouteAction.GetRateLimits()),
+		PrefixRewrite:    xdsRouteAction.GetPrefixRewrite(),
+		HostRewrite:      xdsRouteAction.GetHostRewrite(),
+		AutoHostRewrite:  xdsRouteAction.GetAutoHostRewrite().GetValue(),
+		Cors:             convertCorsPolicy(xdsRouteAction.GetCors()),
+	}
+}
+
+func convertCorsPolicy(xdsCorsPolicy *xdsroute.CorsPolicy) *v2.CorsPolicy {
+	if xdsCorsPolicy == nil {
+		return nil
+	}
+	corsPolicy := &v2.CorsPolicy{
+		AllowOrigin:      xdsCorsPolicy.GetAllowOrigin(),
+		AllowMethods:     xdsCorsPolicy.GetAllowMethods(),
+		AllowHeaders:     xdsCorsPolicy.GetAllowHeaders(),
+		ExposeHeaders:    xdsCorsPolicy.GetExposeHeaders(),
+		MaxAge:           xdsCorsPolicy.GetMaxAge(),
+		AllowCredentials: xdsCorsPolicy.GetAllowCredentials().GetValue(),
+		Enabled:          true,
+	}
+	if xdsEnabled := xdsCorsPolicy.GetEnabled(); xdsEnabled != nil {
+		corsPolicy.Enabled = xdsEnabled.GetValue()
+	}
+	return corsPolicy
+}
+
+// convertRateLimits ignores the rate limits with unsupported actions, as the descriptors can not be generated correctly
+func convertRateLimits(xdsRateLimits []*xdsroute.RateLimit) []v2.RateLimit {
+	if len(xdsRateLimits) == 0 {
+		return nil
+	}
+
+	rateLimits := make([]v2.RateLimit, 0, len(xdsRateLimits))
+	for _, xdsRateLimit := range xdsRateLimits {
+		rateLimit := v2.RateLimit{
+			Stage:      xdsRateLimit.GetStage().GetValue(),
+			DisableKey: xdsRateLimit.GetDisableKey(),
+		}
+
+		supported := true
+		for _, xdsAction := range xdsRateLimit.GetActions() {
+			if genericKey := xdsAction.GetGenericKey(); genericKey != nil {
+				rateLimit.Actions = append(rateLimit.Actions, v2.RateLimitAction{
+					GenericKey: genericKey.GetDescriptorValue(),
+				})
+			} else if xdsAction.GetDestinationCluster() != nil {
+				rateLimit.Actions = append(rateLimit.Actions, v2.RateLimitAction{
+					DestinationCluster: true,
+				})
+			} else if requestHeaders := xdsAction.GetRequestHeaders(); requestHeaders != nil {
+				rateLimit.Actions = append(rateLimit.Actions, v2.RateLimitAction{
+					RequestHeader: strings.ToLower(requestHeaders.GetHeaderName()),
+					DescriptorKey: requestHeaders.GetDescriptorKey(),
+				})
+			} else if xdsAction.GetRemoteAddress() != nil {
+				rateLimit.Actions = append(rateLimit.Actions, v2.RateLimitAction{
+					RemoteAddress: true,
+				})
+			} else {
+				log.DefaultLogger.Warnf("unsupported rate limit action %s", xdsAction.String())
+				supported = false
+				break
+			}
+		}
+
+		if supported && len(rateLimit.Actions) > 0 {
+			rateLimits = append(rateLimits, rateLimit)
+		}
+	}
+	return rateLimits
+}
+
+// convertShadowPolicy mirrors all requests, the fraction of the runtime key is not supported yet
+func convertShadowPolicy(xdsMirrorPolicy *xdsroute.RouteAction_RequestMirrorPolicy) *v2.ShadowPolicy {
+	if xdsMirrorPolicy == nil || xdsMirrorPolicy.GetCluster() == "" {
+		return nil
+	}
+	return &v2.ShadowPolicy{
+		Cluster:    xdsMirrorPolicy.GetCluster(),
+		RuntimeKey: xdsMirrorPolicy.GetRuntimeKey(),
+		Percent:    100,
 	}
 }
 
+func convertHashPolicy(xdsHashPolicies []*xdsroute.RouteAction_HashPolicy) []v2.HashPolicy {
+	if len(xdsHashPolicies) == 0 {
+		return nil
+	}
+
+	hashPolicies := make([]v2.HashPolicy, 0, len(xdsHashPolicies))
+	for _, xdsHashPolicy := range xdsHashPolicies {
+		if header := xdsHashPolicy.GetHeader(); header != nil {
+			hashPolicies = append(hashPolicies, v2.HashPolicy{
+				Header: strings.ToLower(header.GetHeaderName()),
+			})
+		} else if cookie := xdsHashPolicy.GetCookie(); cookie != nil {
+			hashPolicies = append(hashPolicies, v2.HashPolicy{
+				Cookie:     cookie.GetName(),
+				CookiePath: cookie.GetPath(),
+				CookieTTL:  convertTimeDurPoint2TimeDur(cookie.GetTtl()),
+			})
+		} else if conn := xdsHashPolicy.GetConnectionProperties(); conn != nil {
+			hashPolicies = append(hashPolicies, v2.HashPolicy{
+				SourceIP: conn.GetSourceIp(),
+			})
+		} else {
+			log.DefaultLogger.Warnf("unsupported hash policy %s", xdsHashPolicy.String())
+		}
+	}
+	return hashPolicies
+}
+
 func convertTimeDurPoint2TimeDur(duration *time.Duration) time.Duration {
 	if duration == nil {
 		return time.Duration(0)
@@ -476,20 +724,76 @@ func convertRetryPolicy(xdsRetryPolicy *xdsroute.RouteAction_RetryPolicy) *v2.Re
 		return &v2.RetryPolicy{}
 	}
 	return &v2.RetryPolicy{
-		RetryOn:      len(xdsRetryPolicy.GetRetryOn()) > 0,
-		RetryTimeout: convertTimeDurPoint2TimeDur(xdsRetryPolicy.GetPerTryTimeout()),
-		NumRetries:   xdsRetryPolicy.GetNumRetries().GetValue(),
+		RetryOn:         len(xdsRetryPolicy.GetRetryOn()) > 0,
+		RetryTimeout:    convertTimeDurPoint2TimeDur(xdsRetryPolicy.GetPerTryTimeout()),
+		NumRetries:      xdsRetryPolicy.GetNumRetries().GetValue(),
+		RetryConditions: convertRetryConditions(xdsRetryPolicy.GetRetryOn()),
 	}
 }
 
+// convertRetryConditions converts the comma separated retry on conditions,
+// the conditions not supported are ignored by the router
+func convertRetryConditions(retryOn string) []string {
+	var conditions []string
+
+	for _, condition := range strings.Split(retryOn, ",") {
+		if condition = strings.TrimSpace(condition); condition != "" {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	return conditions
+}
+
 func convertRedirectAction(xdsRedirectAction *xdsroute.RedirectAction) v2.RedirectAction {
 	if xdsRedirectAction == nil {
 		return v2.RedirectAction{}
 	}
+
+	var schemeRedirect string
+	if xdsRedirectAction.GetHttpsRedirect() {
+		schemeRedirect = "https"
+	}
+
 	return v2.RedirectAction{
-		HostRedirect: xdsRedirectAction.GetHostRedirect(),
-		PathRedirect: xdsRedirectAction.GetPathRedirect(),
-		ResponseCode: uint32(xdsRedirectAction.GetResponseCode()),
+		SchemeRedirect: schemeRedirect,
+		HostRedirect:   xdsRedirectAction.GetHostRedirect(),
+		PathRedirect:   xdsRedirectAction.GetPathRedirect(),
+		PrefixRewrite:  xdsRedirectAction.GetPrefixRewrite(),
+		ResponseCode:   convertRedirectResponseCode(xdsRedirectAction.GetResponseCode()),
+		StripQuery:     xdsRedirectAction.GetStripQuery(),
+	}
+}
+
+func convertRedirectResponseCode(xdsResponseCode xdsroute.RedirectAction_RedirectResponseCode) uint32 {
+	switch xdsResponseCode {
+	case xdsroute.RedirectAction_FOUND:
+		return http.StatusFound
+	case xdsroute.RedirectAction_SEE_OTHER:
+		return http.StatusSeeOther
+	case xdsroute.RedirectAction_TEMPORARY_REDIRECT:
+		return http.StatusTemporaryRedirect
+	case xdsroute.RedirectAction_PERMANENT_REDIRECT:
+		return http.StatusPermanentRedirect
+	default:
+		return http.StatusMovedPermanently
+	}
+}
+
+// convertDirectResponseAction converts the direct response, the body in a file is not supported yet
+func convertDirectResponseAction(xdsDirectResponse *xdsroute.DirectResponseAction) *v2.DirectResponseAction {
+	body := xdsDirectResponse.GetBody().GetInlineString()
+	if inlineBytes := xdsDirectResponse.GetBody().GetInlineBytes(); len(inlineBytes) > 0 {
+		body = string(inlineBytes)
+	}
+
+	if xdsDirectResponse.GetBody().GetFilename() != "" {
+		log.DefaultLogger.Errorf("direct response body in a file is not supported yet, the body is ignored")
+	}
+
+	return &v2.DirectResponseAction{
+		StatusCode: xdsDirectResponse.GetStatus(),
+		Body:       body,
 	}
 }
 
@@ -553,11 +857,14 @@ func convertLbPolicy(xdsLbPolicy xdsapi.Cluster_LbPolicy) v2.LbType {
 	case xdsapi.Cluster_ROUND_ROBIN:
 		return v2.LB_ROUNDROBIN
 	case xdsapi.Cluster_LEAST_REQUEST:
+		return v2.LB_LEASTREQUEST
 	case xdsapi.Cluster_RING_HASH:
+		return v2.LB_RINGHASH
 	case xdsapi.Cluster_RANDOM:
 		return v2.LB_RANDOM
 	case xdsapi.Cluster_ORIGINAL_DST_LB:
 	case xdsapi.Cluster_MAGLEV:
+		return v2.LB_MAGLEV
 	}
 	//log.DefaultLogger.Fatalf("unsupported lb policy: %s, exchange to LB_RANDOM", xdsLbPolicy.String())
 	return v2.LB_RANDOM
@@ -601,13 +908,42 @@ func convertHealthChecks(xdsHealthChecks []*xdscore.HealthCheck) v2.HealthCheck
 		return v2.HealthCheck{}
 	}
 
-	return v2.HealthCheck{
+	healthCheck := v2.HealthCheck{
 		Timeout:            *xdsHealthChecks[0].GetTimeout(),
 		HealthyThreshold:   xdsHealthChecks[0].GetHealthyThreshold().GetValue(),
 		UnhealthyThreshold: xdsHealthChecks[0].GetUnhealthyThreshold().GetValue(),
 		Interval:           *xdsHealthChecks[0].GetInterval(),
 		IntervalJitter:     convertDuration(xdsHealthChecks[0].GetIntervalJitter()),
 	}
+
+	if xdsTCPHealthCheck := xdsHealthChecks[0].GetTcpHealthCheck(); xdsTCPHealthCheck != nil {
+		healthCheck.Protocol = v2.TCP_HEALTH_CHECK
+		healthCheck.TCPSend = convertHealthCheckPayload(xdsTCPHealthCheck.GetSend())
+		for _, receive := range xdsTCPHealthCheck.GetReceive() {
+			healthCheck.TCPReceive = append(healthCheck.TCPReceive, convertHealthCheckPayload(receive))
+		}
+	}
+
+	return healthCheck
+}
+
+func convertHealthCheckPayload(xdsPayload *xdscore.HealthCheck_Payload) []byte {
+	if xdsPayload == nil {
+		return nil
+	}
+
+	if binary := xdsPayload.GetBinary(); binary != nil {
+		return binary
+	}
+
+	// text payload is hex encoded
+	payload, err := hex.DecodeString(xdsPayload.GetText())
+	if err != nil {
+		log.DefaultLogger.Errorf("invalid hex payload in tcp health check: %s", xdsPayload.GetText())
+		return nil
+	}
+
+	return payload
 }
 
 func convertCircuitBreakers(xdsCircuitBreaker *xdscluster.CircuitBreakers) v2.CircuitBreakers {
@@ -642,8 +978,8 @@ func convertOutlierDetection(xdsOutlierDetection *xdscluster.OutlierDetection) v
 		Interval:                           convertDuration(xdsOutlierDetection.GetInterval()),
 		BaseEjectionTime:                   convertDuration(xdsOutlierDetection.GetBaseEjectionTime()),
 		MaxEjectionPercent:                 xdsOutlierDetection.GetMaxEjectionPercent().GetValue(),
-		ConsecutiveGatewayFailure:          xdsOutlierDetection.GetEnforcingConsecutive_5Xx().GetValue(),
-		EnforcingConsecutive5xx:            xdsOutlierDetection.GetConsecutive_5Xx().GetValue(),
+		ConsecutiveGatewayFailure:          xdsOutlierDetection.GetConsecutiveGatewayFailure().GetValue(),
+		EnforcingConsecutive5xx:            xdsOutlierDetection.GetEnforcingConsecutive_5Xx().GetValue(),
 		EnforcingConsecutiveGatewayFailure: xdsOutlierDetection.GetEnforcingConsecutiveGatewayFailure().GetValue(),
 		EnforcingSuccessRate:               xdsOutlierDetection.GetEnforcingSuccessRate().GetValue(),
 		SuccessRateMinimumHosts:            xdsOutlierDetection.GetSuccessRateMinimumHosts().GetValue(),
@@ -22,7 +22,13 @@ import (
 	"testing"
 
 	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	xdsapi "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	xdscore "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	xdsendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	xdslistener "github.com/envoyproxy/go-control-plane/envoy/api/v2/listener"
+	xdshttp "github.com/envoyproxy/go-control-plane/envoy/config/filter/network/http_connection_manager/v2"
+	xdsutil "github.com/envoyproxy/go-control-plane/pkg/util"
+	"github.com/gogo/protobuf/types"
 )
 
 // todo fill the unit test
@@ -44,6 +50,43 @@ func Test_convertEndpointsConfig(t *testing.T) {
 			},
 			want: []v2.Host{},
 		},
+		{
+			name: "locality",
+			args: args{
+				xdsEndpoint: &xdsendpoint.LocalityLbEndpoints{
+					Locality: &xdscore.Locality{
+						Region: "region",
+						Zone:   "zone",
+					},
+					LbEndpoints: []xdsendpoint.LbEndpoint{
+						{
+							Endpoint: &xdsendpoint.Endpoint{
+								Address: &xdscore.Address{
+									Address: &xdscore.Address_SocketAddress{
+										SocketAddress: &xdscore.SocketAddress{
+											Address:       "127.0.0.1",
+											PortSpecifier: &xdscore.SocketAddress_PortValue{PortValue: 8080},
+										},
+									},
+								},
+							},
+							LoadBalancingWeight: &types.UInt32Value{Value: 20},
+						},
+					},
+					LoadBalancingWeight: &types.UInt32Value{Value: 3},
+					Priority:            1,
+				},
+			},
+			want: []v2.Host{
+				{
+					Address:        "127.0.0.1:8080",
+					Weight:         20,
+					Priority:       1,
+					Locality:       "region/zone/",
+					LocalityWeight: 3,
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -53,3 +96,50 @@ func Test_convertEndpointsConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRouterConfigNames(t *testing.T) {
+	newFilter := func(routerConfigName string) xdslistener.Filter {
+		filterConfig := &xdshttp.HttpConnectionManager{}
+		if routerConfigName != "" {
+			filterConfig.RouteSpecifier = &xdshttp.HttpConnectionManager_Rds{
+				Rds: &xdshttp.Rds{RouteConfigName: routerConfigName},
+			}
+		} else {
+			filterConfig.RouteSpecifier = &xdshttp.HttpConnectionManager_RouteConfig{
+				RouteConfig: &xdsapi.RouteConfiguration{Name: "inlined"},
+			}
+		}
+		s, err := xdsutil.MessageToStruct(filterConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return xdslistener.Filter{Name: xdsutil.HTTPConnectionManager, Config: s}
+	}
+
+	listeners := []*xdsapi.Listener{
+		{
+			Name: "l1",
+			FilterChains: []xdslistener.FilterChain{
+				{Filters: []xdslistener.Filter{newFilter("r1")}},
+				{Filters: []xdslistener.Filter{newFilter("")}},
+			},
+		},
+		{
+			Name: "l2",
+			FilterChains: []xdslistener.FilterChain{
+				{Filters: []xdslistener.Filter{newFilter("r1")}},
+				{Filters: []xdslistener.Filter{newFilter("r2")}},
+			},
+		},
+	}
+
+	names := GetRouterConfigNames(listeners)
+	if !reflect.DeepEqual(names, []string{"r1", "r2"}) {
+		t.Errorf("unexpected router config names: %v", names)
+	}
+
+	proxy := convertFilterConfig(xdsutil.HTTPConnectionManager, listeners[0].FilterChains[0].Filters[0].Config)
+	if proxy["RouterConfigName"] != "r1" {
+		t.Errorf("router config name is not converted, got %v", proxy["RouterConfigName"])
+	}
+}
@@ -18,8 +18,12 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
 	"net"
+	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -38,6 +42,8 @@ type ContentKey string
 const (
 	MinHostWeight = uint32(1)
 	MaxHostWeight = uint32(128)
+
+	DefaultGlobalRateLimitTimeout = 20 * time.Millisecond
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
@@ -76,7 +82,7 @@ const (
 // used to register ParsedCallback
 func RegisterConfigParsedListener(key ContentKey, cb ParsedCallback) {
 	if cbs, ok := configParsedCBMaps[key]; ok {
-		cbs = append(cbs, cb)
+		configParsedCBMaps[key] = append(cbs, cb)
 	} else {
 		log.StartLogger.Infof(" %s added to configParsedCBMaps", key)
 		cpc := []ParsedCallback{cb}
@@ -102,11 +108,20 @@ var (
 	}
 
 	lbTypeMap = map[string]v2.LbType{
-		"LB_RANDOM":     v2.LB_RANDOM,
-		"LB_ROUNDROBIN": v2.LB_ROUNDROBIN,
+		"LB_RANDOM":       v2.LB_RANDOM,
+		"LB_ROUNDROBIN":   v2.LB_ROUNDROBIN,
+		"LB_RINGHASH":     v2.LB_RINGHASH,
+		"LB_MAGLEV":       v2.LB_MAGLEV,
+		"LB_LEASTREQUEST": v2.LB_LEASTREQUEST,
 	}
 )
 
+// GetLogLevel returns the log level named level
+func GetLogLevel(level string) (log.Level, bool) {
+	logLevel, ok := logLevelMap[level]
+	return logLevel, ok
+}
+
 func parseLogLevel(level string) log.Level {
 	if level != "" {
 		if logLevel, ok := logLevelMap[level]; ok {
@@ -150,7 +165,9 @@ func ConvertProxyFilterToV2(config map[string]interface{}) map[string]interface{
 		SupportDynamicRoute: proxyConfig.SupportDynamicRoute,
 		BasicRoutes:         nil,
 		VirtualHosts:        parseVirtualHost(proxyConfig.VirtualHosts),
+		RouterConfigName:    proxyConfig.RouterConfigName,
 		ValidateClusters:    proxyConfig.ValidateClusters,
+		InternalOnlyHeaders: proxyConfig.InternalOnlyHeaders,
 	}
 
 	if proxyConfig.UpstreamProtocol == string(protocol.Xprotocol) || proxyConfig.DownstreamProtocol == string(protocol.Xprotocol) {
@@ -209,6 +226,13 @@ func parseVirtualHost(confighost []*VirtualHost) []*v2.VirtualHost {
 			Routers:         parseRouters(cfh.Routers),
 			RequireTLS:      cfh.RequireTLS,
 			VirtualClusters: parseVirtualClusters(cfh.VirtualClusters),
+			RateLimits:      parseRateLimits(cfh.RateLimits),
+			Cors:            parseCorsPolicy(cfh.Cors),
+
+			RequestHeadersToAdd:     parseHeaderValueOptions(cfh.RequestHeadersToAdd),
+			RequestHeadersToRemove:  cfh.RequestHeadersToRemove,
+			ResponseHeadersToAdd:    parseHeaderValueOptions(cfh.ResponseHeadersToAdd),
+			ResponseHeadersToRemove: cfh.ResponseHeadersToRemove,
 		})
 
 	}
@@ -219,13 +243,33 @@ func parseVirtualHost(confighost []*VirtualHost) []*v2.VirtualHost {
 func parseRetryPolicy(action RouteAction) *v2.RetryPolicy {
 	if action.RetryPolicy == nil {
 		return nil
-	} else {
-		return &v2.RetryPolicy{
-			action.RetryPolicy.RetryOn,
-			action.RetryPolicy.RetryTimeout,
-			action.RetryPolicy.NumRetries,
+	}
+
+	policy := action.RetryPolicy
+	for _, condition := range policy.RetryConditions {
+		if _, ok := types.RetryConditionNames[condition]; !ok {
+			log.StartLogger.Fatalln("unknown retry condition in retry policy:", condition)
 		}
 	}
+	if policy.RetryBudgetPercent > 100 {
+		log.StartLogger.Fatalln("[retry_budget_percent] in retry policy should not be greater than 100")
+	}
+
+	retryPolicy := &v2.RetryPolicy{
+		RetryOn:              policy.RetryOn,
+		RetryTimeout:         policy.RetryTimeout,
+		NumRetries:           policy.NumRetries,
+		RetryConditions:      policy.RetryConditions,
+		RetriableStatusCodes: policy.RetriableStatusCodes,
+		RetryBudgetPercent:   policy.RetryBudgetPercent,
+		AvoidPreviousHosts:   policy.AvoidPreviousHosts,
+	}
+	if policy.BackOff != nil {
+		retryPolicy.BackOffBaseInterval = policy.BackOff.BaseInterval.Duration
+		retryPolicy.BackOffMaxInterval = policy.BackOff.MaxInterval.Duration
+	}
+
+	return retryPolicy
 }
 
 func parseRouters(Router []Router) []v2.Router {
@@ -242,7 +286,11 @@ func parseRouters(Router []Router) []v2.Router {
 				router.Match.Runtime.DefaultValue,
 				router.Match.Runtime.RuntimeKey,
 			},
-			Headers: parseMatchHeaders(router.Match.Headers),
+			Headers:         parseMatchHeaders(router.Match.Headers),
+			QueryParameters: parseQueryParameterMatchers(router.Match.QueryParameters),
+		}
+		if routerMatch.Runtime.DefaultValue > 100 {
+			log.StartLogger.Fatalln("[default_value] in route match runtime should not be greater than 100")
 		}
 
 		routeAction := v2.RouteAction{
@@ -252,24 +300,270 @@ func parseRouters(Router []Router) []v2.Router {
 			MetadataMatch:    parseRouterMetadata(router.Route.MetadataMatch),
 			Timeout:          router.Route.Timeout,
 			RetryPolicy:      parseRetryPolicy(router.Route),
+			HashPolicy:       parseHashPolicy(router.Route.HashPolicy),
+			ShadowPolicy:     parseShadowPolicy(router.Route.ShadowPolicy),
+			RateLimits:       parseRateLimits(router.Route.RateLimits),
+			HedgePolicy:      parseHedgePolicy(router.Route.HedgePolicy),
+			PrefixRewrite:    router.Route.PrefixRewrite,
+			RegexRewrite:     parseRegexRewrite(router.Route),
+			HostRewrite:      router.Route.HostRewrite,
+			AutoHostRewrite:  router.Route.AutoHostRewrite,
+			Cors:             parseCorsPolicy(router.Route.Cors),
+		}
+		if routeAction.HostRewrite != "" && routeAction.AutoHostRewrite {
+			log.StartLogger.Fatalln("[host_rewrite] and [auto_host_rewrite] in route should not be both set")
 		}
 
 		result = append(result, v2.Router{
-			Match: routerMatch,
-			Route: routeAction,
-			Redirect: v2.RedirectAction{
-				HostRedirect: router.Redirect.HostRedirect,
-				PathRedirect: router.Redirect.PathRedirect,
-				ResponseCode: router.Redirect.ResponseCode,
-			},
-			Metadata:  parseRouterMetadata(router.Metadata),
-			Decorator: v2.Decorator(router.Decorator),
+			Name:           router.Name,
+			Match:          routerMatch,
+			Route:          routeAction,
+			Redirect:       parseRedirectAction(router.Redirect),
+			DirectResponse: parseDirectResponse(router.DirectResponse),
+			Metadata:       parseRouterMetadata(router.Metadata),
+			Decorator:      v2.Decorator(router.Decorator),
+
+			RequestHeadersToAdd:     parseHeaderValueOptions(router.RequestHeadersToAdd),
+			RequestHeadersToRemove:  router.RequestHeadersToRemove,
+			ResponseHeadersToAdd:    parseHeaderValueOptions(router.ResponseHeadersToAdd),
+			ResponseHeadersToRemove: router.ResponseHeadersToRemove,
+		})
+	}
+
+	return result
+}
+
+func parseRedirectAction(redirect RedirectAction) v2.RedirectAction {
+	if redirect.PathRedirect != "" && redirect.PrefixRewrite != "" {
+		log.StartLogger.Fatalln("[path_redirect] and [prefix_rewrite] in redirect should not be both set")
+	}
+
+	switch redirect.ResponseCode {
+	case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		log.StartLogger.Fatalln("unsupported [response_code] in redirect: ", redirect.ResponseCode)
+	}
+
+	return v2.RedirectAction{
+		SchemeRedirect: redirect.SchemeRedirect,
+		HostRedirect:   redirect.HostRedirect,
+		PathRedirect:   redirect.PathRedirect,
+		PrefixRewrite:  redirect.PrefixRewrite,
+		ResponseCode:   redirect.ResponseCode,
+		StripQuery:     redirect.StripQuery,
+	}
+}
+
+func parseDirectResponse(directResponse *DirectResponseAction) *v2.DirectResponseAction {
+	if directResponse == nil {
+		return nil
+	}
+
+	if directResponse.Status < 100 || directResponse.Status > 599 {
+		log.StartLogger.Fatalln("invalid [status] in direct response: ", directResponse.Status)
+	}
+
+	return &v2.DirectResponseAction{
+		StatusCode: directResponse.Status,
+		Body:       directResponse.Body,
+	}
+}
+
+func parseHeaderValueOptions(options []*HeaderValueOption) []*v2.HeaderValueOption {
+	var result []*v2.HeaderValueOption
+
+	for _, option := range options {
+		if option.Key == "" {
+			log.StartLogger.Fatalln("[key] is required in header value option")
+		}
+
+		// append the value by default
+		appendValue := true
+		if option.Append != nil {
+			appendValue = *option.Append
+		}
+
+		result = append(result, &v2.HeaderValueOption{
+			Key:    option.Key,
+			Value:  option.Value,
+			Append: appendValue,
 		})
 	}
 
 	return result
 }
 
+func parseHashPolicy(hashPolicies []HashPolicy) []v2.HashPolicy {
+	var result []v2.HashPolicy
+
+	for _, hp := range hashPolicies {
+		switch {
+		case hp.Header != nil:
+			if hp.Header.HeaderName == "" {
+				log.StartLogger.Fatalln("[header_name] is required in header hash policy")
+			}
+			result = append(result, v2.HashPolicy{
+				Header: strings.ToLower(hp.Header.HeaderName),
+			})
+		case hp.Cookie != nil:
+			if hp.Cookie.Name == "" {
+				log.StartLogger.Fatalln("[name] is required in cookie hash policy")
+			}
+			result = append(result, v2.HashPolicy{
+				Cookie:     hp.Cookie.Name,
+				CookiePath: hp.Cookie.Path,
+				CookieTTL:  hp.Cookie.TTL.Duration,
+			})
+		case hp.ConnectionProperties != nil:
+			result = append(result, v2.HashPolicy{
+				SourceIP: hp.ConnectionProperties.SourceIP,
+			})
+		default:
+			log.StartLogger.Fatalln("one of header, cookie and connection_properties is required in hash policy")
+		}
+	}
+
+	return result
+}
+
+func parseShadowPolicy(shadowPolicy *ShadowPolicy) *v2.ShadowPolicy {
+	if shadowPolicy == nil {
+		return nil
+	}
+
+	if shadowPolicy.Cluster == "" {
+		log.StartLogger.Fatalln("[cluster] is required in shadow policy")
+	}
+
+	percent := uint32(100)
+	if shadowPolicy.Percent != nil {
+		percent = *shadowPolicy.Percent
+		if percent > 100 {
+			log.StartLogger.Fatalln("[percent] in shadow policy should not be greater than 100, got ", percent)
+		}
+	}
+
+	return &v2.ShadowPolicy{
+		Cluster:    shadowPolicy.Cluster,
+		RuntimeKey: shadowPolicy.RuntimeKey,
+		Percent:    percent,
+	}
+}
+
+func parseHedgePolicy(hedgePolicy *HedgePolicy) *v2.HedgePolicy {
+	if hedgePolicy == nil {
+		return nil
+	}
+
+	if hedgePolicy.HedgeDelay.Duration <= 0 {
+		log.StartLogger.Fatalln("[hedge_delay] in hedge policy should be greater than 0")
+	}
+
+	return &v2.HedgePolicy{
+		HedgeDelay: hedgePolicy.HedgeDelay.Duration,
+	}
+}
+
+func parseRegexRewrite(action RouteAction) *v2.RegexRewrite {
+	if action.RegexRewrite == nil {
+		return nil
+	}
+
+	if action.PrefixRewrite != "" {
+		log.StartLogger.Fatalln("[prefix_rewrite] and [regex_rewrite] in route should not be both set")
+	}
+	if _, err := regexp.Compile(action.RegexRewrite.Pattern); err != nil {
+		log.StartLogger.Fatalln("invalid [pattern] in regex rewrite:", err)
+	}
+
+	return &v2.RegexRewrite{
+		Pattern:      action.RegexRewrite.Pattern,
+		Substitution: action.RegexRewrite.Substitution,
+	}
+}
+
+func parseCorsPolicy(corsPolicy *CorsPolicy) *v2.CorsPolicy {
+	if corsPolicy == nil {
+		return nil
+	}
+
+	if corsPolicy.MaxAge != "" {
+		if maxAge, err := strconv.Atoi(corsPolicy.MaxAge); err != nil || maxAge < 0 {
+			log.StartLogger.Fatalln("[max_age] in cors policy should be a non-negative integer in seconds, got:", corsPolicy.MaxAge)
+		}
+	}
+
+	// the policy is enabled by default
+	enabled := true
+	if corsPolicy.Enabled != nil {
+		enabled = *corsPolicy.Enabled
+	}
+
+	return &v2.CorsPolicy{
+		AllowOrigin:      corsPolicy.AllowOrigin,
+		AllowMethods:     corsPolicy.AllowMethods,
+		AllowHeaders:     corsPolicy.AllowHeaders,
+		ExposeHeaders:    corsPolicy.ExposeHeaders,
+		MaxAge:           corsPolicy.MaxAge,
+		AllowCredentials: corsPolicy.AllowCredentials,
+		Enabled:          enabled,
+	}
+}
+
+func parseRateLimits(rateLimits []RateLimit) []v2.RateLimit {
+	var result []v2.RateLimit
+
+	for _, rl := range rateLimits {
+		if len(rl.Actions) == 0 {
+			log.StartLogger.Fatalln("[actions] is required in rate limit")
+		}
+
+		rateLimit := v2.RateLimit{
+			Stage:      rl.Stage,
+			DisableKey: rl.DisableKey,
+		}
+
+		for _, action := range rl.Actions {
+			switch {
+			case action.GenericKey != nil:
+				if action.GenericKey.DescriptorValue == "" {
+					log.StartLogger.Fatalln("[descriptor_value] is required in generic key rate limit action")
+				}
+				rateLimit.Actions = append(rateLimit.Actions, v2.RateLimitAction{
+					GenericKey: action.GenericKey.DescriptorValue,
+				})
+			case action.DestinationCluster != nil:
+				rateLimit.Actions = append(rateLimit.Actions, v2.RateLimitAction{
+					DestinationCluster: true,
+				})
+			case action.RequestHeaders != nil:
+				if action.RequestHeaders.HeaderName == "" || action.RequestHeaders.DescriptorKey == "" {
+					log.StartLogger.Fatalln("[header_name] and [descriptor_key] are required in request headers rate limit action")
+				}
+				rateLimit.Actions = append(rateLimit.Actions, v2.RateLimitAction{
+					RequestHeader: strings.ToLower(action.RequestHeaders.HeaderName),
+					DescriptorKey: action.RequestHeaders.DescriptorKey,
+				})
+			case action.RemoteAddress != nil:
+				rateLimit.Actions = append(rateLimit.Actions, v2.RateLimitAction{
+					RemoteAddress: true,
+				})
+			case action.Service != nil:
+				rateLimit.Actions = append(rateLimit.Actions, v2.RateLimitAction{
+					Service: true,
+				})
+			default:
+				log.StartLogger.Fatalln("one of generic_key, destination_cluster, request_headers, remote_address and service is required in rate limit action")
+			}
+		}
+
+		result = append(result, rateLimit)
+	}
+
+	return result
+}
+
 func parseWeightClusters(weightClusters []WeightedCluster) []v2.WeightedCluster {
 	result := []v2.WeightedCluster{}
 
@@ -329,6 +623,29 @@ func parseMatchHeaders(headerMatchers []HeaderMatcher) []v2.HeaderMatcher {
 	return result
 }
 
+func parseQueryParameterMatchers(queryParameterMatchers []QueryParameterMatcher) []v2.QueryParameterMatcher {
+	var result []v2.QueryParameterMatcher
+
+	for _, qpm := range queryParameterMatchers {
+		if qpm.Name == "" {
+			log.StartLogger.Fatalln("[name] is required in query parameter matcher")
+		}
+		if qpm.Regex {
+			if _, err := regexp.Compile(qpm.Value); err != nil {
+				log.StartLogger.Fatalln("invalid regex [value] in query parameter matcher:", err)
+			}
+		}
+
+		result = append(result, v2.QueryParameterMatcher{
+			Name:  qpm.Name,
+			Value: qpm.Value,
+			Regex: qpm.Regex,
+		})
+	}
+
+	return result
+}
+
 func parseVirtualClusters(VirtualClusters []VirtualCluster) []v2.VirtualCluster {
 	result := []v2.VirtualCluster{}
 
@@ -504,8 +821,6 @@ func ParseFaultInjectFilter(config map[string]interface{}) *v2.FaultInject {
 		} else {
 			log.StartLogger.Fatalln("[delay_percent] in fault inject filter config is not integer")
 		}
-	} else {
-		log.StartLogger.Fatalln("[delay_percent] is required in fault inject filter config")
 	}
 
 	//duration
@@ -519,13 +834,347 @@ func ParseFaultInjectFilter(config map[string]interface{}) *v2.FaultInject {
 		} else {
 			log.StartLogger.Fatalln("[delay_duration] in fault inject filter config is not a numeric string, like '30s'")
 		}
-	} else {
+	} else if faultInject.DelayPercent > 0 {
 		log.StartLogger.Fatalln("[delay_duration] is required in fault inject filter config")
 	}
 
+	//abort percent
+	if percent, ok := config["abort_percent"]; ok {
+		if percent, ok := percent.(float64); ok {
+			faultInject.AbortPercent = uint32(percent)
+		} else {
+			log.StartLogger.Fatalln("[abort_percent] in fault inject filter config is not integer")
+		}
+	}
+
+	//abort status
+	if status, ok := config["abort_status"]; ok {
+		if status, ok := status.(float64); ok && status > 0 {
+			faultInject.AbortStatus = int(status)
+		} else {
+			log.StartLogger.Fatalln("[abort_status] in fault inject filter config is not a valid status code")
+		}
+	} else if faultInject.AbortPercent > 0 {
+		log.StartLogger.Fatalln("[abort_status] is required in fault inject filter config")
+	}
+
+	//abort bolt status, used by the sofa rpc instead of the status converted from abort status
+	if status, ok := config["abort_bolt_status"]; ok {
+		if status, ok := status.(float64); ok {
+			boltStatus := int16(status)
+			faultInject.AbortBoltStatus = &boltStatus
+		} else {
+			log.StartLogger.Fatalln("[abort_bolt_status] in fault inject filter config is not integer")
+		}
+	}
+
+	if faultInject.DelayPercent > 100 || faultInject.AbortPercent > 100 {
+		log.StartLogger.Fatalln("[delay_percent] and [abort_percent] in fault inject filter config should not be greater than 100")
+	}
+
+	//headers
+	if headers, ok := config["headers"]; ok {
+		faultInject.Headers = parseFaultInjectHeaders(headers)
+	}
+
+	//upstream cluster
+	if cluster, ok := config["upstream_cluster"]; ok {
+		if cluster, ok := cluster.(string); ok {
+			faultInject.UpstreamCluster = cluster
+		} else {
+			log.StartLogger.Fatalln("[upstream_cluster] in fault inject filter config is not string")
+		}
+	}
+
+	//bandwidth
+	if bandwidth, ok := config["read_bandwidth"]; ok {
+		if bandwidth, ok := bandwidth.(float64); ok {
+			faultInject.ReadBandwidth = uint64(bandwidth)
+		} else {
+			log.StartLogger.Fatalln("[read_bandwidth] in fault inject filter config is not integer")
+		}
+	}
+	if bandwidth, ok := config["write_bandwidth"]; ok {
+		if bandwidth, ok := bandwidth.(float64); ok {
+			faultInject.WriteBandwidth = uint64(bandwidth)
+		} else {
+			log.StartLogger.Fatalln("[write_bandwidth] in fault inject filter config is not integer")
+		}
+	}
+
+	//reset
+	if percent, ok := config["reset_percent"]; ok {
+		if percent, ok := percent.(float64); ok {
+			faultInject.ResetPercent = uint32(percent)
+		} else {
+			log.StartLogger.Fatalln("[reset_percent] in fault inject filter config is not integer")
+		}
+	}
+	if bytes, ok := config["reset_after_bytes"]; ok {
+		if bytes, ok := bytes.(float64); ok {
+			faultInject.ResetAfterBytes = uint64(bytes)
+		} else {
+			log.StartLogger.Fatalln("[reset_after_bytes] in fault inject filter config is not integer")
+		}
+	}
+	if interval, ok := config["reset_after_interval"]; ok {
+		if interval, ok := interval.(string); ok {
+			if interval, error := time.ParseDuration(strings.Trim(interval, `"`)); error == nil {
+				faultInject.ResetAfterInterval = interval
+			} else {
+				log.StartLogger.Fatalln("[reset_after_interval] in fault inject filter config is not valid ,", error)
+			}
+		} else {
+			log.StartLogger.Fatalln("[reset_after_interval] in fault inject filter config is not a numeric string, like '30s'")
+		}
+	}
+	if faultInject.ResetPercent > 0 && faultInject.ResetAfterBytes == 0 && faultInject.ResetAfterInterval == 0 {
+		log.StartLogger.Fatalln("[reset_after_bytes] or [reset_after_interval] is required in fault inject filter config")
+	}
+
+	//black hole
+	if percent, ok := config["black_hole_percent"]; ok {
+		if percent, ok := percent.(float64); ok {
+			faultInject.BlackHolePercent = uint32(percent)
+		} else {
+			log.StartLogger.Fatalln("[black_hole_percent] in fault inject filter config is not integer")
+		}
+	}
+
+	if faultInject.ResetPercent > 100 || faultInject.BlackHolePercent > 100 {
+		log.StartLogger.Fatalln("[reset_percent] and [black_hole_percent] in fault inject filter config should not be greater than 100")
+	}
+
 	return faultInject
 }
 
+func parseFaultInjectHeaders(config interface{}) []v2.HeaderMatcher {
+	headers, ok := config.([]interface{})
+	if !ok {
+		log.StartLogger.Fatalln("[headers] in fault inject filter config is not array")
+	}
+
+	var result []v2.HeaderMatcher
+	for _, header := range headers {
+		header, ok := header.(map[string]interface{})
+		if !ok {
+			log.StartLogger.Fatalln("[headers] in fault inject filter config is not array of object")
+		}
+
+		matcher := v2.HeaderMatcher{}
+		if name, ok := header["name"].(string); ok && name != "" {
+			matcher.Name = name
+		} else {
+			log.StartLogger.Fatalln("[name] of the header in fault inject filter config is required")
+		}
+		if value, ok := header["value"]; ok {
+			if value, ok := value.(string); ok {
+				matcher.Value = value
+			} else {
+				log.StartLogger.Fatalln("[value] of the header in fault inject filter config is not string")
+			}
+		}
+		if regex, ok := header["regex"]; ok {
+			if regex, ok := regex.(bool); ok {
+				matcher.Regex = regex
+			} else {
+				log.StartLogger.Fatalln("[regex] of the header in fault inject filter config is not bool")
+			}
+		}
+
+		result = append(result, matcher)
+	}
+
+	return result
+}
+
+// ParseRateLimitFilter
+func ParseRateLimitFilter(config map[string]interface{}) (*v2.RateLimitFilter, error) {
+	filterConfig := &RateLimitFilterConfig{}
+
+	if data, err := json.Marshal(config); err == nil {
+		if err := json.Unmarshal(data, filterConfig); err != nil {
+			return nil, fmt.Errorf("parsing rate limit filter config failed: %v", err)
+		}
+	} else {
+		return nil, fmt.Errorf("parsing rate limit filter config failed: %v", err)
+	}
+
+	return parseRateLimitFilterConfig(filterConfig)
+}
+
+func parseRateLimitFilterConfig(filterConfig *RateLimitFilterConfig) (*v2.RateLimitFilter, error) {
+	if len(filterConfig.Limits) == 0 {
+		return nil, fmt.Errorf("[limits] is required in rate limit filter config")
+	}
+
+	rateLimit := &v2.RateLimitFilter{
+		StatPrefix: filterConfig.StatPrefix,
+		Stage:      filterConfig.Stage,
+	}
+
+	for _, limit := range filterConfig.Limits {
+		if len(limit.Descriptor) == 0 {
+			return nil, fmt.Errorf("[descriptor] is required in rate limit filter config")
+		}
+		if limit.MaxTokens == 0 {
+			return nil, fmt.Errorf("[max_tokens] in rate limit filter config should be greater than 0")
+		}
+		if limit.FillInterval.Duration <= 0 {
+			return nil, fmt.Errorf("[fill_interval] in rate limit filter config should be greater than 0")
+		}
+
+		localLimit := v2.LocalRateLimit{
+			MaxTokens:     limit.MaxTokens,
+			TokensPerFill: limit.TokensPerFill,
+			FillInterval:  limit.FillInterval.Duration,
+		}
+		if localLimit.TokensPerFill == 0 {
+			localLimit.TokensPerFill = limit.MaxTokens
+		}
+
+		for _, entry := range limit.Descriptor {
+			if entry.Key == "" {
+				return nil, fmt.Errorf("[key] is required in rate limit descriptor")
+			}
+			localLimit.Descriptor = append(localLimit.Descriptor, v2.RateLimitDescriptorEntry{
+				Key:   entry.Key,
+				Value: entry.Value,
+			})
+		}
+
+		rateLimit.Limits = append(rateLimit.Limits, localLimit)
+	}
+
+	return rateLimit, nil
+}
+
+// ParseGlobalRateLimitFilter
+func ParseGlobalRateLimitFilter(config map[string]interface{}) (*v2.GlobalRateLimitFilter, error) {
+	filterConfig := &GlobalRateLimitFilterConfig{}
+
+	if data, err := json.Marshal(config); err == nil {
+		if err := json.Unmarshal(data, filterConfig); err != nil {
+			return nil, fmt.Errorf("parsing global rate limit filter config failed: %v", err)
+		}
+	} else {
+		return nil, fmt.Errorf("parsing global rate limit filter config failed: %v", err)
+	}
+
+	return parseGlobalRateLimitFilterConfig(filterConfig)
+}
+
+func parseGlobalRateLimitFilterConfig(filterConfig *GlobalRateLimitFilterConfig) (*v2.GlobalRateLimitFilter, error) {
+	if filterConfig.Domain == "" {
+		return nil, fmt.Errorf("[domain] is required in global rate limit filter config")
+	}
+	if filterConfig.Address == "" {
+		return nil, fmt.Errorf("[address] is required in global rate limit filter config")
+	}
+	if filterConfig.Timeout.Duration < 0 {
+		return nil, fmt.Errorf("[timeout] in global rate limit filter config should not be negative")
+	}
+
+	rateLimit := &v2.GlobalRateLimitFilter{
+		Domain:          filterConfig.Domain,
+		Address:         filterConfig.Address,
+		Stage:           filterConfig.Stage,
+		Timeout:         filterConfig.Timeout.Duration,
+		FailureModeDeny: filterConfig.FailureModeDeny,
+		StatPrefix:      filterConfig.StatPrefix,
+	}
+	if rateLimit.Timeout == 0 {
+		rateLimit.Timeout = DefaultGlobalRateLimitTimeout
+	}
+
+	return rateLimit, nil
+}
+
+// ParseStatsdSink
+func ParseStatsdSink(config map[string]interface{}) (*v2.StatsdSink, error) {
+	sink := &v2.StatsdSink{}
+
+	//address
+	if address, ok := config["address"]; ok {
+		if address, ok := address.(string); ok {
+			sink.Address = address
+		} else {
+			return nil, fmt.Errorf("[address] in statsd sink config is not string")
+		}
+	} else {
+		return nil, fmt.Errorf("[address] is required in statsd sink config")
+	}
+
+	//prefix
+	if prefix, ok := config["prefix"]; ok {
+		if prefix, ok := prefix.(string); ok {
+			sink.Prefix = prefix
+		} else {
+			return nil, fmt.Errorf("[prefix] in statsd sink config is not string")
+		}
+	}
+
+	//dogstatsd
+	if dogstatsd, ok := config["dogstatsd"]; ok {
+		if dogstatsd, ok := dogstatsd.(bool); ok {
+			sink.DogStatsD = dogstatsd
+		} else {
+			return nil, fmt.Errorf("[dogstatsd] in statsd sink config is not bool")
+		}
+	}
+
+	return sink, nil
+}
+
+// ParseZipkinReporter
+func ParseZipkinReporter(config map[string]interface{}) (*v2.ZipkinReporter, error) {
+	reporter := &v2.ZipkinReporter{}
+
+	//collector endpoint
+	if endpoint, ok := config["collector_endpoint"]; ok {
+		if endpoint, ok := endpoint.(string); ok {
+			reporter.CollectorEndpoint = endpoint
+		} else {
+			return nil, fmt.Errorf("[collector_endpoint] in zipkin reporter config is not string")
+		}
+	} else {
+		return nil, fmt.Errorf("[collector_endpoint] is required in zipkin reporter config")
+	}
+
+	//service name
+	if serviceName, ok := config["service_name"]; ok {
+		if serviceName, ok := serviceName.(string); ok {
+			reporter.ServiceName = serviceName
+		} else {
+			return nil, fmt.Errorf("[service_name] in zipkin reporter config is not string")
+		}
+	}
+
+	//batch size
+	if batchSize, ok := config["batch_size"]; ok {
+		if batchSize, ok := batchSize.(float64); ok {
+			reporter.BatchSize = int(batchSize)
+		} else {
+			return nil, fmt.Errorf("[batch_size] in zipkin reporter config is not number")
+		}
+	}
+
+	//flush interval
+	if interval, ok := config["flush_interval"]; ok {
+		if interval, ok := interval.(string); ok {
+			duration, err := time.ParseDuration(interval)
+			if err != nil {
+				return nil, fmt.Errorf("[flush_interval] in zipkin reporter config is invalid: %v", err)
+			}
+			reporter.FlushInterval = duration
+		} else {
+			return nil, fmt.Errorf("[flush_interval] in zipkin reporter config is not string")
+		}
+	}
+
+	return reporter, nil
+}
+
 // ParseHealthcheckFilter
 func ParseHealthcheckFilter(config map[string]interface{}) *v2.HealthCheckFilter {
 	healthcheck := &v2.HealthCheckFilter{}
@@ -691,6 +1340,8 @@ func ParseClusterConfig(clusters []ClusterConfig) ([]v2.Cluster, map[string][]v2
 
 			HealthCheck:      parseClusterHealthCheckConf(&c.HealthCheck),
 			CirBreThresholds: parseCircuitBreakers(c.CircuitBreakers),
+			OutlierDetection: parseOutlierDetection(&c.OutlierDetection),
+			ZoneAware:        parseZoneAware(&c.ZoneAware),
 
 			Spec: parseConfigSpecConfig(&clusterSpec),
 			LBSubSetConfig: v2.LBSubsetConfig{
@@ -724,7 +1375,7 @@ func parseClusterHealthCheckConf(c *ClusterHealthCheckConfig) v2.HealthCheck {
 	if c.Protocol == "" {
 		log.StartLogger.Warnf("healthcheck for cluster is disabled")
 
-	} else if _, ok := protocolsSupported[c.Protocol]; ok {
+	} else if _, ok := protocolsSupported[c.Protocol]; ok || c.Protocol == v2.TCP_HEALTH_CHECK {
 		healthcheckInstance = v2.HealthCheck{
 			Protocol:           c.Protocol,
 			Timeout:            c.Timeout.Duration,
@@ -735,6 +1386,22 @@ func parseClusterHealthCheckConf(c *ClusterHealthCheckConfig) v2.HealthCheck {
 			CheckPath:          c.CheckPath,
 			ServiceName:        c.ServiceName,
 		}
+
+		if c.TCPSend != "" {
+			send, err := hex.DecodeString(c.TCPSend)
+			if err != nil {
+				log.StartLogger.Fatalln("invalid hex payload in tcp health check:", c.TCPSend)
+			}
+			healthcheckInstance.TCPSend = send
+		}
+
+		for _, r := range c.TCPReceive {
+			receive, err := hex.DecodeString(r)
+			if err != nil {
+				log.StartLogger.Fatalln("invalid hex payload in tcp health check:", r)
+			}
+			healthcheckInstance.TCPReceive = append(healthcheckInstance.TCPReceive, receive)
+		}
 	} else {
 		log.StartLogger.Fatal("unsupported health check protocol:", c.Protocol)
 	}
@@ -742,6 +1409,38 @@ func parseClusterHealthCheckConf(c *ClusterHealthCheckConfig) v2.HealthCheck {
 	return healthcheckInstance
 }
 
+func parseOutlierDetection(c *OutlierDetectionConfig) v2.OutlierDetection {
+	if c.EnforcingConsecutive5xx > 100 || c.EnforcingConsecutiveGatewayFailure > 100 ||
+		c.EnforcingSuccessRate > 100 || c.MaxEjectionPercent > 100 {
+		log.StartLogger.Fatalln("percentage in outlier detection config should not be larger than 100")
+	}
+
+	return v2.OutlierDetection{
+		Consecutive5xx:                     c.Consecutive5xx,
+		Interval:                           c.Interval.Duration,
+		BaseEjectionTime:                   c.BaseEjectionTime.Duration,
+		MaxEjectionPercent:                 c.MaxEjectionPercent,
+		ConsecutiveGatewayFailure:          c.ConsecutiveGatewayFailure,
+		EnforcingConsecutive5xx:            c.EnforcingConsecutive5xx,
+		EnforcingConsecutiveGatewayFailure: c.EnforcingConsecutiveGatewayFailure,
+		EnforcingSuccessRate:               c.EnforcingSuccessRate,
+		SuccessRateMinimumHosts:            c.SuccessRateMinimumHosts,
+		SuccessRateRequestVolume:           c.SuccessRateRequestVolume,
+		SuccessRateStdevFactor:             c.SuccessRateStdevFactor,
+	}
+}
+
+func parseZoneAware(c *ZoneAwareConfig) v2.ZoneAware {
+	if c.FallbackThreshold > 100 {
+		log.StartLogger.Fatalln("fallback threshold in zone aware config should not be larger than 100")
+	}
+
+	return v2.ZoneAware{
+		Enable:            c.Enable,
+		FallbackThreshold: c.FallbackThreshold,
+	}
+}
+
 func parseCircuitBreakers(cbcs []*CircuitBreakerConfig) v2.CircuitBreakers {
 	var cb v2.CircuitBreakers
 	var rp v2.RoutingPriority
@@ -799,11 +1498,14 @@ func parseHostConfig(c *ClusterConfig) []v2.Host {
 		}
 
 		hosts = append(hosts, v2.Host{
-			host.Address,
-			host.Hostname,
-			getHostWeight(host.Weight),
-			parseRouterMetadata(host.MetaData),
-			host.TLSDisable,
+			Address:        host.Address,
+			Hostname:       host.Hostname,
+			Weight:         getHostWeight(host.Weight),
+			MetaData:       parseRouterMetadata(host.MetaData),
+			TLSDisable:     host.TLSDisable,
+			Priority:       host.Priority,
+			Locality:       host.Locality,
+			LocalityWeight: host.LocalityWeight,
 		})
 	}
 
@@ -834,6 +1536,7 @@ func ParseServiceRegistry(src ServiceRegistryConfig) {
 		AntShareCloud: src.ServiceAppInfo.AntShareCloud,
 		DataCenter:    src.ServiceAppInfo.DataCenter,
 		AppName:       src.ServiceAppInfo.AppName,
+		Zone:          src.ServiceAppInfo.Zone,
 	}
 
 	var SrvPubInfoArray []v2.PublishInfo
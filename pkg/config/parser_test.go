@@ -298,6 +298,119 @@ func Test_parseRouters(t *testing.T) {
 	}
 }
 
+func Test_parseRateLimitFilterConfig(t *testing.T) {
+	filterConfig := &RateLimitFilterConfig{}
+	json.Unmarshal([]byte(`{
+		"stat_prefix": "test",
+		"limits": [
+			{
+				"descriptor": [{"key": "remote_address"}],
+				"max_tokens": 100,
+				"fill_interval": "1s"
+			}
+		]
+	}`), filterConfig)
+
+	rateLimit, err := parseRateLimitFilterConfig(filterConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rateLimit.StatPrefix != "test" || len(rateLimit.Limits) != 1 {
+		t.Fatalf("unexpected rate limit filter %+v", rateLimit)
+	}
+	limit := rateLimit.Limits[0]
+	if limit.MaxTokens != 100 || limit.TokensPerFill != 100 || limit.FillInterval != time.Second ||
+		len(limit.Descriptor) != 1 || limit.Descriptor[0].Key != "remote_address" {
+		t.Errorf("unexpected local rate limit %+v", limit)
+	}
+
+	filterConfig.Limits[0].MaxTokens = 0
+	if _, err := parseRateLimitFilterConfig(filterConfig); err == nil {
+		t.Error("expected error without max_tokens")
+	}
+}
+
+func Test_parseGlobalRateLimitFilterConfig(t *testing.T) {
+	filterConfig := &GlobalRateLimitFilterConfig{
+		Domain:  "mosn",
+		Address: "127.0.0.1:8081",
+	}
+
+	rateLimit, err := parseGlobalRateLimitFilterConfig(filterConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rateLimit.Domain != "mosn" || rateLimit.Timeout != DefaultGlobalRateLimitTimeout || rateLimit.FailureModeDeny {
+		t.Errorf("unexpected global rate limit filter %+v", rateLimit)
+	}
+
+	filterConfig.Domain = ""
+	if _, err := parseGlobalRateLimitFilterConfig(filterConfig); err == nil {
+		t.Error("expected error without domain")
+	}
+}
+
+func TestParseFaultInjectFilter(t *testing.T) {
+	faultInject := ParseFaultInjectFilter(map[string]interface{}{
+		"abort_percent":     float64(100),
+		"abort_status":      float64(503),
+		"abort_bolt_status": float64(2),
+		"headers": []interface{}{
+			map[string]interface{}{"name": "x-fault", "value": "true"},
+		},
+		"upstream_cluster":     "test",
+		"reset_percent":        float64(10),
+		"reset_after_interval": "1s",
+	})
+
+	if faultInject.DelayPercent != 0 || faultInject.AbortPercent != 100 || faultInject.AbortStatus != 503 ||
+		faultInject.AbortBoltStatus == nil || *faultInject.AbortBoltStatus != 2 || faultInject.UpstreamCluster != "test" {
+		t.Errorf("unexpected fault inject %+v", faultInject)
+	}
+	if len(faultInject.Headers) != 1 || faultInject.Headers[0].Name != "x-fault" || faultInject.Headers[0].Value != "true" {
+		t.Errorf("unexpected fault inject headers %+v", faultInject.Headers)
+	}
+	if faultInject.ResetPercent != 10 || faultInject.ResetAfterInterval != time.Second || faultInject.ResetAfterBytes != 0 {
+		t.Errorf("unexpected fault inject reset %+v", faultInject)
+	}
+}
+
+func Test_parseShadowPolicy(t *testing.T) {
+	if got := parseShadowPolicy(nil); got != nil {
+		t.Errorf("parseShadowPolicy() = %v, want nil", got)
+	}
+
+	got := parseShadowPolicy(&ShadowPolicy{Cluster: "shadow"})
+	if got == nil || got.Cluster != "shadow" || got.Percent != 100 {
+		t.Errorf("parseShadowPolicy() = %v, want all requests mirrored", got)
+	}
+
+	percent := uint32(0)
+	got = parseShadowPolicy(&ShadowPolicy{Cluster: "shadow", RuntimeKey: "key", Percent: &percent})
+	if got == nil || got.RuntimeKey != "key" || got.Percent != 0 {
+		t.Errorf("parseShadowPolicy() = %v, want no request mirrored", got)
+	}
+}
+
+func Test_parseHeaderValueOptions(t *testing.T) {
+	if got := parseHeaderValueOptions(nil); got != nil {
+		t.Errorf("parseHeaderValueOptions() = %v, want nil", got)
+	}
+
+	set := false
+	got := parseHeaderValueOptions([]*HeaderValueOption{
+		{Key: "x-append", Value: "%DownstreamRemoteAddress%"},
+		{Key: "x-set", Value: "value", Append: &set},
+	})
+	want := []*v2.HeaderValueOption{
+		{Key: "x-append", Value: "%DownstreamRemoteAddress%", Append: true},
+		{Key: "x-set", Value: "value", Append: false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHeaderValueOptions() = %v, want %v", got, want)
+	}
+}
+
 func Test_parseWeightClusters(t *testing.T) {
 	tests := []struct {
 		name string
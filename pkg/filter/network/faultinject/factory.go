@@ -35,8 +35,9 @@ type faultInjectConfigFactory struct {
 }
 
 func (f *faultInjectConfigFactory) CreateFilterChain(context context.Context, clusterManager types.ClusterManager, callbacks types.NetWorkFilterChainFactoryCallbacks) {
-	rf := NewFaultInjector(f.FaultInject)
-	callbacks.AddReadFilter(rf)
+	fi := newFaultInjector(f.FaultInject)
+	callbacks.AddReadFilter(fi)
+	callbacks.AddWriteFilter(fi)
 }
 
 func CreateFaultInjectFactory(conf map[string]interface{}, isV2 bool) (types.NetworkFilterChainFactory, error) {
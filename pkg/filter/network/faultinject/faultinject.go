@@ -19,10 +19,12 @@ package faultinject
 
 import (
 	"math/rand"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/log"
 	"github.com/alipay/sofa-mosn/pkg/types"
 )
 
@@ -32,27 +34,80 @@ type faultInjector struct {
 	delayDuration uint64
 	delaying      uint32
 	readCallbacks types.ReadFilterCallbacks
+
+	readBandwidth  *bandwidthLimiter
+	writeBandwidth *bandwidthLimiter
+
+	// 1~100
+	resetPercent       uint32
+	resetAfterBytes    uint64
+	resetAfterInterval time.Duration
+	resetBytes         uint64
+	resetTimer         *time.Timer
+	resetOnce          sync.Once
+
+	// 1~100
+	blackHolePercent uint32
+	blackHole        bool
 }
 
 // NewFaultInjector makes a fault injector as types.ReadFilter
 func NewFaultInjector(config *v2.FaultInject) types.ReadFilter {
+	return newFaultInjector(config)
+}
+
+func newFaultInjector(config *v2.FaultInject) *faultInjector {
 	return &faultInjector{
-		delayPercent:  config.DelayPercent,
-		delayDuration: config.DelayDuration,
+		delayPercent:       config.DelayPercent,
+		delayDuration:      config.DelayDuration,
+		readBandwidth:      newBandwidthLimiter(config.ReadBandwidth),
+		writeBandwidth:     newBandwidthLimiter(config.WriteBandwidth),
+		resetPercent:       config.ResetPercent,
+		resetAfterBytes:    config.ResetAfterBytes,
+		resetAfterInterval: config.ResetAfterInterval,
+		blackHolePercent:   config.BlackHolePercent,
 	}
 }
 
 func (fi *faultInjector) OnData(buffer types.IoBuffer) types.FilterStatus {
+	if fi.blackHole {
+		buffer.Drain(buffer.Len())
+		return types.StopIteration
+	}
+
 	fi.tryInjectDelay()
 
 	if atomic.LoadUint32(&fi.delaying) > 0 {
 		return types.StopIteration
 	}
 
+	fi.readBandwidth.wait(buffer.Len())
+
 	return types.Continue
 }
 
 func (fi *faultInjector) OnNewConnection() types.FilterStatus {
+	conn := fi.readCallbacks.Connection()
+
+	// the black hole connection is accepted, but never read
+	if hit(fi.blackHolePercent) {
+		log.DefaultLogger.Debugf("[FaultInject] black hole connection %d", conn.ID())
+		fi.blackHole = true
+		conn.SetReadDisable(true)
+		return types.StopIteration
+	}
+
+	if hit(fi.resetPercent) {
+		if fi.resetAfterBytes > 0 {
+			conn.AddBytesReadListener(fi.onBytes)
+			conn.AddBytesSentListener(fi.onBytes)
+		}
+		if fi.resetAfterInterval > 0 {
+			fi.resetTimer = time.AfterFunc(fi.resetAfterInterval, fi.reset)
+		}
+		conn.AddConnectionEventListener(fi)
+	}
+
 	return types.Continue
 }
 
@@ -60,6 +115,41 @@ func (fi *faultInjector) InitializeReadFilterCallbacks(cb types.ReadFilterCallba
 	fi.readCallbacks = cb
 }
 
+// types.WriteFilter
+func (fi *faultInjector) OnWrite(buffer []types.IoBuffer) types.FilterStatus {
+	if fi.writeBandwidth != nil {
+		var length int
+		for _, buf := range buffer {
+			length += buf.Len()
+		}
+		fi.writeBandwidth.wait(length)
+	}
+
+	return types.Continue
+}
+
+// types.ConnectionEventListener
+func (fi *faultInjector) OnEvent(event types.ConnectionEvent) {
+	if event.IsClose() && fi.resetTimer != nil {
+		fi.resetTimer.Stop()
+	}
+}
+
+func (fi *faultInjector) onBytes(bytes uint64) {
+	if atomic.AddUint64(&fi.resetBytes, bytes) >= fi.resetAfterBytes {
+		fi.reset()
+	}
+}
+
+// reset closes the connection without flushing the pending data
+func (fi *faultInjector) reset() {
+	fi.resetOnce.Do(func() {
+		conn := fi.readCallbacks.Connection()
+		log.DefaultLogger.Debugf("[FaultInject] reset connection %d", conn.ID())
+		conn.Close(types.NoFlush, types.LocalClose)
+	})
+}
+
 func (fi *faultInjector) tryInjectDelay() {
 	if atomic.LoadUint32(&fi.delaying) > 0 {
 		return
@@ -71,7 +161,7 @@ func (fi *faultInjector) tryInjectDelay() {
 		if atomic.CompareAndSwapUint32(&fi.delaying, 0, 1) {
 			go func() {
 				select {
-				case <-time.After(time.Duration(duration) * time.Millisecond):
+				case <-time.After(time.Duration(duration)):
 					atomic.StoreUint32(&fi.delaying, 0)
 					fi.readCallbacks.ContinueReading()
 				}
@@ -81,13 +171,60 @@ func (fi *faultInjector) tryInjectDelay() {
 }
 
 func (fi *faultInjector) getDelayDuration() uint64 {
-	if fi.delayPercent == 0 {
+	if !hit(fi.delayPercent) {
 		return 0
 	}
 
-	if uint32(rand.Intn(100))+1 > fi.delayPercent {
-		return 0
+	return fi.delayDuration
+}
+
+// hit returns true in percent of the calls
+func hit(percent uint32) bool {
+	if percent == 0 {
+		return false
 	}
 
-	return fi.delayDuration
+	return uint32(rand.Intn(100))+1 <= percent
+}
+
+// bandwidthLimiter limits the bytes per second of a connection direction,
+// by blocking the reading or writing until the bytes are allowed
+type bandwidthLimiter struct {
+	bytesPerSecond uint64
+	mux            sync.Mutex
+	next           time.Time
+}
+
+// newBandwidthLimiter returns nil if the bandwidth is not limited
+func newBandwidthLimiter(bytesPerSecond uint64) *bandwidthLimiter {
+	if bytesPerSecond == 0 {
+		return nil
+	}
+
+	return &bandwidthLimiter{
+		bytesPerSecond: bytesPerSecond,
+	}
+}
+
+func (bl *bandwidthLimiter) wait(bytes int) {
+	if bl == nil || bytes <= 0 {
+		return
+	}
+
+	if delay := bl.reserve(bytes, time.Now()); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// reserve returns how long the bytes should wait to keep the bandwidth
+func (bl *bandwidthLimiter) reserve(bytes int, now time.Time) time.Duration {
+	bl.mux.Lock()
+	defer bl.mux.Unlock()
+
+	if bl.next.Before(now) {
+		bl.next = now
+	}
+	bl.next = bl.next.Add(time.Duration(uint64(bytes) * uint64(time.Second) / bl.bytesPerSecond))
+
+	return bl.next.Sub(now)
 }
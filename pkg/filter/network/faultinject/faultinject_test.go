@@ -0,0 +1,54 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package faultinject
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBandwidthLimiterReserve(t *testing.T) {
+	if newBandwidthLimiter(0) != nil {
+		t.Fatal("expected no limiter without bandwidth")
+	}
+
+	bl := newBandwidthLimiter(1024)
+	now := time.Now()
+
+	if delay := bl.reserve(512, now); delay != 500*time.Millisecond {
+		t.Errorf("expected delay 500ms, got %v", delay)
+	}
+	if delay := bl.reserve(512, now); delay != time.Second {
+		t.Errorf("expected delay 1s, got %v", delay)
+	}
+	// the bandwidth is not accumulated while idle
+	if delay := bl.reserve(1024, now.Add(5*time.Second)); delay != time.Second {
+		t.Errorf("expected delay 1s after idle, got %v", delay)
+	}
+}
+
+func TestHit(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if hit(0) {
+			t.Fatal("expected no hit with 0 percent")
+		}
+		if !hit(100) {
+			t.Fatal("expected hit with 100 percent")
+		}
+	}
+}
@@ -0,0 +1,205 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cors
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/alipay/sofa-mosn/pkg/filter"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+func init() {
+	filter.RegisterStream("cors", CreateCorsFilterFactory)
+}
+
+const (
+	headerOrigin                        = "origin"
+	headerAccessControlRequestMethod    = "access-control-request-method"
+	headerAccessControlAllowOrigin      = "access-control-allow-origin"
+	headerAccessControlAllowCredentials = "access-control-allow-credentials"
+	headerAccessControlAllowMethods     = "access-control-allow-methods"
+	headerAccessControlAllowHeaders     = "access-control-allow-headers"
+	headerAccessControlExposeHeaders    = "access-control-expose-headers"
+	headerAccessControlMaxAge           = "access-control-max-age"
+)
+
+// types.StreamReceiverFilter
+// types.StreamSenderFilter
+// corsFilter applies the cors policy of the route, or the virtual host's one if the route has none.
+// The preflight requests from an allowed origin are replied locally, and the responses of the actual requests
+// from an allowed origin are decorated with the cors headers
+type corsFilter struct {
+	context context.Context
+
+	// policy is set only if the request comes from an allowed origin
+	policy    types.CorsPolicy
+	origin    string
+	preflight bool
+
+	decoderCb types.StreamReceiverFilterCallbacks
+	encoderCb types.StreamSenderFilterCallbacks
+}
+
+func newCorsFilter(context context.Context) *corsFilter {
+	return &corsFilter{
+		context: context,
+	}
+}
+
+func (f *corsFilter) OnDecodeHeaders(headers map[string]string, endStream bool) types.FilterHeadersStatus {
+	origin, ok := headers[headerOrigin]
+	if !ok || origin == "" {
+		return types.FilterHeadersStatusContinue
+	}
+
+	policy := f.corsPolicy()
+	if policy == nil || !policy.Enabled() || !isOriginAllowed(policy, origin) {
+		return types.FilterHeadersStatusContinue
+	}
+
+	f.policy = policy
+	f.origin = origin
+
+	if headers[protocol.MosnHeaderMethod] != http.MethodOptions || headers[headerAccessControlRequestMethod] == "" {
+		return types.FilterHeadersStatusContinue
+	}
+
+	log.ByContext(f.context).Debugf("[Cors] reply preflight request from origin %s", origin)
+
+	f.preflight = true
+	respHeaders := map[string]string{
+		types.HeaderStatus: strconv.Itoa(http.StatusOK),
+	}
+	f.setAllowOrigin(respHeaders)
+	if methods := policy.AllowMethods(); methods != "" {
+		respHeaders[headerAccessControlAllowMethods] = methods
+	}
+	if allowHeaders := policy.AllowHeaders(); allowHeaders != "" {
+		respHeaders[headerAccessControlAllowHeaders] = allowHeaders
+	}
+	if maxAge := policy.MaxAga(); maxAge != "" {
+		respHeaders[headerAccessControlMaxAge] = maxAge
+	}
+	f.decoderCb.AppendHeaders(respHeaders, true)
+
+	return types.FilterHeadersStatusStopIteration
+}
+
+func (f *corsFilter) OnDecodeData(buf types.IoBuffer, endStream bool) types.FilterDataStatus {
+	if f.preflight {
+		return types.FilterDataStatusStopIterationNoBuffer
+	}
+
+	return types.FilterDataStatusContinue
+}
+
+func (f *corsFilter) OnDecodeTrailers(trailers map[string]string) types.FilterTrailersStatus {
+	if f.preflight {
+		return types.FilterTrailersStatusStopIteration
+	}
+
+	return types.FilterTrailersStatusContinue
+}
+
+func (f *corsFilter) SetDecoderFilterCallbacks(cb types.StreamReceiverFilterCallbacks) {
+	f.decoderCb = cb
+}
+
+func (f *corsFilter) AppendHeaders(headers interface{}, endStream bool) types.FilterHeadersStatus {
+	// the preflight reply is already decorated
+	if f.policy == nil || f.preflight {
+		return types.FilterHeadersStatusContinue
+	}
+
+	if respHeaders, ok := headers.(map[string]string); ok {
+		f.setAllowOrigin(respHeaders)
+		if exposeHeaders := f.policy.ExposeHeaders(); exposeHeaders != "" {
+			respHeaders[headerAccessControlExposeHeaders] = exposeHeaders
+		}
+	}
+
+	return types.FilterHeadersStatusContinue
+}
+
+func (f *corsFilter) AppendData(buf types.IoBuffer, endStream bool) types.FilterDataStatus {
+	return types.FilterDataStatusContinue
+}
+
+func (f *corsFilter) AppendTrailers(trailers map[string]string) types.FilterTrailersStatus {
+	return types.FilterTrailersStatusContinue
+}
+
+func (f *corsFilter) SetEncoderFilterCallbacks(cb types.StreamSenderFilterCallbacks) {
+	f.encoderCb = cb
+}
+
+func (f *corsFilter) OnDestroy() {}
+
+// corsPolicy returns the route's cors policy, or the virtual host's one if the route has none
+func (f *corsFilter) corsPolicy() types.CorsPolicy {
+	route := f.decoderCb.Route()
+	if route == nil || route.RouteRule() == nil {
+		return nil
+	}
+
+	rule := route.RouteRule()
+	if policy := rule.Policy(); policy != nil && policy.CorsPolicy() != nil {
+		return policy.CorsPolicy()
+	}
+	if virtualHost := rule.VirtualHost(); virtualHost != nil {
+		return virtualHost.CorsPolicy()
+	}
+
+	return nil
+}
+
+func (f *corsFilter) setAllowOrigin(headers map[string]string) {
+	headers[headerAccessControlAllowOrigin] = f.origin
+	if f.policy.AllowCredentials() {
+		headers[headerAccessControlAllowCredentials] = "true"
+	}
+}
+
+func isOriginAllowed(policy types.CorsPolicy, origin string) bool {
+	for _, allowOrigin := range policy.AllowOrigins() {
+		if allowOrigin == "*" || allowOrigin == origin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ~~ factory
+type FilterConfigFactory struct{}
+
+func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks types.StreamFilterChainFactoryCallbacks) {
+	filter := newCorsFilter(context)
+	callbacks.AddStreamReceiverFilter(filter)
+	callbacks.AddStreamSenderFilter(filter)
+}
+
+// CreateCorsFilterFactory creates the cors filter factory, the cors policies are configured in the routes
+func CreateCorsFilterFactory(conf map[string]interface{}) (types.StreamFilterChainFactory, error) {
+	return &FilterConfigFactory{}, nil
+}
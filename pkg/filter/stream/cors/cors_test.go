@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/router"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// mockReceiverFilterCallbacks records the headers replied by the filter
+type mockReceiverFilterCallbacks struct {
+	types.StreamReceiverFilterCallbacks
+	route   types.Route
+	replied map[string]string
+}
+
+func (cb *mockReceiverFilterCallbacks) Route() types.Route {
+	return cb.route
+}
+
+func (cb *mockReceiverFilterCallbacks) AppendHeaders(headers interface{}, endStream bool) {
+	cb.replied = headers.(map[string]string)
+}
+
+func newTestRoute(t *testing.T, vhostCors, routeCors *v2.CorsPolicy) types.Route {
+	routers, err := router.NewRouteMatcher(&v2.Proxy{
+		VirtualHosts: []*v2.VirtualHost{
+			{
+				Name:    "test",
+				Domains: []string{"*"},
+				Routers: []v2.Router{
+					{
+						Match: v2.RouterMatch{Prefix: "/"},
+						Route: v2.RouteAction{ClusterName: "test", Cors: routeCors},
+					},
+				},
+				Cors: vhostCors,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return routers.Route(map[string]string{protocol.MosnHeaderPathKey: "/"}, 1)
+}
+
+func TestCorsFilterPreflight(t *testing.T) {
+	route := newTestRoute(t, &v2.CorsPolicy{
+		AllowOrigin:      []string{"https://example.com"},
+		AllowMethods:     "GET,POST",
+		AllowHeaders:     "content-type",
+		MaxAge:           "600",
+		AllowCredentials: true,
+		Enabled:          true,
+	}, nil)
+
+	cb := &mockReceiverFilterCallbacks{route: route}
+	f := newCorsFilter(context.Background())
+	f.SetDecoderFilterCallbacks(cb)
+
+	headers := map[string]string{
+		protocol.MosnHeaderMethod:        "OPTIONS",
+		headerOrigin:                     "https://example.com",
+		headerAccessControlRequestMethod: "POST",
+	}
+	if status := f.OnDecodeHeaders(headers, true); status != types.FilterHeadersStatusStopIteration {
+		t.Fatalf("preflight request should be replied, got status %s", status)
+	}
+
+	expected := map[string]string{
+		types.HeaderStatus:                  "200",
+		headerAccessControlAllowOrigin:      "https://example.com",
+		headerAccessControlAllowCredentials: "true",
+		headerAccessControlAllowMethods:     "GET,POST",
+		headerAccessControlAllowHeaders:     "content-type",
+		headerAccessControlMaxAge:           "600",
+	}
+	for k, v := range expected {
+		if cb.replied[k] != v {
+			t.Errorf("preflight reply header %s expected %s, but got %s", k, v, cb.replied[k])
+		}
+	}
+
+	// the origin is not allowed
+	cb.replied = nil
+	headers[headerOrigin] = "https://other.com"
+	f = newCorsFilter(context.Background())
+	f.SetDecoderFilterCallbacks(cb)
+	if status := f.OnDecodeHeaders(headers, true); status != types.FilterHeadersStatusContinue || cb.replied != nil {
+		t.Error("preflight request from the not allowed origin should be proxied")
+	}
+}
+
+func TestCorsFilterActualRequest(t *testing.T) {
+	// the route's policy takes precedence over the virtual host's one
+	route := newTestRoute(t, &v2.CorsPolicy{
+		AllowOrigin: []string{"https://example.com"},
+		Enabled:     true,
+	}, &v2.CorsPolicy{
+		AllowOrigin:   []string{"*"},
+		ExposeHeaders: "x-request-id",
+		Enabled:       true,
+	})
+
+	cb := &mockReceiverFilterCallbacks{route: route}
+	f := newCorsFilter(context.Background())
+	f.SetDecoderFilterCallbacks(cb)
+
+	headers := map[string]string{
+		protocol.MosnHeaderMethod: "GET",
+		headerOrigin:              "https://other.com",
+	}
+	if status := f.OnDecodeHeaders(headers, true); status != types.FilterHeadersStatusContinue {
+		t.Fatalf("actual request should be proxied, got status %s", status)
+	}
+
+	respHeaders := map[string]string{}
+	f.AppendHeaders(respHeaders, true)
+	if respHeaders[headerAccessControlAllowOrigin] != "https://other.com" ||
+		respHeaders[headerAccessControlExposeHeaders] != "x-request-id" {
+		t.Errorf("unexpected response headers %v", respHeaders)
+	}
+	if _, ok := respHeaders[headerAccessControlAllowCredentials]; ok {
+		t.Error("credentials should not be allowed")
+	}
+
+	// disabled policy
+	route = newTestRoute(t, nil, &v2.CorsPolicy{AllowOrigin: []string{"*"}})
+	cb.route = route
+	f = newCorsFilter(context.Background())
+	f.SetDecoderFilterCallbacks(cb)
+	f.OnDecodeHeaders(headers, true)
+	respHeaders = map[string]string{}
+	f.AppendHeaders(respHeaders, true)
+	if len(respHeaders) != 0 {
+		t.Errorf("disabled policy should not decorate the response, got %v", respHeaders)
+	}
+}
@@ -21,6 +21,7 @@ package faultinject
 import (
 	"context"
 	"math/rand"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -28,9 +29,18 @@ import (
 	"github.com/alipay/sofa-mosn/pkg/config"
 	"github.com/alipay/sofa-mosn/pkg/filter"
 	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/router"
+	"github.com/alipay/sofa-mosn/pkg/runtime"
 	"github.com/alipay/sofa-mosn/pkg/types"
 )
 
+// Runtime keys override the configured faults, so the faults can be changed without pushing new config
+const (
+	DelayPercentKey    = "fault.http.delay.fixed_delay_percent"
+	DelayDurationMsKey = "fault.http.delay.fixed_duration_ms"
+	AbortPercentKey    = "fault.http.abort.abort_percent"
+)
+
 func init() {
 	filter.RegisterStream("fault_inject", CreateFaultInjectFilterFactory)
 }
@@ -38,32 +48,70 @@ func init() {
 type faultInjectFilter struct {
 	context context.Context
 
-	delayPercent  uint32
-	delayDuration uint64
-	delaying      uint32
-	cb            types.StreamReceiverFilterCallbacks
+	delayPercent    uint32
+	delayDuration   uint64
+	abortPercent    uint32
+	abortStatus     int
+	abortBoltStatus *int16
+	matchHeaders    []*types.HeaderData
+	upstreamCluster string
+
+	matched  bool
+	aborted  uint32
+	delaying uint32
+	headers  map[string]string
+	cb       types.StreamReceiverFilterCallbacks
 }
 
 func NewFaultInjectFilter(context context.Context, config *v2.FaultInject) types.StreamReceiverFilter {
+	return newFaultInjectFilter(context, config, router.GetRouterHeaders(config.Headers))
+}
+
+// newFaultInjectFilter used to create new fault inject filter, the header datas are shared by the filters
+func newFaultInjectFilter(context context.Context, config *v2.FaultInject, matchHeaders []*types.HeaderData) *faultInjectFilter {
 	return &faultInjectFilter{
-		context:       context,
-		delayPercent:  config.DelayPercent,
-		delayDuration: config.DelayDuration,
+		context:         context,
+		delayPercent:    config.DelayPercent,
+		delayDuration:   config.DelayDuration,
+		abortPercent:    config.AbortPercent,
+		abortStatus:     config.AbortStatus,
+		abortBoltStatus: config.AbortBoltStatus,
+		matchHeaders:    matchHeaders,
+		upstreamCluster: config.UpstreamCluster,
 	}
 }
 
 func (f *faultInjectFilter) OnDecodeHeaders(headers map[string]string, endStream bool) types.FilterHeadersStatus {
-	f.tryInjectDelay()
+	f.matched = f.matchFault(headers)
+	if !f.matched {
+		return types.FilterHeadersStatusContinue
+	}
+
+	f.headers = headers
+	// the request is aborted after the delay, if both faults are injected
+	abort := f.shouldAbort()
+	f.tryInjectDelay(abort)
 
 	if atomic.LoadUint32(&f.delaying) > 0 {
 		return types.FilterHeadersStatusStopIteration
 	}
 
+	if abort {
+		f.abort()
+		return types.FilterHeadersStatusStopIteration
+	}
+
 	return types.FilterHeadersStatusContinue
 }
 
 func (f *faultInjectFilter) OnDecodeData(buf types.IoBuffer, endStream bool) types.FilterDataStatus {
-	f.tryInjectDelay()
+	if atomic.LoadUint32(&f.aborted) > 0 {
+		return types.FilterDataStatusStopIterationNoBuffer
+	}
+
+	if f.matched {
+		f.tryInjectDelay(false)
+	}
 
 	if atomic.LoadUint32(&f.delaying) > 0 {
 		return types.FilterDataStatusStopIterationAndBuffer
@@ -73,7 +121,13 @@ func (f *faultInjectFilter) OnDecodeData(buf types.IoBuffer, endStream bool) typ
 }
 
 func (f *faultInjectFilter) OnDecodeTrailers(trailers map[string]string) types.FilterTrailersStatus {
-	f.tryInjectDelay()
+	if atomic.LoadUint32(&f.aborted) > 0 {
+		return types.FilterTrailersStatusStopIteration
+	}
+
+	if f.matched {
+		f.tryInjectDelay(false)
+	}
 
 	if atomic.LoadUint32(&f.delaying) > 0 {
 		return types.FilterTrailersStatusStopIteration
@@ -88,7 +142,24 @@ func (f *faultInjectFilter) SetDecoderFilterCallbacks(cb types.StreamReceiverFil
 
 func (f *faultInjectFilter) OnDestroy() {}
 
-func (f *faultInjectFilter) tryInjectDelay() {
+// matchFault checks whether the faults should be injected into the request,
+// the request should match all the headers and be routed to the upstream cluster if they are configured
+func (f *faultInjectFilter) matchFault(headers map[string]string) bool {
+	if len(f.matchHeaders) > 0 && !router.ConfigUtilityInst.MatchHeaders(headers, f.matchHeaders) {
+		return false
+	}
+
+	if f.upstreamCluster != "" {
+		route := f.cb.Route()
+		if route == nil || route.RouteRule() == nil || route.RouteRule().ClusterName() != f.upstreamCluster {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *faultInjectFilter) tryInjectDelay(abort bool) {
 	if atomic.LoadUint32(&f.delaying) > 0 {
 		return
 	}
@@ -97,10 +168,15 @@ func (f *faultInjectFilter) tryInjectDelay() {
 
 	if duration > 0 {
 		if atomic.CompareAndSwapUint32(&f.delaying, 0, 1) {
+			f.cb.RequestInfo().SetResponseFlag(types.DelayInjected)
 			go func() {
 				select {
-				case <-time.After(time.Duration(duration) * time.Millisecond):
+				case <-time.After(time.Duration(duration)):
 					atomic.StoreUint32(&f.delaying, 0)
+					if abort {
+						f.abort()
+						return
+					}
 					log.ByContext(f.context).Debugf("[FaultInject] Continue after delay")
 					f.cb.ContinueDecoding()
 				}
@@ -110,29 +186,62 @@ func (f *faultInjectFilter) tryInjectDelay() {
 }
 
 func (f *faultInjectFilter) getDelayDuration() uint64 {
-	if f.delayPercent == 0 {
+	snapshot := runtime.Snapshot()
+
+	delayPercent := snapshot.GetInteger(DelayPercentKey, uint64(f.delayPercent))
+	if delayPercent == 0 {
 		return 0
 	}
 
-	if uint32(rand.Intn(100))+1 > f.delayPercent {
+	if uint64(rand.Intn(100))+1 > delayPercent {
 		return 0
 	}
 
+	if durationMs := snapshot.GetInteger(DelayDurationMsKey, 0); durationMs > 0 {
+		return durationMs * uint64(time.Millisecond)
+	}
+
 	return f.delayDuration
 }
 
+func (f *faultInjectFilter) shouldAbort() bool {
+	abortPercent := runtime.Snapshot().GetInteger(AbortPercentKey, uint64(f.abortPercent))
+	if abortPercent == 0 {
+		return false
+	}
+
+	return uint64(rand.Intn(100))+1 <= abortPercent
+}
+
+// abort replies the request with the abort status directly
+func (f *faultInjectFilter) abort() {
+	atomic.StoreUint32(&f.aborted, 1)
+	log.ByContext(f.context).Debugf("[FaultInject] Abort with status %d", f.abortStatus)
+
+	f.cb.RequestInfo().SetResponseFlag(types.FaultInjected)
+	f.headers[types.HeaderStatus] = strconv.Itoa(f.abortStatus)
+	if f.abortBoltStatus != nil {
+		f.headers[types.HeaderRpcStatus] = strconv.Itoa(int(*f.abortBoltStatus))
+	}
+	f.cb.AppendHeaders(f.headers, true)
+}
+
 // ~~ factory
 type FilterConfigFactory struct {
-	FaultInject *v2.FaultInject
+	FaultInject  *v2.FaultInject
+	matchHeaders []*types.HeaderData
 }
 
 func (f *FilterConfigFactory) CreateFilterChain(context context.Context, callbacks types.StreamFilterChainFactoryCallbacks) {
-	filter := NewFaultInjectFilter(context, f.FaultInject)
+	filter := newFaultInjectFilter(context, f.FaultInject, f.matchHeaders)
 	callbacks.AddStreamReceiverFilter(filter)
 }
 
 func CreateFaultInjectFilterFactory(conf map[string]interface{}) (types.StreamFilterChainFactory, error) {
+	faultInject := config.ParseFaultInjectFilter(conf)
+
 	return &FilterConfigFactory{
-		FaultInject: config.ParseFaultInjectFilter(conf),
+		FaultInject:  faultInject,
+		matchHeaders: router.GetRouterHeaders(faultInject.Headers),
 	}, nil
 }
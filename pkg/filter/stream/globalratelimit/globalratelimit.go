@@ -0,0 +1,287 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package globalratelimit
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/config"
+	"github.com/alipay/sofa-mosn/pkg/filter"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/router"
+	"github.com/alipay/sofa-mosn/pkg/stats"
+	"github.com/alipay/sofa-mosn/pkg/types"
+	"github.com/rcrowley/go-metrics"
+)
+
+func init() {
+	filter.RegisterStream("global_rate_limit", CreateGlobalRateLimitFilterFactory)
+}
+
+// global rate limit stats key
+const (
+	GlobalRateLimitTotal              = "total"
+	GlobalRateLimitOK                 = "ok"
+	GlobalRateLimitOverLimit          = "over_limit"
+	GlobalRateLimitError              = "error"
+	GlobalRateLimitFailureModeAllowed = "failure_mode_allowed"
+)
+
+// the states of the rate limit call
+const (
+	callIdle uint32 = iota
+	callRunning
+	callCompleted
+	callCancelled
+)
+
+type globalRateLimitStats struct {
+	stats *stats.Stats
+}
+
+func newGlobalRateLimitStats(statPrefix string) *globalRateLimitStats {
+	namespace := "globalratelimit"
+	if statPrefix != "" {
+		namespace = namespace + "." + statPrefix
+	}
+
+	return &globalRateLimitStats{
+		stats: stats.NewStats(namespace).AddCounter(GlobalRateLimitTotal).AddCounter(GlobalRateLimitOK).
+			AddCounter(GlobalRateLimitOverLimit).AddCounter(GlobalRateLimitError).AddCounter(GlobalRateLimitFailureModeAllowed),
+	}
+}
+
+func (s *globalRateLimitStats) Total() metrics.Counter {
+	return s.stats.Counter(GlobalRateLimitTotal)
+}
+
+func (s *globalRateLimitStats) OK() metrics.Counter {
+	return s.stats.Counter(GlobalRateLimitOK)
+}
+
+func (s *globalRateLimitStats) OverLimit() metrics.Counter {
+	return s.stats.Counter(GlobalRateLimitOverLimit)
+}
+
+func (s *globalRateLimitStats) Error() metrics.Counter {
+	return s.stats.Counter(GlobalRateLimitError)
+}
+
+func (s *globalRateLimitStats) FailureModeAllowed() metrics.Counter {
+	return s.stats.Counter(GlobalRateLimitFailureModeAllowed)
+}
+
+// globalRateLimit is shared by the filters created by the same factory
+type globalRateLimit struct {
+	domain          string
+	stage           uint64
+	timeout         time.Duration
+	failureModeDeny bool
+	client          rateLimitClient
+	stats           *globalRateLimitStats
+}
+
+// shouldLimit calls the rate limit service, the request is limited if the service replies over limit,
+// or the call fails in failure mode deny
+func (rl *globalRateLimit) shouldLimit(ctx context.Context, descriptors []types.Descriptor) bool {
+	rl.stats.Total().Inc(1)
+
+	response, err := rl.client.ShouldRateLimit(ctx, newRateLimitRequest(rl.domain, descriptors))
+	if err != nil || response.OverallCode == RateLimitResponse_UNKNOWN {
+		rl.stats.Error().Inc(1)
+		if rl.failureModeDeny {
+			return true
+		}
+		rl.stats.FailureModeAllowed().Inc(1)
+		return false
+	}
+
+	if response.OverallCode == RateLimitResponse_OVER_LIMIT {
+		rl.stats.OverLimit().Inc(1)
+		return true
+	}
+
+	rl.stats.OK().Inc(1)
+	return false
+}
+
+func newRateLimitRequest(domain string, descriptors []types.Descriptor) *RateLimitRequest {
+	request := &RateLimitRequest{
+		Domain:      domain,
+		Descriptors: make([]*RateLimitDescriptor, 0, len(descriptors)),
+		HitsAddend:  1,
+	}
+
+	for _, descriptor := range descriptors {
+		d := &RateLimitDescriptor{
+			Entries: make([]*RateLimitDescriptor_Entry, 0, len(descriptor.Entries)),
+		}
+		for _, entry := range descriptor.Entries {
+			d.Entries = append(d.Entries, &RateLimitDescriptor_Entry{
+				Key:   entry.Key,
+				Value: entry.Value,
+			})
+		}
+		request.Descriptors = append(request.Descriptors, d)
+	}
+
+	return request
+}
+
+// types.StreamReceiverFilter
+// globalRateLimitFilter stops the request until the rate limit service replies,
+// the limited requests are replied with 429, which is converted to a bolt status for sofa rpc
+type globalRateLimitFilter struct {
+	context   context.Context
+	rateLimit *globalRateLimit
+
+	state   uint32
+	limited bool
+	cancel  context.CancelFunc
+	cb      types.StreamReceiverFilterCallbacks
+}
+
+// newGlobalRateLimitFilter used to create new global rate limit filter
+func newGlobalRateLimitFilter(context context.Context, rateLimit *globalRateLimit) types.StreamReceiverFilter {
+	return &globalRateLimitFilter{
+		context:   context,
+		rateLimit: rateLimit,
+	}
+}
+
+func (f *globalRateLimitFilter) OnDecodeHeaders(headers map[string]string, endStream bool) types.FilterHeadersStatus {
+	var remoteAddr string
+	if conn := f.cb.Connection(); conn != nil && conn.RemoteAddr() != nil {
+		remoteAddr = conn.RemoteAddr().String()
+	}
+
+	descriptors := router.PopulateRateLimitDescriptors(f.cb.Route(), f.rateLimit.stage, headers, remoteAddr)
+	if len(descriptors) == 0 {
+		return types.FilterHeadersStatusContinue
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), f.rateLimit.timeout)
+	f.cancel = cancel
+	atomic.StoreUint32(&f.state, callRunning)
+
+	go f.call(ctx, descriptors, headers)
+
+	return types.FilterHeadersStatusStopIteration
+}
+
+func (f *globalRateLimitFilter) call(ctx context.Context, descriptors []types.Descriptor, headers map[string]string) {
+	limited := f.rateLimit.shouldLimit(ctx, descriptors)
+	f.cancel()
+
+	if limited {
+		f.limited = true
+	}
+	// the stream is destroyed while calling
+	if !atomic.CompareAndSwapUint32(&f.state, callRunning, callCompleted) {
+		return
+	}
+
+	if limited {
+		log.ByContext(f.context).Debugf("[GlobalRateLimit] request is limited, descriptors = %v", descriptors)
+
+		f.cb.RequestInfo().SetResponseFlag(types.RateLimited)
+		headers[types.HeaderStatus] = strconv.Itoa(types.RateLimitedCode)
+		f.cb.AppendHeaders(headers, true)
+		return
+	}
+
+	f.cb.ContinueDecoding()
+}
+
+func (f *globalRateLimitFilter) OnDecodeData(buf types.IoBuffer, endStream bool) types.FilterDataStatus {
+	switch atomic.LoadUint32(&f.state) {
+	case callRunning:
+		return types.FilterDataStatusStopIterationAndBuffer
+	case callCompleted:
+		if f.limited {
+			return types.FilterDataStatusStopIterationNoBuffer
+		}
+	}
+
+	return types.FilterDataStatusContinue
+}
+
+func (f *globalRateLimitFilter) OnDecodeTrailers(trailers map[string]string) types.FilterTrailersStatus {
+	switch atomic.LoadUint32(&f.state) {
+	case callRunning:
+		return types.FilterTrailersStatusStopIteration
+	case callCompleted:
+		if f.limited {
+			return types.FilterTrailersStatusStopIteration
+		}
+	}
+
+	return types.FilterTrailersStatusContinue
+}
+
+func (f *globalRateLimitFilter) SetDecoderFilterCallbacks(cb types.StreamReceiverFilterCallbacks) {
+	f.cb = cb
+}
+
+func (f *globalRateLimitFilter) OnDestroy() {
+	if atomic.CompareAndSwapUint32(&f.state, callRunning, callCancelled) {
+		f.cancel()
+	}
+}
+
+// GlobalRateLimitFilterConfigFactory Filter Config Factory
+type GlobalRateLimitFilterConfigFactory struct {
+	rateLimit *globalRateLimit
+}
+
+func (f *GlobalRateLimitFilterConfigFactory) CreateFilterChain(context context.Context, callbacks types.StreamFilterChainFactoryCallbacks) {
+	filter := newGlobalRateLimitFilter(context, f.rateLimit)
+	callbacks.AddStreamReceiverFilter(filter)
+}
+
+// CreateGlobalRateLimitFilterFactory
+func CreateGlobalRateLimitFilterFactory(conf map[string]interface{}) (types.StreamFilterChainFactory, error) {
+	rateLimit, err := config.ParseGlobalRateLimitFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := getRateLimitClient(rateLimit.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GlobalRateLimitFilterConfigFactory{
+		rateLimit: newGlobalRateLimit(rateLimit, client),
+	}, nil
+}
+
+func newGlobalRateLimit(config *v2.GlobalRateLimitFilter, client rateLimitClient) *globalRateLimit {
+	return &globalRateLimit{
+		domain:          config.Domain,
+		stage:           uint64(config.Stage),
+		timeout:         config.Timeout,
+		failureModeDeny: config.FailureModeDeny,
+		client:          client,
+		stats:           newGlobalRateLimitStats(config.StatPrefix),
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package globalratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/types"
+	"google.golang.org/grpc"
+)
+
+// mockRateLimitService limits the descriptors with the limited key, and sleeps delay before reply
+type mockRateLimitService struct {
+	limitedKey string
+	delay      time.Duration
+}
+
+func (s *mockRateLimitService) ShouldRateLimit(ctx context.Context, request *RateLimitRequest) (*RateLimitResponse, error) {
+	time.Sleep(s.delay)
+
+	response := &RateLimitResponse{
+		OverallCode: RateLimitResponse_OK,
+	}
+	for _, descriptor := range request.Descriptors {
+		code := RateLimitResponse_OK
+		for _, entry := range descriptor.Entries {
+			if entry.Key == s.limitedKey {
+				code = RateLimitResponse_OVER_LIMIT
+				response.OverallCode = RateLimitResponse_OVER_LIMIT
+			}
+		}
+		response.Statuses = append(response.Statuses, &RateLimitResponse_DescriptorStatus{Code: code})
+	}
+
+	return response, nil
+}
+
+func startRateLimitService(t *testing.T, service RateLimitServiceServer) (string, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := grpc.NewServer()
+	RegisterRateLimitServiceServer(s, service)
+	go s.Serve(ln)
+
+	return ln.Addr().String(), s.Stop
+}
+
+func newTestRateLimit(t *testing.T, address string, failureModeDeny bool) *globalRateLimit {
+	client, err := newRateLimitClient(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return newGlobalRateLimit(&v2.GlobalRateLimitFilter{
+		Domain:          "mosn",
+		Address:         address,
+		Timeout:         200 * time.Millisecond,
+		FailureModeDeny: failureModeDeny,
+		StatPrefix:      t.Name(),
+	}, client)
+}
+
+func TestGlobalRateLimitShouldLimit(t *testing.T) {
+	address, stop := startRateLimitService(t, &mockRateLimitService{limitedKey: "remote_address"})
+	defer stop()
+
+	rateLimit := newTestRateLimit(t, address, false)
+	ok, overLimit := rateLimit.stats.OK().Count(), rateLimit.stats.OverLimit().Count()
+
+	allowed := []types.Descriptor{
+		{Entries: []types.DescriptorEntry{{Key: "generic_key", Value: "test"}}},
+	}
+	if rateLimit.shouldLimit(context.Background(), allowed) {
+		t.Error("expected request allowed by the rate limit service")
+	}
+
+	limited := append(allowed, types.Descriptor{
+		Entries: []types.DescriptorEntry{{Key: "remote_address", Value: "127.0.0.1"}},
+	})
+	if !rateLimit.shouldLimit(context.Background(), limited) {
+		t.Error("expected request limited by the rate limit service")
+	}
+
+	if rateLimit.stats.OK().Count()-ok != 1 || rateLimit.stats.OverLimit().Count()-overLimit != 1 {
+		t.Errorf("unexpected stats, ok = %d, over_limit = %d",
+			rateLimit.stats.OK().Count()-ok, rateLimit.stats.OverLimit().Count()-overLimit)
+	}
+}
+
+func TestGlobalRateLimitFailureMode(t *testing.T) {
+	address, stop := startRateLimitService(t, &mockRateLimitService{delay: time.Second})
+	defer stop()
+
+	descriptors := []types.Descriptor{
+		{Entries: []types.DescriptorEntry{{Key: "generic_key", Value: "test"}}},
+	}
+
+	for _, failureModeDeny := range []bool{false, true} {
+		rateLimit := newTestRateLimit(t, address, failureModeDeny)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		limited := rateLimit.shouldLimit(ctx, descriptors)
+		cancel()
+
+		if limited != failureModeDeny {
+			t.Errorf("failure mode deny = %v, limited = %v", failureModeDeny, limited)
+		}
+	}
+}
+
+func TestGetRateLimitClient(t *testing.T) {
+	client, err := getRateLimitClient("127.0.0.1:18081")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := getRateLimitClient("127.0.0.1:18081")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached != client {
+		t.Error("expected the client of the same address is reused")
+	}
+
+	other, err := getRateLimitClient("127.0.0.1:18082")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == client {
+		t.Error("expected a new client for another address")
+	}
+}
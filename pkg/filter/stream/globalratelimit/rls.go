@@ -0,0 +1,189 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package globalratelimit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// The messages of the envoy rate limit service (envoy.service.ratelimit.v2), only the fields used by mosn
+// are declared, the unknown fields are skipped in decoding.
+
+// RateLimitRequest is sent to the rate limit service with the descriptors of a request
+type RateLimitRequest struct {
+	Domain      string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Descriptors []*RateLimitDescriptor `protobuf:"bytes,2,rep,name=descriptors" json:"descriptors,omitempty"`
+	HitsAddend  uint32                 `protobuf:"varint,3,opt,name=hits_addend,json=hitsAddend,proto3" json:"hits_addend,omitempty"`
+}
+
+func (m *RateLimitRequest) Reset()         { *m = RateLimitRequest{} }
+func (m *RateLimitRequest) String() string { return proto.CompactTextString(m) }
+func (*RateLimitRequest) ProtoMessage()    {}
+
+// RateLimitDescriptor is envoy.api.v2.ratelimit.RateLimitDescriptor
+type RateLimitDescriptor struct {
+	Entries []*RateLimitDescriptor_Entry `protobuf:"bytes,1,rep,name=entries" json:"entries,omitempty"`
+}
+
+func (m *RateLimitDescriptor) Reset()         { *m = RateLimitDescriptor{} }
+func (m *RateLimitDescriptor) String() string { return proto.CompactTextString(m) }
+func (*RateLimitDescriptor) ProtoMessage()    {}
+
+// RateLimitDescriptor_Entry is a key-value pair of the descriptor
+type RateLimitDescriptor_Entry struct {
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *RateLimitDescriptor_Entry) Reset()         { *m = RateLimitDescriptor_Entry{} }
+func (m *RateLimitDescriptor_Entry) String() string { return proto.CompactTextString(m) }
+func (*RateLimitDescriptor_Entry) ProtoMessage()    {}
+
+// RateLimitResponse_Code is the result of the rate limit service
+type RateLimitResponse_Code int32
+
+const (
+	RateLimitResponse_UNKNOWN    RateLimitResponse_Code = 0
+	RateLimitResponse_OK         RateLimitResponse_Code = 1
+	RateLimitResponse_OVER_LIMIT RateLimitResponse_Code = 2
+)
+
+// RateLimitResponse is replied by the rate limit service, the request is limited
+// if the OverallCode is RateLimitResponse_OVER_LIMIT
+type RateLimitResponse struct {
+	OverallCode RateLimitResponse_Code                `protobuf:"varint,1,opt,name=overall_code,json=overallCode,proto3" json:"overall_code,omitempty"`
+	Statuses    []*RateLimitResponse_DescriptorStatus `protobuf:"bytes,2,rep,name=statuses" json:"statuses,omitempty"`
+}
+
+func (m *RateLimitResponse) Reset()         { *m = RateLimitResponse{} }
+func (m *RateLimitResponse) String() string { return proto.CompactTextString(m) }
+func (*RateLimitResponse) ProtoMessage()    {}
+
+// RateLimitResponse_DescriptorStatus is the result of each descriptor in the request
+type RateLimitResponse_DescriptorStatus struct {
+	Code           RateLimitResponse_Code `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	LimitRemaining uint32                 `protobuf:"varint,3,opt,name=limit_remaining,json=limitRemaining,proto3" json:"limit_remaining,omitempty"`
+}
+
+func (m *RateLimitResponse_DescriptorStatus) Reset()         { *m = RateLimitResponse_DescriptorStatus{} }
+func (m *RateLimitResponse_DescriptorStatus) String() string { return proto.CompactTextString(m) }
+func (*RateLimitResponse_DescriptorStatus) ProtoMessage()    {}
+
+// ShouldRateLimitMethod is the full method name of the rate limit service
+const ShouldRateLimitMethod = "/envoy.service.ratelimit.v2.RateLimitService/ShouldRateLimit"
+
+// RateLimitServiceServer is the server API of the rate limit service
+type RateLimitServiceServer interface {
+	ShouldRateLimit(context.Context, *RateLimitRequest) (*RateLimitResponse, error)
+}
+
+// RegisterRateLimitServiceServer registers a rate limit service to the grpc server,
+// a local rate limit service can be used instead of the remote one in tests
+func RegisterRateLimitServiceServer(s *grpc.Server, srv RateLimitServiceServer) {
+	s.RegisterService(&rateLimitServiceDesc, srv)
+}
+
+func shouldRateLimitHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RateLimitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimitServiceServer).ShouldRateLimit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ShouldRateLimitMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimitServiceServer).ShouldRateLimit(ctx, req.(*RateLimitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var rateLimitServiceDesc = grpc.ServiceDesc{
+	ServiceName: "envoy.service.ratelimit.v2.RateLimitService",
+	HandlerType: (*RateLimitServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ShouldRateLimit",
+			Handler:    shouldRateLimitHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "envoy/service/ratelimit/v2/rls.proto",
+}
+
+// rateLimitClient calls the rate limit service
+type rateLimitClient interface {
+	ShouldRateLimit(ctx context.Context, request *RateLimitRequest) (*RateLimitResponse, error)
+}
+
+type grpcRateLimitClient struct {
+	conn *grpc.ClientConn
+}
+
+// the rate limit clients are shared by the factories with the same rate limit service address,
+// as the factories are rebuilt on each listener update
+var (
+	clientsMux sync.Mutex
+	clients    = make(map[string]rateLimitClient)
+)
+
+// getRateLimitClient returns the cached client of the address, or creates a new one
+func getRateLimitClient(address string) (rateLimitClient, error) {
+	clientsMux.Lock()
+	defer clientsMux.Unlock()
+
+	if client, ok := clients[address]; ok {
+		return client, nil
+	}
+
+	client, err := newRateLimitClient(address)
+	if err != nil {
+		return nil, err
+	}
+	clients[address] = client
+
+	return client, nil
+}
+
+// newRateLimitClient creates a rate limit client, the grpc connection is established in background
+func newRateLimitClient(address string) (rateLimitClient, error) {
+	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcRateLimitClient{
+		conn: conn,
+	}, nil
+}
+
+func (c *grpcRateLimitClient) ShouldRateLimit(ctx context.Context, request *RateLimitRequest) (*RateLimitResponse, error) {
+	response := &RateLimitResponse{}
+	if err := c.conn.Invoke(ctx, ShouldRateLimitMethod, request, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
@@ -0,0 +1,234 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/stats"
+	"github.com/alipay/sofa-mosn/pkg/types"
+	"github.com/rcrowley/go-metrics"
+)
+
+// rate limit stats key
+const (
+	RateLimitTotal     = "total"
+	RateLimitOK        = "ok"
+	RateLimitOverLimit = "over_limit"
+)
+
+// the idle buckets are removed every bucketSweepInterval, a bucket is idle if it is full
+const bucketSweepInterval = time.Minute
+
+type rateLimitStats struct {
+	stats *stats.Stats
+}
+
+func newRateLimitStats(statPrefix string) *rateLimitStats {
+	namespace := "ratelimit"
+	if statPrefix != "" {
+		namespace = namespace + "." + statPrefix
+	}
+
+	return &rateLimitStats{
+		stats: stats.NewStats(namespace).AddCounter(RateLimitTotal).AddCounter(RateLimitOK).AddCounter(RateLimitOverLimit),
+	}
+}
+
+func (s *rateLimitStats) Total() metrics.Counter {
+	return s.stats.Counter(RateLimitTotal)
+}
+
+func (s *rateLimitStats) OK() metrics.Counter {
+	return s.stats.Counter(RateLimitOK)
+}
+
+func (s *rateLimitStats) OverLimit() metrics.Counter {
+	return s.stats.Counter(RateLimitOverLimit)
+}
+
+// rateLimiter is shared by all filters created by a factory
+type rateLimiter struct {
+	limits []*localRateLimit
+	stats  *rateLimitStats
+}
+
+func newRateLimiter(config *v2.RateLimitFilter) *rateLimiter {
+	limiter := &rateLimiter{
+		stats: newRateLimitStats(config.StatPrefix),
+	}
+
+	for _, limit := range config.Limits {
+		limiter.limits = append(limiter.limits, newLocalRateLimit(limit))
+	}
+
+	return limiter
+}
+
+// takenToken is a token taken by allow, it is put back if the request is limited by a later bucket
+type takenToken struct {
+	limit *localRateLimit
+	key   string
+}
+
+// allow takes a token from the bucket of each matched descriptor, returns false if any bucket is empty.
+// The tokens taken from the other buckets are put back if the request is limited.
+func (l *rateLimiter) allow(descriptors []types.Descriptor) bool {
+	l.stats.Total().Inc(1)
+
+	now := time.Now()
+	var taken []takenToken
+	for _, descriptor := range descriptors {
+		for _, limit := range l.limits {
+			key, ok := limit.match(descriptor)
+			if !ok {
+				continue
+			}
+
+			if !limit.take(key, now) {
+				for _, token := range taken {
+					token.limit.putBack(token.key)
+				}
+				l.stats.OverLimit().Inc(1)
+				return false
+			}
+			taken = append(taken, takenToken{limit, key})
+		}
+	}
+
+	l.stats.OK().Inc(1)
+	return true
+}
+
+// localRateLimit holds a bucket for each value of the descriptor entries without configured value
+type localRateLimit struct {
+	descriptor    []v2.RateLimitDescriptorEntry
+	maxTokens     uint64
+	tokensPerFill uint64
+	fillInterval  time.Duration
+
+	mux       sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens   uint64
+	lastFill time.Time
+}
+
+func newLocalRateLimit(config v2.LocalRateLimit) *localRateLimit {
+	return &localRateLimit{
+		descriptor:    config.Descriptor,
+		maxTokens:     uint64(config.MaxTokens),
+		tokensPerFill: uint64(config.TokensPerFill),
+		fillInterval:  config.FillInterval,
+		buckets:       make(map[string]*tokenBucket),
+		lastSweep:     time.Now(),
+	}
+}
+
+// match returns the bucket key of the descriptor, the entries should have the same keys in order,
+// and the values should be the same if the value is configured
+func (l *localRateLimit) match(descriptor types.Descriptor) (string, bool) {
+	if len(descriptor.Entries) != len(l.descriptor) {
+		return "", false
+	}
+
+	values := make([]string, 0, len(descriptor.Entries))
+	for i, entry := range descriptor.Entries {
+		if entry.Key != l.descriptor[i].Key {
+			return "", false
+		}
+		if l.descriptor[i].Value != "" && entry.Value != l.descriptor[i].Value {
+			return "", false
+		}
+		values = append(values, entry.Value)
+	}
+
+	return strings.Join(values, "|"), true
+}
+
+// take takes a token from the bucket, returns false if the bucket is empty
+func (l *localRateLimit) take(key string, now time.Time) bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if now.Sub(l.lastSweep) >= bucketSweepInterval {
+		l.sweep(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{
+			tokens:   l.maxTokens,
+			lastFill: now,
+		}
+		l.buckets[key] = bucket
+	}
+
+	l.refill(bucket, now)
+
+	if bucket.tokens == 0 {
+		return false
+	}
+	bucket.tokens--
+
+	return true
+}
+
+// putBack returns a token taken from the bucket, a removed bucket is full already
+func (l *localRateLimit) putBack(key string) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if bucket, ok := l.buckets[key]; ok && bucket.tokens < l.maxTokens {
+		bucket.tokens++
+	}
+}
+
+func (l *localRateLimit) refill(bucket *tokenBucket, now time.Time) {
+	elapsed := now.Sub(bucket.lastFill)
+	if elapsed < l.fillInterval {
+		return
+	}
+
+	fills := uint64(elapsed / l.fillInterval)
+	bucket.lastFill = bucket.lastFill.Add(time.Duration(fills) * l.fillInterval)
+
+	if fills >= l.maxTokens || bucket.tokens+fills*l.tokensPerFill >= l.maxTokens {
+		bucket.tokens = l.maxTokens
+	} else {
+		bucket.tokens += fills * l.tokensPerFill
+	}
+}
+
+// sweep removes the full buckets, as they are the same as the new ones
+func (l *localRateLimit) sweep(now time.Time) {
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		l.refill(bucket, now)
+		if bucket.tokens >= l.maxTokens {
+			delete(l.buckets, key)
+		}
+	}
+}
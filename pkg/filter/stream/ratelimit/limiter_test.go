@@ -0,0 +1,165 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+func TestLocalRateLimitMatch(t *testing.T) {
+	limit := newLocalRateLimit(v2.LocalRateLimit{
+		Descriptor: []v2.RateLimitDescriptorEntry{
+			{Key: "generic_key", Value: "api"},
+			{Key: "remote_address"},
+		},
+		MaxTokens:     1,
+		TokensPerFill: 1,
+		FillInterval:  time.Second,
+	})
+
+	testCases := []struct {
+		entries []types.DescriptorEntry
+		key     string
+		match   bool
+	}{
+		{[]types.DescriptorEntry{{Key: "generic_key", Value: "api"}, {Key: "remote_address", Value: "10.0.0.1"}}, "api|10.0.0.1", true},
+		{[]types.DescriptorEntry{{Key: "generic_key", Value: "web"}, {Key: "remote_address", Value: "10.0.0.1"}}, "", false},
+		{[]types.DescriptorEntry{{Key: "remote_address", Value: "10.0.0.1"}, {Key: "generic_key", Value: "api"}}, "", false},
+		{[]types.DescriptorEntry{{Key: "generic_key", Value: "api"}}, "", false},
+	}
+
+	for i, tc := range testCases {
+		key, ok := limit.match(types.Descriptor{Entries: tc.entries})
+		if ok != tc.match || key != tc.key {
+			t.Errorf("case %d: expected (%s, %v), got (%s, %v)", i, tc.key, tc.match, key, ok)
+		}
+	}
+}
+
+func TestLocalRateLimitTake(t *testing.T) {
+	limit := newLocalRateLimit(v2.LocalRateLimit{
+		Descriptor:    []v2.RateLimitDescriptorEntry{{Key: "remote_address"}},
+		MaxTokens:     3,
+		TokensPerFill: 2,
+		FillInterval:  time.Second,
+	})
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if !limit.take("10.0.0.1", now) {
+			t.Fatalf("token %d should be taken", i)
+		}
+	}
+	if limit.take("10.0.0.1", now) {
+		t.Fatal("bucket should be empty")
+	}
+	// each value has its own bucket
+	if !limit.take("10.0.0.2", now) {
+		t.Fatal("bucket of another value should not be affected")
+	}
+
+	// refilled with 2 tokens
+	now = now.Add(1500 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if !limit.take("10.0.0.1", now) {
+			t.Fatalf("refilled token %d should be taken", i)
+		}
+	}
+	if limit.take("10.0.0.1", now) {
+		t.Fatal("bucket should be empty after taking the refilled tokens")
+	}
+
+	// full buckets are removed by the sweep
+	now = now.Add(bucketSweepInterval)
+	limit.take("10.0.0.3", now)
+	if len(limit.buckets) != 1 {
+		t.Errorf("expected only the new bucket after sweep, got %d buckets", len(limit.buckets))
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := newRateLimiter(&v2.RateLimitFilter{
+		StatPrefix: "test_allow",
+		Limits: []v2.LocalRateLimit{
+			{
+				Descriptor:    []v2.RateLimitDescriptorEntry{{Key: "service", Value: "com.alipay.test.TestService:1.0"}},
+				MaxTokens:     1,
+				TokensPerFill: 1,
+				FillInterval:  time.Hour,
+			},
+		},
+	})
+
+	limited := []types.Descriptor{{Entries: []types.DescriptorEntry{{Key: "service", Value: "com.alipay.test.TestService:1.0"}}}}
+	unlimited := []types.Descriptor{{Entries: []types.DescriptorEntry{{Key: "service", Value: "com.alipay.test.OtherService:1.0"}}}}
+
+	if !limiter.allow(limited) {
+		t.Error("first request should be allowed")
+	}
+	if limiter.allow(limited) {
+		t.Error("second request should be limited")
+	}
+	if !limiter.allow(unlimited) {
+		t.Error("request without matched limit should be allowed")
+	}
+
+	if limiter.stats.Total().Count() != 3 || limiter.stats.OK().Count() != 2 || limiter.stats.OverLimit().Count() != 1 {
+		t.Errorf("unexpected stats %s", limiter.stats.stats.String())
+	}
+}
+
+func TestRateLimiterAllowMultipleDescriptors(t *testing.T) {
+	limiter := newRateLimiter(&v2.RateLimitFilter{
+		StatPrefix: "test_allow_multiple",
+		Limits: []v2.LocalRateLimit{
+			{
+				Descriptor:    []v2.RateLimitDescriptorEntry{{Key: "service"}},
+				MaxTokens:     2,
+				TokensPerFill: 1,
+				FillInterval:  time.Hour,
+			},
+			{
+				Descriptor:    []v2.RateLimitDescriptorEntry{{Key: "caller"}},
+				MaxTokens:     1,
+				TokensPerFill: 1,
+				FillInterval:  time.Hour,
+			},
+		},
+	})
+
+	service := types.Descriptor{Entries: []types.DescriptorEntry{{Key: "service", Value: "com.alipay.test.TestService:1.0"}}}
+	caller := types.Descriptor{Entries: []types.DescriptorEntry{{Key: "caller", Value: "app1"}}}
+
+	if !limiter.allow([]types.Descriptor{service, caller}) {
+		t.Error("first request should be allowed")
+	}
+	// limited by the caller bucket, the token of the service bucket is put back
+	if limiter.allow([]types.Descriptor{service, caller}) {
+		t.Error("second request should be limited by caller")
+	}
+	if !limiter.allow([]types.Descriptor{service}) {
+		t.Error("service token should not be consumed by the limited request")
+	}
+	if limiter.allow([]types.Descriptor{service}) {
+		t.Error("service bucket should be empty")
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/alipay/sofa-mosn/pkg/config"
+	"github.com/alipay/sofa-mosn/pkg/filter"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/router"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+func init() {
+	filter.RegisterStream("rate_limit", CreateRateLimitFilterFactory)
+}
+
+// types.StreamReceiverFilter
+// rateLimitFilter limits the requests by the descriptors generated from the rate limits of the route and
+// the virtual host, the limited requests are replied with 429, which is converted to a bolt status for sofa rpc
+type rateLimitFilter struct {
+	context context.Context
+	stage   uint64
+	limiter *rateLimiter
+
+	limited bool
+	cb      types.StreamReceiverFilterCallbacks
+}
+
+// newRateLimitFilter used to create new rate limit filter, the token buckets are shared by the limiter
+func newRateLimitFilter(context context.Context, stage uint32, limiter *rateLimiter) types.StreamReceiverFilter {
+	return &rateLimitFilter{
+		context: context,
+		stage:   uint64(stage),
+		limiter: limiter,
+	}
+}
+
+func (f *rateLimitFilter) OnDecodeHeaders(headers map[string]string, endStream bool) types.FilterHeadersStatus {
+	descriptors := f.populateDescriptors(headers)
+	if len(descriptors) == 0 || f.limiter.allow(descriptors) {
+		return types.FilterHeadersStatusContinue
+	}
+
+	log.ByContext(f.context).Debugf("[RateLimit] request is limited, descriptors = %v", descriptors)
+
+	f.limited = true
+	f.cb.RequestInfo().SetResponseFlag(types.RateLimited)
+	headers[types.HeaderStatus] = strconv.Itoa(types.RateLimitedCode)
+	f.cb.AppendHeaders(headers, true)
+
+	return types.FilterHeadersStatusStopIteration
+}
+
+func (f *rateLimitFilter) OnDecodeData(buf types.IoBuffer, endStream bool) types.FilterDataStatus {
+	if f.limited {
+		return types.FilterDataStatusStopIterationNoBuffer
+	}
+
+	return types.FilterDataStatusContinue
+}
+
+func (f *rateLimitFilter) OnDecodeTrailers(trailers map[string]string) types.FilterTrailersStatus {
+	if f.limited {
+		return types.FilterTrailersStatusStopIteration
+	}
+
+	return types.FilterTrailersStatusContinue
+}
+
+func (f *rateLimitFilter) SetDecoderFilterCallbacks(cb types.StreamReceiverFilterCallbacks) {
+	f.cb = cb
+}
+
+func (f *rateLimitFilter) OnDestroy() {}
+
+func (f *rateLimitFilter) populateDescriptors(headers map[string]string) []types.Descriptor {
+	var remoteAddr string
+	if conn := f.cb.Connection(); conn != nil && conn.RemoteAddr() != nil {
+		remoteAddr = conn.RemoteAddr().String()
+	}
+
+	return router.PopulateRateLimitDescriptors(f.cb.Route(), f.stage, headers, remoteAddr)
+}
+
+// RateLimitFilterConfigFactory Filter Config Factory
+type RateLimitFilterConfigFactory struct {
+	Stage   uint32
+	limiter *rateLimiter
+}
+
+func (f *RateLimitFilterConfigFactory) CreateFilterChain(context context.Context, callbacks types.StreamFilterChainFactoryCallbacks) {
+	filter := newRateLimitFilter(context, f.Stage, f.limiter)
+	callbacks.AddStreamReceiverFilter(filter)
+}
+
+// CreateRateLimitFilterFactory
+func CreateRateLimitFilterFactory(conf map[string]interface{}) (types.StreamFilterChainFactory, error) {
+	rateLimit, err := config.ParseRateLimitFilter(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitFilterConfigFactory{
+		Stage:   rateLimit.Stage,
+		limiter: newRateLimiter(rateLimit),
+	}, nil
+}
@@ -47,6 +47,7 @@ func init() {
 		types.LogDownstreamLocalAddress:     DownstreamLocalAddressGetter,
 		types.LogDownstreamRemoteAddress:    DownstreamRemoteAddressGetter,
 		types.LogUpstreamHostSelectedGetter: UpstreamHostSelectedGetter,
+		types.LogRouteName:                  RouteNameGetter,
 	}
 }
 
@@ -333,3 +334,12 @@ func UpstreamHostSelectedGetter(info types.RequestInfo) string {
 	}
 	return "nil"
 }
+
+// RouteNameGetter
+// get the name of the route matched
+func RouteNameGetter(info types.RequestInfo) string {
+	if info.RouteEntry() != nil {
+		return info.RouteEntry().GetRouterName()
+	}
+	return "nil"
+}
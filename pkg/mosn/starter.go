@@ -23,22 +23,30 @@ import (
 	"strconv"
 	"sync"
 
+	"github.com/alipay/sofa-mosn/pkg/admin"
 	"github.com/alipay/sofa-mosn/pkg/api/v2"
 	"github.com/alipay/sofa-mosn/pkg/config"
 	"github.com/alipay/sofa-mosn/pkg/filter"
 	"github.com/alipay/sofa-mosn/pkg/log"
 	"github.com/alipay/sofa-mosn/pkg/network"
 	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/runtime"
 	"github.com/alipay/sofa-mosn/pkg/server"
+	"github.com/alipay/sofa-mosn/pkg/stats"
+	"github.com/alipay/sofa-mosn/pkg/trace"
 	"github.com/alipay/sofa-mosn/pkg/types"
 	"github.com/alipay/sofa-mosn/pkg/upstream/cluster"
 	"github.com/alipay/sofa-mosn/pkg/xds"
+	"github.com/rcrowley/go-metrics"
 )
 
 // Mosn class which wrapper server
 type Mosn struct {
 	servers        []server.Server
 	clustermanager types.ClusterManager
+	admin          *admin.Server
+	statsFlusher   *stats.Flusher
+	tracer         *trace.Tracer
 }
 
 // NewMosn
@@ -70,12 +78,29 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 	} else if srvNum > 1 {
 		log.StartLogger.Fatalln("multiple server not supported yet, got ", srvNum)
 	}
+	// the runtime values should be loaded before any request is served
+	if c.Runtime.SymlinkRoot != "" {
+		if err := runtime.Init(c.Runtime.SymlinkRoot, c.Runtime.Subdirectory, c.Runtime.OverrideSubdirectory); err != nil {
+			log.StartLogger.Fatalln("load runtime failed:", err)
+		}
+	}
+
 	//get inherit fds
 	inheritListeners := getInheritListeners()
 
 	//cluster manager filter
 	cmf := &clusterManagerFilter{}
 
+	// local zone should be set before the cluster hosts are added
+	config.RegisterConfigParsedListener(config.ParseCallbackKeyServiceRgtInfo, func(data interface{}, endParsing bool) error {
+		if info, ok := data.(v2.ServiceRegistryInfo); ok {
+			cluster.SetLocalZone(info.ServiceAppInfo.Zone)
+		}
+		return nil
+	})
+	//parse service registry info
+	config.ParseServiceRegistry(c.ServiceRegistry)
+
 	// parse cluster all in one
 	clusters, clusterMap := config.ParseClusterConfig(c.ClusterManager.Clusters)
 	// create cluster manager
@@ -131,8 +156,6 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 		}
 		m.servers = append(m.servers, srv)
 	}
-	//parse service registry info
-	config.ParseServiceRegistry(c.ServiceRegistry)
 
 	//close legacy listeners
 	for _, ln := range inheritListeners {
@@ -142,6 +165,36 @@ func NewMosn(c *config.MOSNConfig) *Mosn {
 		}
 	}
 
+	// admin api server
+	m.admin = admin.NewServer(c, m.clustermanager)
+
+	// stats sinks
+	var sinks []stats.Sink
+	for _, sinkConfig := range c.StatsSinks {
+		sink, err := stats.CreateSink(sinkConfig.Type, sinkConfig.Config)
+		if err != nil {
+			log.StartLogger.Fatalln("create stats sink failed:", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	m.statsFlusher = stats.NewFlusher(metrics.DefaultRegistry, c.StatsFlushInterval.Duration, sinks)
+
+	// tracing
+	if c.Tracing.Enable {
+		reporter, err := trace.CreateReporter(c.Tracing.Reporter, c.Tracing.Config)
+		if err != nil {
+			log.StartLogger.Fatalln("create trace reporter failed:", err)
+		}
+
+		sampleRate := c.Tracing.SampleRate
+		if sampleRate == 0 {
+			sampleRate = trace.DefaultSampleRate
+		}
+
+		m.tracer = trace.NewTracer(reporter, trace.NewSampler(sampleRate))
+		trace.SetDriver(m.tracer)
+	}
+
 	// set TransferTimeout
 	network.TransferTimeout = server.GracefulTimeout
 	// transfer old mosn connections
@@ -155,6 +208,9 @@ func (m *Mosn) Start() {
 	for _, srv := range m.servers {
 		go srv.Start()
 	}
+
+	m.admin.Start()
+	m.statsFlusher.Start()
 }
 
 // Close mosn's server
@@ -162,6 +218,12 @@ func (m *Mosn) Close() {
 	for _, srv := range m.servers {
 		srv.Close()
 	}
+	m.admin.Close()
+	m.statsFlusher.Stop()
+	if m.tracer != nil {
+		trace.SetDriver(nil)
+		m.tracer.Close()
+	}
 	m.clustermanager.Destory()
 }
 
@@ -34,10 +34,13 @@ func ParseQueryString(query string) types.QueryParams {
 	queryMaps := strings.Split(query, "&")
 
 	for _, qm := range queryMaps {
-		queryMap := strings.Split(qm, "=")
+		// the value may contain '=', and a parameter without value is present with an empty value
+		queryMap := strings.SplitN(qm, "=", 2)
 
-		if len(queryMap) != 2 {
+		if strings.TrimSpace(queryMap[0]) == "" {
 			log.DefaultLogger.Errorf("parse query parameters error,parameters = %s", qm)
+		} else if len(queryMap) == 1 {
+			QueryParams[strings.TrimSpace(queryMap[0])] = ""
 		} else {
 			QueryParams[strings.TrimSpace(queryMap[0])] = strings.TrimSpace(queryMap[1])
 		}
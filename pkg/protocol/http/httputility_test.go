@@ -73,6 +73,16 @@ func TestParseQueryString(t *testing.T) {
 				"test":   "biz",
 			},
 		},
+
+		{
+			args: args{
+				query: "debug&token=YWJj==",
+			},
+			want: types.QueryParams{
+				"debug": "",
+				"token": "YWJj==",
+			},
+		},
 	}
 
 	for _, tt := range tests {
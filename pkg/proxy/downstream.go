@@ -21,16 +21,20 @@ import (
 	"container/list"
 	"net"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"context"
 	"fmt"
+	"hash/fnv"
+	"math/rand"
 	"reflect"
 
 	"github.com/alipay/sofa-mosn/pkg/buffer"
 	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/protocol"
 	"github.com/alipay/sofa-mosn/pkg/types"
 )
 
@@ -54,11 +58,20 @@ type downStream struct {
 	retryState *retryState
 
 	requestInfo     types.RequestInfo
+	span            types.Span
+	hashCookie      string
+	hashCookieName  string
 	responseSender  types.StreamSender
 	upstreamRequest *upstreamRequest
+	shadowRequest   *shadowRequest
 	perRetryTimer   *timer
 	responseTimer   *timer
 
+	// ~~~ hedging
+	hedgeRequest   *upstreamRequest
+	hedgeTimer     *timer
+	hedgeAvoidHost types.HostInfo
+
 	// ~~~ downstream request buf
 	downstreamReqHeaders  map[string]string
 	downstreamReqDataBuf  types.IoBuffer
@@ -177,6 +190,9 @@ func (s *downStream) cleanStream() {
 		ef.filter.OnDestroy()
 	}
 
+	// finish tracing
+	s.finishSpan()
+
 	// countdown metrics
 	s.proxy.stats.DownstreamRequestActive().Dec(1)
 	s.proxy.listenerStats.DownstreamRequestActive().Dec(1)
@@ -248,6 +264,8 @@ func (s *downStream) ReceiveHeaders(headers map[string]string, endStream bool) {
 	s.downstreamRecvDone = endStream
 	s.downstreamReqHeaders = headers
 
+	s.startSpan(headers)
+
 	s.doReceiveHeaders(nil, headers, endStream)
 }
 
@@ -258,7 +276,7 @@ func (s *downStream) doReceiveHeaders(filter *activeStreamReceiverFilter, header
 
 	//Get some route by service name
 	log.DefaultLogger.Tracef("before active stream route")
-	route := s.proxy.routers.Route(headers, 1)
+	route := s.matchRoute()
 
 	if route == nil || route.RouteRule() == nil {
 		// no route
@@ -270,6 +288,15 @@ func (s *downStream) doReceiveHeaders(filter *activeStreamReceiverFilter, header
 		return
 	}
 
+	// redirect routes and direct response routes reply without any upstream
+	if redirectRule := route.RedirectRule(); redirectRule != nil {
+		s.route = route
+		s.requestInfo.SetRouteEntry(route.RouteRule())
+		s.sendRedirectReply(redirectRule, headers)
+
+		return
+	}
+
 	// as ClusterName has random factor when choosing weighted cluster,
 	// so need determination at the first time
 	clusterName := route.RouteRule().ClusterName()
@@ -283,6 +310,9 @@ func (s *downStream) doReceiveHeaders(filter *activeStreamReceiverFilter, header
 	// todo: detect remote addr
 	s.requestInfo.SetDownstreamRemoteAddress(s.proxy.readCallbacks.Connection().RemoteAddr())
 
+	// apply the route's headers manipulation before the request is proxied
+	route.RouteRule().FinalizeRequestHeaders(headers, s.requestInfo)
+
 	// `downstream` implement loadbalancer ctx
 	log.DefaultLogger.Tracef("before initializeUpstreamConnectionPool")
 	pool, err := s.initializeUpstreamConnectionPool(clusterName, s)
@@ -293,6 +323,7 @@ func (s *downStream) doReceiveHeaders(filter *activeStreamReceiverFilter, header
 	}
 
 	log.DefaultLogger.Tracef("after initializeUpstreamConnectionPool")
+	s.decorateSpan(route)
 	s.timeout = parseProxyTimeout(route, headers)
 	s.retryState = newRetryState(route.RouteRule().Policy().RetryPolicy(), headers, s.cluster)
 
@@ -303,12 +334,27 @@ func (s *downStream) doReceiveHeaders(filter *activeStreamReceiverFilter, header
 	s.upstreamRequest.proxy = s.proxy
 	s.upstreamRequest.connPool = pool
 
+	// mirror the request if the route has a shadow policy, headers are copied before sending to upstream
+	s.shadowRequest = newShadowRequest(s, route, headers)
+
 	//Call upstream's append header method to build upstream's request
 	s.upstreamRequest.appendHeaders(headers, endStream)
 
 	if endStream {
 		s.onUpstreamRequestSent()
+		s.sendShadowRequest()
+	}
+}
+
+// matchRoute matches the route of the request once and caches it,
+// so the receiver filters are able to get the route before the request is proxied
+func (s *downStream) matchRoute() types.Route {
+	if s.route == nil && s.downstreamReqHeaders != nil {
+		// the random value is used by the runtime fraction matching of the routes
+		s.route = s.proxy.routers.Route(s.downstreamReqHeaders, rand.Uint64())
 	}
+
+	return s.route
 }
 
 func (s *downStream) OnReceiveData(context context.Context, data types.IoBuffer, endStream bool) {
@@ -346,10 +392,22 @@ func (s *downStream) doReceiveData(filter *activeStreamReceiverFilter, data type
 		return
 	}
 
+	if s.shadowRequest != nil {
+		s.shadowRequest.appendData(data)
+		if endStream {
+			s.sendShadowRequest()
+		}
+	}
+
 	if endStream {
 		s.onUpstreamRequestSent()
 	}
 
+	// the hedged request replays the request data, keep a copy as the data is drained once it is sent
+	if s.hedgeTimer != nil {
+		s.downstreamReqDataBuf = data.Clone()
+	}
+
 	s.upstreamRequest.appendData(data, endStream)
 
 	// if upstream process done in the middle of receiving data, just end stream
@@ -406,6 +464,11 @@ func (s *downStream) doReceiveTrailers(filter *activeStreamReceiverFilter, trail
 	}
 
 	s.downstreamReqTrailers = trailers
+	if s.shadowRequest != nil {
+		s.shadowRequest.appendTrailers(trailers)
+		s.sendShadowRequest()
+	}
+
 	s.onUpstreamRequestSent()
 	s.upstreamRequest.appendTrailers(trailers)
 
@@ -432,6 +495,9 @@ func (s *downStream) onUpstreamRequestSent() {
 			s.responseTimer = newTimer(s.onResponseTimeout, s.timeout.GlobalTimeout)
 			s.responseTimer.start()
 		}
+
+		// setup hedge timer
+		s.setupHedge()
 	}
 }
 
@@ -445,6 +511,7 @@ func (s *downStream) onResponseTimeout() {
 			s.upstreamRequest.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		}
 
+		s.upstreamRequest.putResult(types.ResultRequestTimeout)
 		s.upstreamRequest.resetStream()
 	}
 
@@ -459,7 +526,7 @@ func (s *downStream) setupPerReqTimeout() {
 			s.perRetryTimer.stop()
 		}
 
-		s.perRetryTimer = newTimer(s.onPerReqTimeout, timeout.TryTimeout*time.Second)
+		s.perRetryTimer = newTimer(s.onPerReqTimeout, timeout.TryTimeout)
 		s.perRetryTimer.start()
 	}
 }
@@ -472,10 +539,16 @@ func (s *downStream) onPerReqTimeout() {
 		s.perRetryTimer = nil
 		s.cluster.Stats().UpstreamRequestTimeout.Inc(1)
 
+		// the requests are retried as a whole if both of the hedged requests timeout
+		if s.hedgeRequest != nil {
+			s.cancelHedgedRequest(s.hedgeRequest)
+		}
+
 		if s.upstreamRequest.host != nil {
 			s.upstreamRequest.host.HostStats().UpstreamRequestTimeout.Inc(1)
 		}
 
+		s.upstreamRequest.putResult(types.ResultRequestTimeout)
 		s.upstreamRequest.resetStream()
 		s.requestInfo.SetResponseFlag(types.UpstreamRequestTimeout)
 		s.onUpstreamReset(UpstreamPerTryTimeout, types.StreamLocalReset)
@@ -527,6 +600,8 @@ func (s *downStream) doAppendHeaders(filter *activeStreamSenderFilter, headers i
 		return
 	}
 
+	s.setSpanStatus(headers)
+
 	//Currently, just log the error
 	if err := s.responseSender.AppendHeaders(s.context, headers, endStream); err != nil {
 		s.logger.Errorf("[downstream] append headers error, %s", err)
@@ -578,9 +653,13 @@ func (s *downStream) onUpstreamReset(urtype UpstreamResetType, reason types.Stre
 	// todo: update stats
 	log.DefaultLogger.Tracef("on upstream reset invoked")
 
+	if s.upstreamRequest != nil {
+		s.upstreamRequest.finishSpan(string(urtype))
+	}
+
 	// see if we need a retry
 	if urtype != UpstreamGlobalTimeout &&
-		s.downstreamResponseStarted && s.retryState != nil {
+		!s.downstreamResponseStarted && s.retryState != nil {
 		retryCheck := s.retryState.retry(nil, reason, s.doRetry)
 
 		if retryCheck == types.ShouldRetry && s.setupRetry(true) {
@@ -650,6 +729,14 @@ func (s *downStream) onUpstreamHeaders(headers map[string]string, endStream bool
 		s.onUpstreamResponseRecvFinished()
 	}
 
+	if s.hashCookie != "" {
+		s.appendHashCookie(headers)
+	}
+
+	if s.route != nil && s.route.RouteRule() != nil {
+		s.route.RouteRule().FinalizeResponseHeaders(headers, s.requestInfo)
+	}
+
 	// todo: insert proxy headers
 	s.appendHeaders(headers, endStream)
 }
@@ -673,6 +760,8 @@ func (s *downStream) onUpstreamResponseRecvFinished() {
 		s.upstreamRequest.resetStream()
 	}
 
+	s.upstreamRequest.finishSpan("")
+
 	// todo: stats
 	// todo: logs
 
@@ -688,6 +777,7 @@ func (s *downStream) setupRetry(endStream bool) bool {
 		s.upstreamRequest.resetStream()
 	}
 
+	s.upstreamRequest.finishSpan("")
 	s.upstreamRequest.requestSender = nil
 
 	// reset per req timer
@@ -701,7 +791,12 @@ func (s *downStream) setupRetry(endStream bool) bool {
 
 // Note: retry-timer MUST be stopped before active stream got recycled, otherwise resetting stream's properties will cause panic here
 func (s *downStream) doRetry() {
-	pool, err := s.initializeUpstreamConnectionPool(s.cluster.Name(), nil)
+	// the hosts tried are avoided by the load balancer if the retry policy requires
+	if s.upstreamRequest != nil && s.upstreamRequest.host != nil {
+		s.retryState.addTriedHost(s.upstreamRequest.host)
+	}
+
+	pool, err := s.initializeUpstreamConnectionPool(s.cluster.Name(), s)
 
 	if err != nil {
 		s.sendHijackReply(types.NoHealthUpstreamCode, s.downstreamReqHeaders)
@@ -748,13 +843,42 @@ func (s *downStream) resetStream() {
 }
 
 func (s *downStream) sendHijackReply(code int, headers map[string]string) {
+	s.sendHijackReplyWithBody(code, headers, "")
+}
+
+// sendHijackReplyWithBody replies the downstream directly, the body is sent if it is not empty
+func (s *downStream) sendHijackReplyWithBody(code int, headers map[string]string, body string) {
 	s.logger.Debugf("set hijack reply, stream id = %s, code = %d", s.streamID, code)
 	if headers == nil {
 		headers = make(map[string]string, 5)
 	}
 
 	headers[types.HeaderStatus] = strconv.Itoa(code)
-	s.appendHeaders(headers, true)
+	if body == "" {
+		s.appendHeaders(headers, true)
+		return
+	}
+
+	s.appendHeaders(headers, false)
+	s.appendData(buffer.NewIoBufferString(body), true)
+}
+
+// sendRedirectReply replies a redirect or a direct response of the route.
+// The response headers are generated for http, the request headers are used by other protocols
+// to build the response as other hijack replies do.
+func (s *downStream) sendRedirectReply(redirectRule types.RedirectRule, headers map[string]string) {
+	respHeaders := headers
+
+	switch types.Protocol(s.proxy.config.DownstreamProtocol) {
+	case protocol.HTTP1, protocol.HTTP2:
+		respHeaders = make(map[string]string, 2)
+		if location := redirectRule.NewPath(headers); location != "" {
+			respHeaders[locationHeader] = location
+		}
+		s.route.RouteRule().FinalizeResponseHeaders(respHeaders, s.requestInfo)
+	}
+
+	s.sendHijackReplyWithBody(redirectRule.ResponseCode(), respHeaders, redirectRule.ResponseBody())
 }
 
 func (s *downStream) cleanUp() {
@@ -776,6 +900,16 @@ func (s *downStream) cleanUp() {
 		s.perRetryTimer = nil
 	}
 
+	// reset hedge timer and the hedged request, if any
+	if s.hedgeTimer != nil {
+		s.hedgeTimer.stop()
+		s.hedgeTimer = nil
+	}
+
+	if s.hedgeRequest != nil {
+		s.cancelHedgedRequest(s.hedgeRequest)
+	}
+
 	// reset response timer
 	if s.responseTimer != nil {
 		s.responseTimer.stop()
@@ -808,29 +942,94 @@ func (s *downStream) reset() {
 	s.timeout = nil
 	s.retryState = nil
 	s.requestInfo = nil
+	s.span = nil
 	s.responseSender = nil
 	s.upstreamRequest.downStream = nil
 	s.upstreamRequest.requestSender = nil
 	s.upstreamRequest.proxy = nil
 	s.upstreamRequest.upstreamRespHeaders = nil
+	s.upstreamRequest.span = nil
 	s.upstreamRequest = nil
+	s.shadowRequest = nil
 	s.perRetryTimer = nil
 	s.responseTimer = nil
+	s.hedgeRequest = nil
+	s.hedgeTimer = nil
+	s.hedgeAvoidHost = nil
 	s.downstreamRespHeaders = nil
 	s.downstreamReqDataBuf = nil
 	s.downstreamReqTrailers = nil
 	s.downstreamRespHeaders = nil
 	s.downstreamRespDataBuf = nil
 	s.downstreamRespTrailers = nil
+	s.hashCookie = ""
+	s.hashCookieName = ""
 	s.senderFilters = s.senderFilters[:0]
 	s.receiverFilters = s.receiverFilters[:0]
 }
 
 // types.LoadBalancerContext
-// no use currently
+// ComputeHashKey generates the hash key by the route's hash policy, used by consistent hash load balancers
 func (s *downStream) ComputeHashKey() types.HashedValue {
-	//return [16]byte{}
-	return ""
+	if s.route == nil || s.route.RouteRule() == nil {
+		return ""
+	}
+
+	lbPolicy := s.route.RouteRule().Policy().LoadBalancerPolicy()
+	if lbPolicy == nil || lbPolicy.HashPolicy() == nil {
+		return ""
+	}
+
+	var remoteAddr string
+	if addr := s.proxy.readCallbacks.Connection().RemoteAddr(); addr != nil {
+		remoteAddr = addr.String()
+	}
+
+	return lbPolicy.HashPolicy().GenerateHash(remoteAddr, s.downstreamReqHeaders, s.addHashCookie)
+}
+
+// addHashCookie generates a cookie for the hash policy, the cookie is set on the response headers.
+// The value is derived from the downstream address, so the requests from a client are sticky before the cookie is set
+func (s *downStream) addHashCookie(key string, path string, ttl time.Duration) string {
+	h := fnv.New64a()
+	if addr := s.proxy.readCallbacks.Connection().RemoteAddr(); addr != nil {
+		remoteAddr := addr.String()
+		// only the ip is used, as the port changes in each connection
+		if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+			remoteAddr = host
+		}
+		h.Write([]byte(remoteAddr))
+	}
+	value := strconv.FormatUint(h.Sum64(), 16)
+
+	cookie := fmt.Sprintf("%s=\"%s\"; Max-Age=%d", key, value, int64(ttl/time.Second))
+	if path != "" {
+		cookie += "; Path=" + path
+	}
+	s.hashCookie = cookie + "; HttpOnly"
+	s.hashCookieName = key
+
+	return value
+}
+
+// appendHashCookie adds the hash cookie to the cookies set by the upstream.
+// The headers keep one value per key, so the cookies are folded into one set-cookie header,
+// and the cookie of the same name set by the upstream takes precedence over the hash cookie
+func (s *downStream) appendHashCookie(headers map[string]string) {
+	cookies, ok := headers[setCookieHeader]
+	if !ok || cookies == "" {
+		headers[setCookieHeader] = s.hashCookie
+		return
+	}
+
+	for _, cookie := range strings.Split(cookies, ",") {
+		if strings.HasPrefix(strings.TrimSpace(cookie), s.hashCookieName+"=") {
+			log.DefaultLogger.Debugf("hash cookie %s is set by the upstream, skip it", s.hashCookieName)
+			return
+		}
+	}
+
+	headers[setCookieHeader] = cookies + ", " + s.hashCookie
 }
 
 func (s *downStream) MetadataMatchCriteria() types.MetadataMatchCriteria {
@@ -849,6 +1048,25 @@ func (s *downStream) DownstreamHeaders() map[string]string {
 	return s.downstreamReqHeaders
 }
 
+// types.HostPredicateContext
+// ShouldSelectAnotherHost rejects the host of the hedged upstream request,
+// and the hosts tried by the request if the retry policy avoids previous hosts
+func (s *downStream) ShouldSelectAnotherHost(host types.Host) bool {
+	if s.hedgeAvoidHost != nil && s.hedgeAvoidHost.AddressString() == host.AddressString() {
+		return true
+	}
+
+	if s.retryState == nil {
+		return false
+	}
+
+	return s.retryState.shouldSelectAnotherHost(host)
+}
+
+func (s *downStream) HostSelectionRetryCount() int {
+	return hostSelectionRetryCount
+}
+
 func (s *downStream) GiveStream() {
 	if s.upstreamReset == 1 || s.downstreamReset == 1 {
 		return
@@ -34,6 +34,8 @@ type streamEvent struct {
 	direction int
 	streamID  string
 	stream    *downStream
+	// request is the upstream request which the upstream event comes from
+	request *upstreamRequest
 }
 
 func (s *streamEvent) Source() uint32 {
@@ -109,7 +111,9 @@ func eventProcess(shard int, streamMap map[string]bool, event interface{}) {
 			case Downstream:
 				e.stream.ResetStream(e.reason)
 			case Upstream:
-				e.stream.upstreamRequest.ResetStream(e.reason)
+				if e.stream.isUpstreamRequest(e.request) {
+					e.request.ResetStream(e.reason)
+				}
 			default:
 				e.stream.logger.Errorf("Unknown receiveTrailerEvent direction %s", e.direction)
 			}
@@ -124,7 +128,9 @@ func eventProcess(shard int, streamMap map[string]bool, event interface{}) {
 			case Downstream:
 				e.stream.ReceiveHeaders(e.headers, e.endStream)
 			case Upstream:
-				e.stream.upstreamRequest.ReceiveHeaders(e.headers, e.endStream)
+				if e.stream.isUpstreamRequest(e.request) {
+					e.request.ReceiveHeaders(e.headers, e.endStream)
+				}
 			default:
 				e.stream.logger.Errorf("Unknown receiveHeadersEvent direction %s", e.direction)
 			}
@@ -142,7 +148,9 @@ func eventProcess(shard int, streamMap map[string]bool, event interface{}) {
 				}
 				e.stream.ReceiveData(e.data, e.endStream)
 			case Upstream:
-				e.stream.upstreamRequest.ReceiveData(e.data, e.endStream)
+				if e.stream.isUpstreamRequest(e.request) {
+					e.request.ReceiveData(e.data, e.endStream)
+				}
 			default:
 				e.stream.logger.Errorf("Unknown receiveDataEvent direction %s", e.direction)
 			}
@@ -157,7 +165,9 @@ func eventProcess(shard int, streamMap map[string]bool, event interface{}) {
 			case Downstream:
 				e.stream.ReceiveTrailers(e.trailers)
 			case Upstream:
-				e.stream.upstreamRequest.ReceiveTrailers(e.trailers)
+				if e.stream.isUpstreamRequest(e.request) {
+					e.request.ReceiveTrailers(e.trailers)
+				}
 			default:
 				e.stream.logger.Errorf("Unknown receiveTrailerEvent direction %s", e.direction)
 			}
@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// Hedging sends a second request to another host if no response is received within the hedge delay
+// of the route. The first response is used and the other request is reset. As a hedged request is an
+// extra request to the upstream, it is limited by the retries resource of the cluster.
+
+// setupHedge starts the hedge timer once the downstream request is received completely,
+// as the hedged request replays the buffered request
+func (s *downStream) setupHedge() {
+	if s.route == nil || s.route.RouteRule() == nil {
+		return
+	}
+
+	hedgePolicy := s.route.RouteRule().Policy().HedgePolicy()
+	if hedgePolicy == nil || hedgePolicy.HedgeDelay() <= 0 {
+		return
+	}
+
+	if s.hedgeTimer != nil {
+		s.hedgeTimer.stop()
+	}
+
+	s.hedgeTimer = newTimer(s.onHedgeTimeout, hedgePolicy.HedgeDelay())
+	s.hedgeTimer.start()
+}
+
+// Note: hedge-timer MUST be stopped before active stream got recycled, otherwise resetting stream's properties will cause panic here
+func (s *downStream) onHedgeTimeout() {
+	s.hedgeTimer = nil
+
+	// no hedged request if the response is started or a retry is scheduled
+	if s.downstreamResponseStarted || s.upstreamProcessDone || s.hedgeRequest != nil ||
+		s.upstreamRequest == nil || s.upstreamRequest.requestSender == nil {
+		return
+	}
+
+	retries := s.cluster.ResourceManager().Retries()
+	if !retries.CanCreate() {
+		s.cluster.Stats().UpstreamRequestHedgeOverflow.Inc(1)
+		return
+	}
+
+	// the load balancer avoids the host of the upstream request, see ShouldSelectAnotherHost
+	s.hedgeAvoidHost = s.upstreamRequest.host
+	pool := s.proxy.clusterManager.ConnPoolForCluster(s, s.cluster.Name(), types.Protocol(s.proxy.config.UpstreamProtocol))
+	s.hedgeAvoidHost = nil
+
+	if pool == nil {
+		log.DefaultLogger.Debugf("no upstream for hedged request, stream id = %s", s.streamID)
+		return
+	}
+
+	retries.Increase()
+	s.cluster.Stats().UpstreamRequestHedge.Inc(1)
+
+	r := &upstreamRequest{
+		downStream: s,
+		proxy:      s.proxy,
+		connPool:   pool,
+	}
+	s.hedgeRequest = r
+
+	r.appendHeaders(s.downstreamReqHeaders, s.downstreamReqDataBuf == nil && s.downstreamReqTrailers == nil)
+
+	// the hedged request is cancelled if the stream can not be created
+	if s.hedgeRequest != r {
+		return
+	}
+
+	if s.downstreamReqDataBuf != nil {
+		r.appendData(s.downstreamReqDataBuf.Clone(), s.downstreamReqTrailers == nil)
+	}
+
+	if s.downstreamReqTrailers != nil {
+		r.appendTrailers(s.downstreamReqTrailers)
+	}
+}
+
+// onHedgedResponse chooses the upstream request by the response received while the requests are hedged.
+// A failed response that should be retried is dropped as the other request may succeed, otherwise the
+// response wins and the other request is cancelled. Returns false if the response is dropped.
+func (s *downStream) onHedgedResponse(r *upstreamRequest, headers map[string]string) bool {
+	if s.retryState != nil && s.retryState.doRetryCheck(headers, "") {
+		s.cancelHedgedRequest(r)
+		return false
+	}
+
+	other := s.hedgeRequest
+	if r == s.hedgeRequest {
+		other = s.upstreamRequest
+		s.cluster.Stats().UpstreamRequestHedgeWin.Inc(1)
+	}
+
+	s.cancelHedgedRequest(other)
+
+	return true
+}
+
+// cancelHedgedRequest resets one of the hedged requests, the other one goes on as the upstream request
+func (s *downStream) cancelHedgedRequest(r *upstreamRequest) {
+	if r == s.upstreamRequest {
+		s.upstreamRequest = s.hedgeRequest
+	}
+	s.hedgeRequest = nil
+	s.cluster.ResourceManager().Retries().Decrease()
+
+	r.resetStream()
+	r.requestSender = nil
+	r.finishSpan(string(UpstreamHedgeCancel))
+}
+
+// isUpstreamRequest returns false if the upstream request has been replaced by a retry or cancelled by hedging,
+// the events of such a request are ignored
+func (s *downStream) isUpstreamRequest(r *upstreamRequest) bool {
+	return r == s.upstreamRequest || (r != nil && r == s.hedgeRequest)
+}
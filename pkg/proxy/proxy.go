@@ -108,12 +108,17 @@ func NewProxy(ctx context.Context, config *v2.Proxy, clusterManager types.Cluste
 
 	listenStatsNamespace := ctx.Value(types.ContextKeyListenerStatsNameSpace).(string)
 	proxy.listenerStats = newListenerStats(listenStatsNamespace)
-	//log fatal to exit
-	routers, err := router.CreateRouteConfig(types.Protocol(config.DownstreamProtocol), config)
-	if err != nil {
-		log.StartLogger.Fatal(err)
+	if config.RouterConfigName != "" {
+		// the routers are subscribed from RDS and swapped by the routers manager
+		proxy.routers = router.RoutersManager.GetRouters(config.RouterConfigName)
+	} else {
+		//log fatal to exit
+		routers, err := router.CreateRouteConfig(types.Protocol(config.DownstreamProtocol), config)
+		if err != nil {
+			log.StartLogger.Fatal(err)
+		}
+		proxy.routers = routers
 	}
-	proxy.routers = routers
 	proxy.downstreamCallbacks = &downstreamCallbacks{
 		proxy: proxy,
 	}
@@ -22,15 +22,27 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/alipay/sofa-mosn/pkg/protocol/sofarpc"
 	"github.com/alipay/sofa-mosn/pkg/types"
 )
 
+const (
+	// defaultNumRetries is used if the retry policy does not limit the retries
+	defaultNumRetries = 3
+	// minRetryConcurrency retries are always allowed by the retry budget
+	minRetryConcurrency = 3
+	// hostSelectionRetryCount is the max times to select another host for the retry
+	hostSelectionRetryCount = 3
+)
+
 type retryState struct {
 	retryPolicy     types.RetryPolicy
 	requestHeaders  map[string]string
 	cluster         types.ClusterInfo
 	retryOn         bool
 	retiesRemaining uint32
+	retries         uint32
+	triedHosts      []string
 	retryFunc       func()
 	retryTimer      *timer
 }
@@ -42,11 +54,11 @@ func newRetryState(retryPolicy types.RetryPolicy,
 		requestHeaders:  requestHeaders,
 		cluster:         cluster,
 		retryOn:         retryPolicy.RetryOn(),
-		retiesRemaining: 3,
+		retiesRemaining: retryPolicy.NumRetries(),
 	}
 
-	if retryPolicy.NumRetries() > rs.retiesRemaining {
-		rs.retiesRemaining = retryPolicy.NumRetries()
+	if rs.retiesRemaining == 0 {
+		rs.retiesRemaining = defaultNumRetries
 	}
 
 	return rs
@@ -71,13 +83,13 @@ func (r *retryState) shouldRetry(headers map[string]string, reason types.StreamR
 		return types.NoRetry
 	}
 
-	r.retiesRemaining--
-
 	if !r.doRetryCheck(headers, reason) {
 		return types.NoRetry
 	}
 
-	if r.cluster.ResourceManager().Retries().CanCreate() {
+	r.retiesRemaining--
+
+	if !r.cluster.ResourceManager().Retries().CanCreate() || !r.withinBudget() {
 		r.cluster.Stats().UpstreamRequestRetryOverflow.Inc(1)
 
 		return types.RetryOverflow
@@ -86,38 +98,129 @@ func (r *retryState) shouldRetry(headers map[string]string, reason types.StreamR
 	return types.ShouldRetry
 }
 
+// withinBudget checks whether the active retries of the cluster are within the retry budget
+func (r *retryState) withinBudget() bool {
+	percent := r.retryPolicy.RetryBudgetPercent()
+	if percent == 0 {
+		return true
+	}
+
+	resourceManager := r.cluster.ResourceManager()
+	budget := resourceManager.Requests().Cur() * int64(percent) / 100
+	if budget < minRetryConcurrency {
+		budget = minRetryConcurrency
+	}
+
+	return resourceManager.Retries().Cur() < budget
+}
+
 func (r *retryState) scheduleRetry(doRetry func()) *timer {
 	r.retryFunc = doRetry
 	r.cluster.ResourceManager().Retries().Increase()
 	r.cluster.Stats().UpstreamRequestRetry.Inc(1)
 
-	// todo: use backoff alth
-	timeout := rand.Intn(10)
-	timer := newTimer(doRetry, time.Duration(timeout)*time.Second)
+	timer := newTimer(doRetry, r.backOff())
 	timer.start()
 
 	return timer
 }
 
+// backOff returns a random interval in [0, base * 2^retries), no more than the max interval
+func (r *retryState) backOff() time.Duration {
+	base, max := r.retryPolicy.BackOff()
+	if base <= 0 {
+		return 0
+	}
+
+	interval := max
+	if r.retries < 32 {
+		if i := base << r.retries; i > 0 && i < max {
+			interval = i
+		}
+	}
+	r.retries++
+
+	if interval <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(interval)))
+}
+
 func (r *retryState) doRetryCheck(headers map[string]string, reason types.StreamResetReason) bool {
-	if reason == types.StreamOverflow {
+	if reason == types.StreamOverflow || !r.retryOn {
 		return false
 	}
 
-	if r.retryOn {
-		if code, ok := headers[types.HeaderStatus]; ok {
-			codeValue, _ := strconv.Atoi(code)
+	conditions := r.retryPolicy.RetryConditions()
 
-			return codeValue >= 500
+	if reason != "" {
+		if reason == types.StreamConnectionFailed {
+			return conditions&(types.RetryOn5xx|types.RetryOnConnectFailure) != 0
 		}
 
-		// todo: more conditions
+		return conditions&(types.RetryOn5xx|types.RetryOnReset) != 0
+	}
+
+	if conditions&types.RetryOnServerBusy != 0 {
+		if status, ok := headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderRespStatus)]; ok {
+			if status == strconv.Itoa(int(sofarpc.RESPONSE_STATUS_SERVER_THREADPOOL_BUSY)) {
+				return true
+			}
+		}
+	}
+
+	code, ok := headers[types.HeaderStatus]
+	if !ok {
+		return false
+	}
+	codeValue, _ := strconv.Atoi(code)
+
+	if conditions&types.RetryOn5xx != 0 && codeValue >= 500 {
+		return true
+	}
+
+	if conditions&types.RetryOnGatewayError != 0 && codeValue >= 502 && codeValue <= 504 {
+		return true
+	}
+
+	if conditions&types.RetryOnRetriableStatusCodes != 0 {
+		for _, retriable := range r.retryPolicy.RetriableStatusCodes() {
+			if codeValue == retriable {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// addTriedHost records the host tried by the request
+func (r *retryState) addTriedHost(host types.HostInfo) {
+	r.triedHosts = append(r.triedHosts, host.AddressString())
+}
+
+// shouldSelectAnotherHost returns true if the host has been tried and the retry policy avoids the previous hosts
+func (r *retryState) shouldSelectAnotherHost(host types.HostInfo) bool {
+	if !r.retryPolicy.AvoidPreviousHosts() {
+		return false
+	}
+
+	for _, address := range r.triedHosts {
+		if address == host.AddressString() {
+			return true
+		}
 	}
 
 	return false
 }
 
 func (r *retryState) reset() {
+	if r.retryTimer != nil {
+		r.retryTimer.stop()
+		r.retryTimer = nil
+	}
+
 	if r.retryFunc != nil {
 		r.cluster.ResourceManager().Retries().Decrease()
 		r.retryFunc = nil
@@ -0,0 +1,325 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/protocol/sofarpc"
+	"github.com/alipay/sofa-mosn/pkg/types"
+	"github.com/rcrowley/go-metrics"
+)
+
+type mockRetryPolicy struct {
+	types.RetryPolicy
+	conditions           types.RetryCondition
+	retriableStatusCodes []int
+	baseInterval         time.Duration
+	maxInterval          time.Duration
+	budgetPercent        uint32
+	avoidPreviousHosts   bool
+}
+
+func (p *mockRetryPolicy) RetryOn() bool {
+	return p.conditions != 0
+}
+
+func (p *mockRetryPolicy) NumRetries() uint32 {
+	return 3
+}
+
+func (p *mockRetryPolicy) RetryConditions() types.RetryCondition {
+	return p.conditions
+}
+
+func (p *mockRetryPolicy) RetriableStatusCodes() []int {
+	return p.retriableStatusCodes
+}
+
+func (p *mockRetryPolicy) BackOff() (time.Duration, time.Duration) {
+	return p.baseInterval, p.maxInterval
+}
+
+func (p *mockRetryPolicy) RetryBudgetPercent() uint32 {
+	return p.budgetPercent
+}
+
+func (p *mockRetryPolicy) AvoidPreviousHosts() bool {
+	return p.avoidPreviousHosts
+}
+
+type mockResource struct {
+	types.Resource
+	current int64
+	max     int64
+}
+
+func (r *mockResource) CanCreate() bool {
+	return r.current < r.max
+}
+
+func (r *mockResource) Increase() {
+	r.current++
+}
+
+func (r *mockResource) Decrease() {
+	r.current--
+}
+
+func (r *mockResource) Cur() int64 {
+	return r.current
+}
+
+type mockResourceManager struct {
+	types.ResourceManager
+	requests *mockResource
+	retries  *mockResource
+}
+
+func (rm *mockResourceManager) Requests() types.Resource {
+	return rm.requests
+}
+
+func (rm *mockResourceManager) Retries() types.Resource {
+	return rm.retries
+}
+
+type mockRetryClusterInfo struct {
+	types.ClusterInfo
+	resourceManager types.ResourceManager
+	stats           types.ClusterStats
+}
+
+func newMockRetryClusterInfo(maxRetries int64) *mockRetryClusterInfo {
+	return &mockRetryClusterInfo{
+		resourceManager: &mockResourceManager{
+			requests: &mockResource{max: 10240},
+			retries:  &mockResource{max: maxRetries},
+		},
+		stats: types.ClusterStats{
+			UpstreamRequestRetry:         metrics.NewCounter(),
+			UpstreamRequestRetryOverflow: metrics.NewCounter(),
+		},
+	}
+}
+
+func (ci *mockRetryClusterInfo) ResourceManager() types.ResourceManager {
+	return ci.resourceManager
+}
+
+func (ci *mockRetryClusterInfo) Stats() types.ClusterStats {
+	return ci.stats
+}
+
+type mockRetryHost struct {
+	types.HostInfo
+	address string
+}
+
+func (h *mockRetryHost) AddressString() string {
+	return h.address
+}
+
+func TestRetryStateDoRetryCheck(t *testing.T) {
+	serverBusy := map[string]string{
+		sofarpc.SofaPropertyHeader(sofarpc.HeaderRespStatus): strconv.Itoa(int(sofarpc.RESPONSE_STATUS_SERVER_THREADPOOL_BUSY)),
+	}
+
+	testCases := []struct {
+		conditions types.RetryCondition
+		headers    map[string]string
+		reason     types.StreamResetReason
+		expected   bool
+	}{
+		// retry is not enabled
+		{0, nil, types.StreamRemoteReset, false},
+		// overflow is never retried
+		{types.RetryOn5xx, nil, types.StreamOverflow, false},
+		// reset
+		{types.RetryOn5xx, nil, types.StreamRemoteReset, true},
+		{types.RetryOnReset, nil, types.StreamRemoteReset, true},
+		{types.RetryOnConnectFailure, nil, types.StreamRemoteReset, false},
+		{types.RetryOnGatewayError, nil, types.StreamRemoteReset, false},
+		// connect failure
+		{types.RetryOn5xx, nil, types.StreamConnectionFailed, true},
+		{types.RetryOnConnectFailure, nil, types.StreamConnectionFailed, true},
+		{types.RetryOnReset, nil, types.StreamConnectionFailed, false},
+		// 5xx
+		{types.RetryOn5xx, map[string]string{types.HeaderStatus: "500"}, "", true},
+		{types.RetryOn5xx, map[string]string{types.HeaderStatus: "404"}, "", false},
+		{types.RetryOn5xx, map[string]string{}, "", false},
+		// gateway error
+		{types.RetryOnGatewayError, map[string]string{types.HeaderStatus: "502"}, "", true},
+		{types.RetryOnGatewayError, map[string]string{types.HeaderStatus: "504"}, "", true},
+		{types.RetryOnGatewayError, map[string]string{types.HeaderStatus: "500"}, "", false},
+		// retriable status codes
+		{types.RetryOnRetriableStatusCodes, map[string]string{types.HeaderStatus: "409"}, "", true},
+		{types.RetryOnRetriableStatusCodes, map[string]string{types.HeaderStatus: "410"}, "", false},
+		{types.RetryOn5xx, map[string]string{types.HeaderStatus: "409"}, "", false},
+		// bolt server busy
+		{types.RetryOnServerBusy, serverBusy, "", true},
+		{types.RetryOn5xx, serverBusy, "", false},
+	}
+
+	for i, tc := range testCases {
+		rs := newRetryState(&mockRetryPolicy{
+			conditions:           tc.conditions,
+			retriableStatusCodes: []int{409},
+		}, nil, newMockRetryClusterInfo(3))
+
+		if got := rs.doRetryCheck(tc.headers, tc.reason); got != tc.expected {
+			t.Errorf("#%d conditions = %b, headers = %v, reason = %s, expected %v, but got %v",
+				i, tc.conditions, tc.headers, tc.reason, tc.expected, got)
+		}
+	}
+}
+
+func TestRetryStateBackOff(t *testing.T) {
+	testCases := []struct {
+		baseInterval time.Duration
+		maxInterval  time.Duration
+		// the upper bound of the backoff of each retry
+		bounds []time.Duration
+	}{
+		// backoff is disabled
+		{0, time.Second, []time.Duration{0, 0}},
+		// exponential bound, capped by the max interval
+		{10 * time.Millisecond, 50 * time.Millisecond, []time.Duration{
+			10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond,
+		}},
+	}
+
+	for i, tc := range testCases {
+		// sample the random backoff several times
+		for n := 0; n < 100; n++ {
+			rs := newRetryState(&mockRetryPolicy{
+				conditions:   types.RetryOn5xx,
+				baseInterval: tc.baseInterval,
+				maxInterval:  tc.maxInterval,
+			}, nil, newMockRetryClusterInfo(3))
+
+			for retry, bound := range tc.bounds {
+				got := rs.backOff()
+				if got < 0 || (bound == 0 && got != 0) || (bound > 0 && got >= bound) {
+					t.Fatalf("#%d retry %d, expected backoff in [0, %s), but got %s", i, retry, bound, got)
+				}
+			}
+		}
+	}
+
+	// the shift does not overflow after many retries
+	rs := newRetryState(&mockRetryPolicy{
+		conditions:   types.RetryOn5xx,
+		baseInterval: time.Second,
+		maxInterval:  2 * time.Second,
+	}, nil, newMockRetryClusterInfo(3))
+	rs.retries = 100
+	if got := rs.backOff(); got < 0 || got >= 2*time.Second {
+		t.Errorf("expected backoff capped by max interval, but got %s", got)
+	}
+}
+
+func TestRetryStateWithinBudget(t *testing.T) {
+	testCases := []struct {
+		budgetPercent uint32
+		requests      int
+		retries       int
+		expected      bool
+	}{
+		// no budget
+		{0, 0, 100, true},
+		// budget is 20% of 100 requests
+		{20, 100, 19, true},
+		{20, 100, 20, false},
+		// min retry concurrency is always allowed
+		{20, 5, 2, true},
+		{20, 5, 3, false},
+	}
+
+	for i, tc := range testCases {
+		clusterInfo := newMockRetryClusterInfo(1000)
+		for n := 0; n < tc.requests; n++ {
+			clusterInfo.ResourceManager().Requests().Increase()
+		}
+		for n := 0; n < tc.retries; n++ {
+			clusterInfo.ResourceManager().Retries().Increase()
+		}
+
+		rs := newRetryState(&mockRetryPolicy{
+			conditions:    types.RetryOn5xx,
+			budgetPercent: tc.budgetPercent,
+		}, nil, clusterInfo)
+		if got := rs.withinBudget(); got != tc.expected {
+			t.Errorf("#%d expected within budget %v, but got %v", i, tc.expected, got)
+		}
+	}
+}
+
+func TestRetryStateBudgetOverflow(t *testing.T) {
+	clusterInfo := newMockRetryClusterInfo(1000)
+	for n := 0; n < minRetryConcurrency; n++ {
+		clusterInfo.ResourceManager().Retries().Increase()
+	}
+
+	rs := newRetryState(&mockRetryPolicy{
+		conditions:    types.RetryOn5xx,
+		budgetPercent: 20,
+	}, nil, clusterInfo)
+
+	headers := map[string]string{types.HeaderStatus: "500"}
+	if check := rs.shouldRetry(headers, ""); check != types.RetryOverflow {
+		t.Errorf("expected retry overflow, but got %v", check)
+	}
+	if clusterInfo.Stats().UpstreamRequestRetryOverflow.Count() != 1 {
+		t.Error("retry overflow is not recorded")
+	}
+
+	clusterInfo.ResourceManager().Retries().Decrease()
+	if check := rs.shouldRetry(headers, ""); check != types.ShouldRetry {
+		t.Errorf("expected should retry, but got %v", check)
+	}
+}
+
+func TestRetryStateShouldSelectAnotherHost(t *testing.T) {
+	tried := &mockRetryHost{address: "127.0.0.1:8080"}
+	untried := &mockRetryHost{address: "127.0.0.2:8080"}
+
+	testCases := []struct {
+		avoidPreviousHosts bool
+		host               types.HostInfo
+		expected           bool
+	}{
+		{false, tried, false},
+		{true, tried, true},
+		{true, untried, false},
+	}
+
+	for i, tc := range testCases {
+		rs := newRetryState(&mockRetryPolicy{
+			conditions:         types.RetryOn5xx,
+			avoidPreviousHosts: tc.avoidPreviousHosts,
+		}, nil, newMockRetryClusterInfo(3))
+		rs.addTriedHost(tried)
+
+		if got := rs.shouldSelectAnotherHost(tc.host); got != tc.expected {
+			t.Errorf("#%d expected %v, but got %v", i, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/buffer"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/runtime"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// shadowHostSuffix is appended to the host header of mirrored http requests
+const shadowHostSuffix = "-shadow"
+
+// types.StreamEventListener
+// types.StreamReceiver
+// types.PoolEventListener
+// shadowRequest mirrors a downstream request to the shadow cluster in a fire and forget manner.
+// The request is buffered until the downstream request is received completely, the shadow
+// response is discarded and the failures never affect the downstream.
+type shadowRequest struct {
+	proxy       *proxy
+	context     context.Context
+	clusterName string
+	timeout     time.Duration
+
+	headers  map[string]string
+	data     types.IoBuffer
+	trailers map[string]string
+
+	requestSender types.StreamSender
+	timer         *timer
+	finished      bool
+	mux           sync.Mutex
+}
+
+// newShadowRequest returns nil if the route has no shadow policy or the request is not sampled
+func newShadowRequest(s *downStream, route types.Route, headers map[string]string) *shadowRequest {
+	policy := route.RouteRule().Policy()
+	if policy == nil {
+		return nil
+	}
+
+	shadowPolicy := policy.ShadowPolicy()
+	if shadowPolicy == nil || !shadowSampled(shadowPolicy.RuntimeKey(), shadowPolicy.Percent()) {
+		return nil
+	}
+
+	r := &shadowRequest{
+		proxy:       s.proxy,
+		context:     buffer.NewBufferPoolContext(s.context, false),
+		clusterName: shadowPolicy.ClusterName(),
+		headers:     copyShadowHeaders(headers),
+	}
+
+	if s.timeout != nil {
+		r.timeout = s.timeout.GlobalTimeout
+	}
+
+	return r
+}
+
+// shadowSampled reads the percent from the runtime key if it is set
+func shadowSampled(runtimeKey string, percent uint32) bool {
+	if runtimeKey != "" {
+		return runtime.Snapshot().FeatureEnabled(runtimeKey, uint64(percent), rand.Uint64())
+	}
+
+	if percent >= 100 {
+		return true
+	}
+
+	return uint32(rand.Intn(100)) < percent
+}
+
+// copyShadowHeaders copies the headers, as the stream layer may modify the headers when encoding.
+// The host of http requests is suffixed, so the shadow cluster can tell the mirrored requests.
+func copyShadowHeaders(headers map[string]string) map[string]string {
+	copied := make(map[string]string, len(headers))
+	for k, v := range headers {
+		copied[k] = v
+	}
+
+	if host, ok := copied[protocol.MosnHeaderHostKey]; ok && host != "" {
+		copied[protocol.MosnHeaderHostKey] = host + shadowHostSuffix
+	}
+
+	return copied
+}
+
+// sendShadowRequest sends the buffered shadow request, the shadow request is detached
+// from the downstream as its lifecycle is independent
+func (s *downStream) sendShadowRequest() {
+	if s.shadowRequest == nil {
+		return
+	}
+
+	shadowRequest := s.shadowRequest
+	s.shadowRequest = nil
+	shadowRequest.send()
+}
+
+func (r *shadowRequest) appendData(data types.IoBuffer) {
+	if r.data == nil {
+		r.data = data.Clone()
+		return
+	}
+
+	r.data.Write(data.Bytes())
+}
+
+func (r *shadowRequest) appendTrailers(trailers map[string]string) {
+	r.trailers = make(map[string]string, len(trailers))
+	for k, v := range trailers {
+		r.trailers[k] = v
+	}
+}
+
+// send is called once the downstream request is received completely
+func (r *shadowRequest) send() {
+	clusterSnapshot := r.proxy.clusterManager.Get(nil, r.clusterName)
+	if clusterSnapshot == nil || reflect.ValueOf(clusterSnapshot).IsNil() {
+		log.DefaultLogger.Warnf("shadow cluster %s not found, request is not mirrored", r.clusterName)
+		return
+	}
+
+	connPool := r.proxy.clusterManager.ConnPoolForCluster(nil, r.clusterName, types.Protocol(r.proxy.config.UpstreamProtocol))
+	if connPool == nil {
+		log.DefaultLogger.Warnf("no healthy upstream in shadow cluster %s, request is not mirrored", r.clusterName)
+		return
+	}
+
+	if r.timeout > 0 {
+		r.timer = newTimer(r.onTimeout, r.timeout)
+		r.timer.start()
+	}
+
+	connPool.NewStream(r.context, r.headers[types.HeaderStreamID], r, r)
+}
+
+// finish marks the shadow request finished, returns false if it has been finished already
+func (r *shadowRequest) finish() bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.finished {
+		return false
+	}
+	r.finished = true
+
+	if r.timer != nil {
+		r.timer.stop()
+	}
+
+	return true
+}
+
+func (r *shadowRequest) onTimeout() {
+	r.mux.Lock()
+	sender := r.requestSender
+	r.mux.Unlock()
+
+	if !r.finish() {
+		return
+	}
+
+	log.DefaultLogger.Debugf("shadow request to cluster %s timeout", r.clusterName)
+	if sender != nil {
+		sender.GetStream().RemoveEventListener(r)
+		sender.GetStream().ResetStream(types.StreamLocalReset)
+	}
+}
+
+// types.PoolEventListener
+func (r *shadowRequest) OnFailure(streamID string, reason types.PoolFailureReason, host types.Host) {
+	log.DefaultLogger.Debugf("shadow request to cluster %s failed, reason = %v", r.clusterName, reason)
+	r.finish()
+}
+
+func (r *shadowRequest) OnReady(streamID string, sender types.StreamSender, host types.Host) {
+	r.mux.Lock()
+	if r.finished {
+		r.mux.Unlock()
+		sender.GetStream().ResetStream(types.StreamLocalReset)
+		return
+	}
+	r.requestSender = sender
+	r.mux.Unlock()
+
+	sender.GetStream().AddEventListener(r)
+
+	endStream := r.data == nil && r.trailers == nil
+	sender.AppendHeaders(r.context, r.headers, endStream)
+
+	if r.data != nil {
+		sender.AppendData(r.context, r.data, r.trailers == nil)
+	}
+
+	if r.trailers != nil {
+		sender.AppendTrailers(r.context, r.trailers)
+	}
+}
+
+// types.StreamEventListener
+func (r *shadowRequest) OnResetStream(reason types.StreamResetReason) {
+	log.DefaultLogger.Debugf("shadow request to cluster %s reset, reason = %v", r.clusterName, reason)
+	r.finish()
+}
+
+// types.StreamReceiver
+// the shadow response is discarded
+func (r *shadowRequest) OnReceiveHeaders(context context.Context, headers map[string]string, endStream bool) {
+	if endStream {
+		r.finish()
+	}
+}
+
+func (r *shadowRequest) OnReceiveData(context context.Context, data types.IoBuffer, endStream bool) {
+	data.Drain(data.Len())
+
+	if endStream {
+		r.finish()
+	}
+}
+
+func (r *shadowRequest) OnReceiveTrailers(context context.Context, trailers map[string]string) {
+	r.finish()
+}
+
+func (r *shadowRequest) OnDecodeError(context context.Context, err error, headers map[string]string) {
+	r.finish()
+}
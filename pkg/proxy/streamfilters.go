@@ -285,7 +285,7 @@ func (f *activeStreamFilter) ResetStream() {
 }
 
 func (f *activeStreamFilter) Route() types.Route {
-	return f.activeStream.route
+	return f.activeStream.matchRoute()
 }
 
 func (f *activeStreamFilter) StreamID() string {
@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package proxy
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/protocol/sofarpc/models"
+	"github.com/alipay/sofa-mosn/pkg/trace"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// startSpan starts the server span of the downstream request if tracing is enabled
+func (s *downStream) startSpan(headers map[string]string) {
+	driver := trace.GetDriver()
+	if driver == nil {
+		return
+	}
+
+	proto := types.Protocol(s.proxy.config.DownstreamProtocol)
+	operation := s.proxy.config.Name
+
+	// request line headers are removed by http stream on encoding, so tag them on start
+	switch proto {
+	case protocol.HTTP1, protocol.HTTP2:
+		if path, ok := headers[protocol.MosnHeaderPathKey]; ok {
+			operation = path
+		}
+	case protocol.SofaRPC:
+		if service, ok := headers[models.SERVICE_KEY]; ok {
+			operation = service
+		}
+	}
+
+	s.span = driver.Start(proto, headers, operation, s.requestInfo.StartTime())
+	s.span.SetTag(trace.TagProtocol, string(proto))
+
+	if method, ok := headers[protocol.MosnHeaderMethod]; ok {
+		s.span.SetTag(trace.TagHTTPMethod, method)
+	}
+	if path, ok := headers[protocol.MosnHeaderPathKey]; ok {
+		s.span.SetTag(trace.TagHTTPPath, path)
+	}
+	if service, ok := headers[models.SERVICE_KEY]; ok {
+		s.span.SetTag(trace.TagService, service)
+	}
+	if remoteAddr := s.proxy.readCallbacks.Connection().RemoteAddr(); remoteAddr != nil {
+		s.span.SetTag(trace.TagPeerAddress, remoteAddr.String())
+	}
+}
+
+// decorateSpan applies the trace decorator of the matched route
+func (s *downStream) decorateSpan(route types.Route) {
+	if s.span == nil {
+		return
+	}
+
+	if decorator := route.TraceDecorator(); decorator != nil {
+		decorator.Apply(s.span)
+	}
+	s.span.SetTag(trace.TagUpstreamCluster, s.cluster.Name())
+}
+
+// setSpanStatus tags the status code of the response sent to downstream
+func (s *downStream) setSpanStatus(headers interface{}) {
+	if s.span == nil {
+		return
+	}
+
+	if headersMap, ok := headers.(map[string]string); ok {
+		setSpanStatus(s.span, headersMap)
+	}
+}
+
+func (s *downStream) finishSpan() {
+	if s.span == nil {
+		return
+	}
+
+	if s.upstreamRequest != nil {
+		s.upstreamRequest.finishSpan("")
+	}
+
+	s.span.FinishSpan()
+}
+
+// startSpan spawns a client span of the downstream span, and injects it into the upstream request headers
+func (r *upstreamRequest) startSpan(host types.Host, headers map[string]string) {
+	parent := r.downStream.span
+	if parent == nil {
+		return
+	}
+
+	clusterName := r.downStream.cluster.Name()
+
+	r.span = parent.SpawnChild(clusterName, time.Now())
+	r.span.SetTag(trace.TagUpstreamCluster, clusterName)
+	r.span.SetTag(trace.TagPeerAddress, host.AddressString())
+	r.span.InjectContext(types.Protocol(r.proxy.config.UpstreamProtocol), headers)
+}
+
+// finishSpan finishes the client span, the span is tagged as error if errorReason is not empty
+func (r *upstreamRequest) finishSpan(errorReason string) {
+	if r.span == nil {
+		return
+	}
+
+	if errorReason != "" {
+		r.span.SetTag(trace.TagError, errorReason)
+	}
+
+	r.span.FinishSpan()
+	r.span = nil
+}
+
+func setSpanStatus(span types.Span, headers map[string]string) {
+	status, ok := headers[types.HeaderStatus]
+	if !ok {
+		return
+	}
+
+	span.SetTag(trace.TagStatusCode, status)
+
+	if code, err := strconv.Atoi(status); err == nil && code >= 500 {
+		span.SetTag(trace.TagError, "true")
+	}
+}
@@ -70,8 +70,15 @@ const (
 	UpstreamReset         UpstreamResetType = "UpstreamReset"
 	UpstreamGlobalTimeout UpstreamResetType = "UpstreamGlobalTimeout"
 	UpstreamPerTryTimeout UpstreamResetType = "UpstreamPerTryTimeout"
+	UpstreamHedgeCancel   UpstreamResetType = "UpstreamHedgeCancel"
 )
 
+// setCookieHeader is used to set the cookie generated by the route's hash policy
+const setCookieHeader = "set-cookie"
+
+// locationHeader is used to set the location of the redirect
+const locationHeader = "location"
+
 func init() {
 	ConnNewPoolFactories = make(map[types.Protocol]connNewPool)
 }
@@ -20,9 +20,13 @@ package proxy
 import (
 	"container/list"
 	"context"
+	"net/http"
+	"strconv"
 
 	"github.com/alipay/sofa-mosn/pkg/buffer"
 	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/protocol/sofarpc"
 	"github.com/alipay/sofa-mosn/pkg/types"
 )
 
@@ -36,6 +40,7 @@ type upstreamRequest struct {
 	host          types.Host
 	requestSender types.StreamSender
 	connPool      types.ConnectionPool
+	span          types.Span
 
 	// ~~~ upstream response buf
 	upstreamRespHeaders map[string]string
@@ -68,6 +73,7 @@ func (r *upstreamRequest) OnResetStream(reason types.StreamResetReason) {
 			direction: Upstream,
 			streamID:  r.downStream.streamID,
 			stream:    r.downStream,
+			request:   r,
 		},
 		reason: reason,
 	})
@@ -76,6 +82,16 @@ func (r *upstreamRequest) OnResetStream(reason types.StreamResetReason) {
 func (r *upstreamRequest) ResetStream(reason types.StreamResetReason) {
 	r.requestSender = nil
 
+	if reason == types.StreamRemoteReset || reason == types.StreamConnectionTermination {
+		r.putResult(types.ResultRequestFailed)
+	}
+
+	// the other hedged request goes on if one of them fails
+	if r.downStream.hedgeRequest != nil {
+		r.downStream.cancelHedgedRequest(r)
+		return
+	}
+
 	// todo: check if we get a reset on encode request headers. e.g. send failed
 	r.downStream.onUpstreamReset(UpstreamReset, reason)
 }
@@ -90,6 +106,7 @@ func (r *upstreamRequest) OnReceiveHeaders(context context.Context, headers map[
 			direction: Upstream,
 			streamID:  r.downStream.streamID,
 			stream:    r.downStream,
+			request:   r,
 		},
 		headers:   headers,
 		endStream: endStream,
@@ -98,6 +115,19 @@ func (r *upstreamRequest) OnReceiveHeaders(context context.Context, headers map[
 
 func (r *upstreamRequest) ReceiveHeaders(headers map[string]string, endStream bool) {
 	r.upstreamRespHeaders = headers
+
+	if r.span != nil {
+		setSpanStatus(r.span, headers)
+	}
+
+	if code, ok := responseCode(headers); ok {
+		r.putResponseCode(code)
+	}
+
+	if r.downStream.hedgeRequest != nil && !r.downStream.onHedgedResponse(r, headers) {
+		return
+	}
+
 	r.downStream.onUpstreamHeaders(headers, endStream)
 }
 
@@ -110,6 +140,7 @@ func (r *upstreamRequest) OnReceiveData(context context.Context, data types.IoBu
 			direction: Upstream,
 			streamID:  r.downStream.streamID,
 			stream:    r.downStream,
+			request:   r,
 		},
 		data:      r.downStream.downstreamRespDataBuf,
 		endStream: endStream,
@@ -126,6 +157,7 @@ func (r *upstreamRequest) OnReceiveTrailers(context context.Context, trailers ma
 			direction: Upstream,
 			streamID:  r.downStream.streamID,
 			stream:    r.downStream,
+			request:   r,
 		},
 		trailers: trailers,
 	})
@@ -176,6 +208,8 @@ func (r *upstreamRequest) OnFailure(streamID string, reason types.PoolFailureRea
 		resetReason = types.StreamOverflow
 	case types.ConnectionFailure:
 		resetReason = types.StreamConnectionFailed
+		r.host = host
+		r.putResult(types.ResultConnectFailed)
 	}
 
 	r.ResetStream(resetReason)
@@ -184,6 +218,14 @@ func (r *upstreamRequest) OnFailure(streamID string, reason types.PoolFailureRea
 func (r *upstreamRequest) OnReady(streamID string, sender types.StreamSender, host types.Host) {
 	r.requestSender = sender
 	r.requestSender.GetStream().AddEventListener(r)
+	r.host = host
+
+	r.startSpan(host, r.downStream.downstreamReqHeaders)
+
+	// rewrite the host header with the selected upstream host's hostname
+	if route := r.downStream.route; route != nil && route.RouteRule().AutoHostRewrite() && host.Hostname() != "" {
+		r.downStream.downstreamReqHeaders[protocol.MosnHeaderHostKey] = host.Hostname()
+	}
 
 	endStream := r.sendComplete && !r.dataSent && !r.trailerSent
 	r.requestSender.AppendHeaders(r.downStream.context, r.downStream.downstreamReqHeaders, endStream)
@@ -193,3 +235,50 @@ func (r *upstreamRequest) OnReady(streamID string, sender types.StreamSender, ho
 
 	// todo: check if we get a reset on send headers
 }
+
+// ~~~ outlier detection
+func (r *upstreamRequest) putResponseCode(code int) {
+	if r.host == nil {
+		return
+	}
+
+	if monitor := r.host.OutlierDetector(); monitor != nil {
+		monitor.PutResponseCode(code)
+	}
+}
+
+func (r *upstreamRequest) putResult(result types.OutlierResult) {
+	if r.host == nil {
+		return
+	}
+
+	if monitor := r.host.OutlierDetector(); monitor != nil {
+		monitor.PutResult(result)
+	}
+}
+
+// responseCode gets the http status code of the response, the bolt response status is mapped to a http one
+func responseCode(headers map[string]string) (int, bool) {
+	if status, ok := headers[types.HeaderStatus]; ok {
+		if code, err := strconv.Atoi(status); err == nil {
+			return code, true
+		}
+	}
+
+	if status, ok := headers[sofarpc.SofaPropertyHeader(sofarpc.HeaderRespStatus)]; ok {
+		switch sofarpc.ConvertPropertyValueInt16(status) {
+		case sofarpc.RESPONSE_STATUS_SUCCESS:
+			return http.StatusOK, true
+		case sofarpc.RESPONSE_STATUS_SERVER_THREADPOOL_BUSY:
+			return http.StatusServiceUnavailable, true
+		case sofarpc.RESPONSE_STATUS_TIMEOUT:
+			return http.StatusGatewayTimeout, true
+		case sofarpc.RESPONSE_STATUS_ERROR_COMM, sofarpc.RESPONSE_STATUS_CLIENT_SEND_ERROR:
+			return http.StatusBadGateway, true
+		default:
+			return http.StatusInternalServerError, true
+		}
+	}
+
+	return 0, false
+}
@@ -34,15 +34,16 @@ func parseProxyTimeout(route types.Route, headers map[string]string) *Timeout {
 	// todo: check global timeout in request headers
 	// todo: check per try timeout in request headers
 
+	// the timeouts in request headers are in milliseconds
 	if tto, ok := headers[types.HeaderTryTimeout]; ok {
 		if trytimeout, err := strconv.ParseInt(tto, 10, bitSize64); err == nil {
-			timeout.TryTimeout = time.Duration(trytimeout)
+			timeout.TryTimeout = time.Duration(trytimeout) * time.Millisecond
 		}
 	}
 
 	if gto, ok := headers[types.HeaderGlobalTimeout]; ok {
 		if globaltimeout, err := strconv.ParseInt(gto, 10, bitSize64); err == nil {
-			timeout.GlobalTimeout = time.Duration(globaltimeout)
+			timeout.GlobalTimeout = time.Duration(globaltimeout) * time.Millisecond
 		}
 	}
 
@@ -80,9 +80,7 @@ func (rc *Routers) AddRouter(routerName string) {
 	} else {
 		br.globalTimeout = types.GlobalTimeout
 		br.policy = &routerPolicy{
-			retryOn:      false,
-			retryTimeout: 0,
-			numRetries:   0,
+			retryPolicy: router.NewRetryPolicyImpl(nil),
 		}
 	}
 
@@ -127,21 +125,10 @@ func NewRouters(config interface{}) (types.Routers, error) {
 				service:       r.Service,
 				cluster:       r.Cluster,
 				globalTimeout: r.GlobalTimeout,
-			}
-
-			if r.RetryPolicy != nil {
-				router.policy = &routerPolicy{
-					retryOn:      r.RetryPolicy.RetryOn,
-					retryTimeout: r.RetryPolicy.RetryTimeout,
-					numRetries:   r.RetryPolicy.NumRetries,
-				}
-			} else {
-				// default
-				router.policy = &routerPolicy{
-					retryOn:      false,
-					retryTimeout: 0,
-					numRetries:   0,
-				}
+				// retry is off by default
+				policy: &routerPolicy{
+					retryPolicy: router.NewRetryPolicyImpl(r.RetryPolicy),
+				},
 			}
 
 			routers = append(routers, router)
@@ -209,32 +196,32 @@ func (srr *basicRouter) GetRouterName() string {
 	return srr.name
 }
 
-type routerPolicy struct {
-	retryOn      bool
-	retryTimeout time.Duration
-	numRetries   uint32
+func (srr *basicRouter) FinalizeRequestHeaders(headers map[string]string, requestInfo types.RequestInfo) {
 }
 
-func (p *routerPolicy) RetryOn() bool {
-	return p.retryOn
+func (srr *basicRouter) FinalizeResponseHeaders(headers map[string]string, requestInfo types.RequestInfo) {
 }
 
-func (p *routerPolicy) TryTimeout() time.Duration {
-	return p.retryTimeout
+func (srr *basicRouter) AutoHostRewrite() bool {
+	return false
 }
 
-func (p *routerPolicy) NumRetries() uint32 {
-	return p.numRetries
+type routerPolicy struct {
+	retryPolicy types.RetryPolicy
 }
 
 func (p *routerPolicy) RetryPolicy() types.RetryPolicy {
-	return p
+	return p.retryPolicy
 }
 
 func (p *routerPolicy) ShadowPolicy() types.ShadowPolicy {
 	return nil
 }
 
+func (p *routerPolicy) HedgePolicy() types.HedgePolicy {
+	return nil
+}
+
 func (p *routerPolicy) CorsPolicy() types.CorsPolicy {
 	return nil
 }
@@ -242,3 +229,7 @@ func (p *routerPolicy) CorsPolicy() types.CorsPolicy {
 func (p *routerPolicy) LoadBalancerPolicy() types.LoadBalancerPolicy {
 	return nil
 }
+
+func (p *routerPolicy) RateLimitPolicy() types.RateLimitPolicy {
+	return nil
+}
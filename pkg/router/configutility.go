@@ -105,7 +105,7 @@ func (qpm *queryParameterMatcher) Matches(requestQueryParams types.QueryParams)
 // Implementation of Config that reads from a proto file.
 type configImpl struct {
 	name                  string
-	routeMatcher          routeMatcher
+	routeMatcher          *routeMatcher
 	internalOnlyHeaders   *list.List
 	requestHeadersParser  *headerParser
 	responseHeadersParser *headerParser
@@ -123,6 +123,17 @@ func (ci *configImpl) InternalOnlyHeaders() *list.List {
 	return ci.internalOnlyHeaders
 }
 
+// stripInternalOnlyHeaders removes the headers that only internal requests are allowed to set
+func (ci *configImpl) stripInternalOnlyHeaders(headers map[string]string) {
+	if ci.internalOnlyHeaders == nil {
+		return
+	}
+
+	for e := ci.internalOnlyHeaders.Front(); e != nil; e = e.Next() {
+		delete(headers, e.Value.(string))
+	}
+}
+
 // NewMetadataMatchCriteriaImpl
 func NewMetadataMatchCriteriaImpl(metadataMatches map[string]string) *MetadataMatchCriteriaImpl {
 
@@ -0,0 +1,186 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"net"
+	"strings"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// headerVariableDelimiter wraps the request info variables in the header value, such as %DownstreamRemoteAddress%
+const headerVariableDelimiter = "%"
+
+type headerPair struct {
+	headerName  *lowerCaseString
+	headerValue types.HeaderFormat
+}
+
+type headerParser struct {
+	headersToAdd    []*headerPair
+	headersToRemove []*lowerCaseString
+}
+
+// newHeaderParser returns nil if there is no header to add or remove
+func newHeaderParser(headersToAdd []*v2.HeaderValueOption, headersToRemove []string) *headerParser {
+	if len(headersToAdd) == 0 && len(headersToRemove) == 0 {
+		return nil
+	}
+
+	parser := &headerParser{}
+	for _, header := range headersToAdd {
+		name := &lowerCaseString{header.Key}
+		name.Lower()
+		parser.headersToAdd = append(parser.headersToAdd, &headerPair{
+			headerName:  name,
+			headerValue: newHeaderFormatter(header.Value, header.Append),
+		})
+	}
+	for _, header := range headersToRemove {
+		name := &lowerCaseString{header}
+		name.Lower()
+		parser.headersToRemove = append(parser.headersToRemove, name)
+	}
+
+	return parser
+}
+
+// evaluateHeaders removes the headers first, then adds the headers with the formatted value
+func (hp *headerParser) evaluateHeaders(headers map[string]string, requestInfo types.RequestInfo) {
+	if hp == nil || headers == nil {
+		return
+	}
+
+	for _, name := range hp.headersToRemove {
+		delete(headers, name.Get())
+	}
+
+	for _, header := range hp.headersToAdd {
+		name := header.headerName.Get()
+		value := header.headerValue.Format(requestInfo)
+		if old, ok := headers[name]; ok && old != "" && header.headerValue.Append() {
+			headers[name] = old + "," + value
+		} else {
+			headers[name] = value
+		}
+	}
+}
+
+// headerFormatter implements types.HeaderFormat
+// the value is split into the literal parts and the request info variables,
+// the variables are the same as the access log format, an unknown variable is kept as it is
+type headerFormatter struct {
+	parts  []headerFormatterPart
+	append bool
+}
+
+type headerFormatterPart struct {
+	literal string
+	getter  func(info types.RequestInfo) string
+}
+
+func newHeaderFormatter(value string, appendValue bool) *headerFormatter {
+	formatter := &headerFormatter{
+		append: appendValue,
+	}
+
+	for {
+		start := strings.Index(value, headerVariableDelimiter)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(value[start+1:], headerVariableDelimiter)
+		if end < 0 {
+			break
+		}
+		end += start + 1
+
+		getter, ok := log.RequestInfoFuncMap[value[start+1:end]]
+		if !ok {
+			log.DefaultLogger.Warnf("unknown request info variable %s in header value, keep it as literal", value[start:end+1])
+			formatter.parts = append(formatter.parts, headerFormatterPart{literal: value[:end]})
+			value = value[end:]
+			continue
+		}
+
+		if start > 0 {
+			formatter.parts = append(formatter.parts, headerFormatterPart{literal: value[:start]})
+		}
+		formatter.parts = append(formatter.parts, headerFormatterPart{getter: getter})
+		value = value[end+1:]
+	}
+
+	if value != "" {
+		formatter.parts = append(formatter.parts, headerFormatterPart{literal: value})
+	}
+
+	return formatter
+}
+
+func (hf *headerFormatter) Format(info types.RequestInfo) string {
+	if len(hf.parts) == 1 && hf.parts[0].getter == nil {
+		return hf.parts[0].literal
+	}
+
+	var value string
+	for _, part := range hf.parts {
+		if part.getter == nil {
+			value += part.literal
+		} else if info != nil {
+			value += part.getter(info)
+		}
+	}
+
+	return value
+}
+
+func (hf *headerFormatter) Append() bool {
+	return hf.append
+}
+
+// isInternalAddress reports whether the address is a loopback or a private network address
+func isInternalAddress(addr net.Addr) bool {
+	if addr == nil {
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() {
+		return true
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4[0] == 10 ||
+			(ip4[0] == 172 && ip4[1]&0xf0 == 16) ||
+			(ip4[0] == 192 && ip4[1] == 168)
+	}
+
+	// unique local address fc00::/7
+	return len(ip) == net.IPv6len && ip[0]&0xfe == 0xfc
+}
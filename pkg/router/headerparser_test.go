@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"net"
+	"testing"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/network"
+)
+
+func TestHeaderFormatter(t *testing.T) {
+	requestInfo := network.NewRequestInfo()
+	requestInfo.SetDownstreamRemoteAddress(&net.TCPAddr{IP: net.ParseIP("10.1.1.1"), Port: 12200})
+
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{"static", "static"},
+		{"%DownstreamRemoteAddress%", "10.1.1.1:12200"},
+		{"from %DownstreamRemoteAddress% by mosn", "from 10.1.1.1:12200 by mosn"},
+		{"50%Unknown%DownstreamRemoteAddress%", "50%Unknown10.1.1.1:12200"},
+		{"100%", "100%"},
+	}
+	for i, tc := range testCases {
+		formatter := newHeaderFormatter(tc.value, true)
+		if got := formatter.Format(requestInfo); got != tc.expected {
+			t.Errorf("#%d format %s, expected %s, but got %s", i, tc.value, tc.expected, got)
+		}
+	}
+}
+
+func TestRouteRuleFinalizeHeaders(t *testing.T) {
+	virtualHost := &v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Routers: []v2.Router{
+			{
+				Name:  "prefix",
+				Match: v2.RouterMatch{Prefix: "/"},
+				Route: v2.RouteAction{ClusterName: "test"},
+				RequestHeadersToAdd: []*v2.HeaderValueOption{
+					{Key: "X-Route", Value: "%RouteName%", Append: false},
+					{Key: "x-added", Value: "route", Append: true},
+				},
+				RequestHeadersToRemove: []string{"X-Removed"},
+				ResponseHeadersToAdd: []*v2.HeaderValueOption{
+					{Key: "x-server", Value: "route", Append: false},
+				},
+			},
+		},
+		RequestHeadersToAdd: []*v2.HeaderValueOption{
+			{Key: "x-added", Value: "vhost", Append: true},
+			{Key: "x-client", Value: "%DownstreamRemoteAddress%", Append: false},
+		},
+		ResponseHeadersToAdd: []*v2.HeaderValueOption{
+			{Key: "x-server", Value: "vhost", Append: false},
+		},
+		ResponseHeadersToRemove: []string{"x-internal"},
+	}
+	routers, err := NewRouteMatcher(&v2.Proxy{
+		VirtualHosts:        []*v2.VirtualHost{virtualHost},
+		InternalOnlyHeaders: []string{"X-Internal-Only"},
+	})
+	if err != nil {
+		t.Fatal("create route matcher failed", err)
+	}
+
+	headers := map[string]string{
+		"host":            "test",
+		"path":            "/foo",
+		"x-added":         "client",
+		"x-removed":       "client",
+		"x-internal-only": "client",
+	}
+	route := routers.Route(headers, 1)
+	if route == nil {
+		t.Fatal("no route matched")
+	}
+
+	requestInfo := network.NewRequestInfo()
+	requestInfo.SetRouteEntry(route.RouteRule())
+	requestInfo.SetDownstreamRemoteAddress(&net.TCPAddr{IP: net.ParseIP("1.1.1.1"), Port: 80})
+	route.RouteRule().FinalizeRequestHeaders(headers, requestInfo)
+
+	expected := map[string]string{
+		"x-route":  "prefix",
+		"x-added":  "client,vhost,route",
+		"x-client": "1.1.1.1:80",
+	}
+	for k, v := range expected {
+		if headers[k] != v {
+			t.Errorf("request header %s expected %s, but got %s", k, v, headers[k])
+		}
+	}
+	for _, k := range []string{"x-removed", "x-internal-only"} {
+		if _, ok := headers[k]; ok {
+			t.Errorf("request header %s should be removed", k)
+		}
+	}
+
+	// internal only headers are kept for the internal requests
+	headers = map[string]string{"x-internal-only": "client"}
+	requestInfo.SetDownstreamRemoteAddress(&net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 80})
+	route.RouteRule().FinalizeRequestHeaders(headers, requestInfo)
+	if headers["x-internal-only"] != "client" {
+		t.Error("internal only header should be kept for the internal request")
+	}
+
+	respHeaders := map[string]string{
+		"x-server":   "upstream",
+		"x-internal": "upstream",
+	}
+	route.RouteRule().FinalizeResponseHeaders(respHeaders, requestInfo)
+	if respHeaders["x-server"] != "route" {
+		t.Errorf("response header x-server expected route, but got %s", respHeaders["x-server"])
+	}
+	if _, ok := respHeaders["x-internal"]; ok {
+		t.Error("response header x-internal should be removed")
+	}
+}
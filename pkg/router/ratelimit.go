@@ -0,0 +1,224 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"net"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// descriptor keys of the rate limit actions
+const (
+	GenericKeyDescriptorKey         = "generic_key"
+	DestinationClusterDescriptorKey = "destination_cluster"
+	RemoteAddressDescriptorKey      = "remote_address"
+	ServiceDescriptorKey            = "service"
+)
+
+// types.RateLimitPolicy
+type rateLimitPolicyImpl struct {
+	rateLimitEntries []types.RateLimitPolicyEntry
+	maxStageNumber   uint64
+}
+
+func newRateLimitPolicyImpl(rateLimits []v2.RateLimit) *rateLimitPolicyImpl {
+	if len(rateLimits) == 0 {
+		return nil
+	}
+
+	rp := &rateLimitPolicyImpl{}
+	for _, rateLimit := range rateLimits {
+		entry := &rateLimitPolicyEntryImpl{
+			stage:      uint64(rateLimit.Stage),
+			disableKey: rateLimit.DisableKey,
+		}
+
+		for _, action := range rateLimit.Actions {
+			switch {
+			case action.GenericKey != "":
+				entry.actions = append(entry.actions, &genericKeyAction{descriptorValue: action.GenericKey})
+			case action.DestinationCluster:
+				entry.actions = append(entry.actions, &destinationClusterAction{})
+			case action.RequestHeader != "":
+				entry.actions = append(entry.actions, &requestHeadersAction{
+					headerName:    action.RequestHeader,
+					descriptorKey: action.DescriptorKey,
+				})
+			case action.RemoteAddress:
+				entry.actions = append(entry.actions, &remoteAddressAction{})
+			case action.Service:
+				entry.actions = append(entry.actions, &serviceAction{})
+			}
+		}
+
+		if entry.stage > rp.maxStageNumber {
+			rp.maxStageNumber = entry.stage
+		}
+		rp.rateLimitEntries = append(rp.rateLimitEntries, entry)
+	}
+
+	return rp
+}
+
+func (rp *rateLimitPolicyImpl) Enabled() bool {
+	return len(rp.rateLimitEntries) > 0
+}
+
+func (rp *rateLimitPolicyImpl) GetApplicableRateLimit(stage uint64) []types.RateLimitPolicyEntry {
+	if stage > rp.maxStageNumber {
+		return nil
+	}
+
+	var entries []types.RateLimitPolicyEntry
+	for _, entry := range rp.rateLimitEntries {
+		if entry.Stage() == stage {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// types.RateLimitPolicyEntry
+type rateLimitPolicyEntryImpl struct {
+	stage      uint64
+	disableKey string
+	actions    []rateLimitAction
+}
+
+func (rpei *rateLimitPolicyEntryImpl) Stage() uint64 {
+	return rpei.stage
+}
+
+func (rpei *rateLimitPolicyEntryImpl) DisableKey() string {
+	return rpei.disableKey
+}
+
+func (rpei *rateLimitPolicyEntryImpl) PopulateDescriptors(route types.RouteRule, descriptors []types.Descriptor, localSrvCluster string,
+	headers map[string]string, remoteAddr string) []types.Descriptor {
+	if len(rpei.actions) == 0 {
+		return descriptors
+	}
+
+	descriptor := types.Descriptor{
+		Entries: make([]types.DescriptorEntry, 0, len(rpei.actions)),
+	}
+
+	for _, action := range rpei.actions {
+		entry, ok := action.populateDescriptorEntry(route, headers, remoteAddr)
+		if !ok {
+			return descriptors
+		}
+		descriptor.Entries = append(descriptor.Entries, entry)
+	}
+
+	return append(descriptors, descriptor)
+}
+
+// rateLimitAction generates an entry of the descriptor, returns false if the action can not be applied
+type rateLimitAction interface {
+	populateDescriptorEntry(route types.RouteRule, headers map[string]string, remoteAddr string) (types.DescriptorEntry, bool)
+}
+
+type genericKeyAction struct {
+	descriptorValue string
+}
+
+func (a *genericKeyAction) populateDescriptorEntry(route types.RouteRule, headers map[string]string, remoteAddr string) (types.DescriptorEntry, bool) {
+	return types.DescriptorEntry{Key: GenericKeyDescriptorKey, Value: a.descriptorValue}, true
+}
+
+type destinationClusterAction struct{}
+
+func (a *destinationClusterAction) populateDescriptorEntry(route types.RouteRule, headers map[string]string, remoteAddr string) (types.DescriptorEntry, bool) {
+	if route == nil {
+		return types.DescriptorEntry{}, false
+	}
+
+	return types.DescriptorEntry{Key: DestinationClusterDescriptorKey, Value: route.ClusterName()}, true
+}
+
+type requestHeadersAction struct {
+	headerName    string
+	descriptorKey string
+}
+
+func (a *requestHeadersAction) populateDescriptorEntry(route types.RouteRule, headers map[string]string, remoteAddr string) (types.DescriptorEntry, bool) {
+	value, ok := headers[a.headerName]
+	if !ok {
+		return types.DescriptorEntry{}, false
+	}
+
+	return types.DescriptorEntry{Key: a.descriptorKey, Value: value}, true
+}
+
+type remoteAddressAction struct{}
+
+func (a *remoteAddressAction) populateDescriptorEntry(route types.RouteRule, headers map[string]string, remoteAddr string) (types.DescriptorEntry, bool) {
+	if remoteAddr == "" {
+		return types.DescriptorEntry{}, false
+	}
+
+	// only the ip is used, as the port changes in each connection
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		remoteAddr = host
+	}
+
+	return types.DescriptorEntry{Key: RemoteAddressDescriptorKey, Value: remoteAddr}, true
+}
+
+type serviceAction struct{}
+
+func (a *serviceAction) populateDescriptorEntry(route types.RouteRule, headers map[string]string, remoteAddr string) (types.DescriptorEntry, bool) {
+	service, ok := headers[types.SofaRouteMatchKey]
+	if !ok || service == "" {
+		return types.DescriptorEntry{}, false
+	}
+
+	return types.DescriptorEntry{Key: ServiceDescriptorKey, Value: service}, true
+}
+
+// PopulateRateLimitDescriptors generates the descriptors of the request in the stage,
+// by the rate limits of the route and the rate limits of its virtual host
+func PopulateRateLimitDescriptors(route types.Route, stage uint64, headers map[string]string, remoteAddr string) []types.Descriptor {
+	if route == nil || route.RouteRule() == nil {
+		return nil
+	}
+	rule := route.RouteRule()
+
+	var descriptors []types.Descriptor
+	populate := func(policy types.RateLimitPolicy) {
+		if policy == nil || !policy.Enabled() {
+			return
+		}
+		for _, entry := range policy.GetApplicableRateLimit(stage) {
+			descriptors = entry.PopulateDescriptors(rule, descriptors, "", headers, remoteAddr)
+		}
+	}
+
+	if policy := rule.Policy(); policy != nil {
+		populate(policy.RateLimitPolicy())
+	}
+	if vh := rule.VirtualHost(); vh != nil {
+		populate(vh.RateLimitPolicy())
+	}
+
+	return descriptors
+}
@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package router
+
+import (
+	"testing"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+func TestRateLimitPolicyPopulateDescriptors(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+
+	route := &v2.Router{
+		Match: v2.RouterMatch{Prefix: "/"},
+		Route: v2.RouteAction{
+			ClusterName: "test",
+			RateLimits: []v2.RateLimit{
+				{
+					Actions: []v2.RateLimitAction{
+						{GenericKey: "api"},
+						{DestinationCluster: true},
+						{RemoteAddress: true},
+					},
+				},
+				{
+					Actions: []v2.RateLimitAction{
+						{RequestHeader: "x-user", DescriptorKey: "user"},
+					},
+				},
+				{
+					Stage:   1,
+					Actions: []v2.RateLimitAction{{Service: true}},
+				},
+			},
+		},
+	}
+	rr, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+	policy := rr.Policy().RateLimitPolicy()
+	if policy == nil || !policy.Enabled() {
+		t.Fatal("expected rate limit policy")
+	}
+
+	headers := map[string]string{
+		types.SofaRouteMatchKey: "com.alipay.test.TestService:1.0",
+	}
+
+	// the request header is not present, so the second descriptor is not generated
+	var descriptors []types.Descriptor
+	for _, entry := range policy.GetApplicableRateLimit(0) {
+		descriptors = entry.PopulateDescriptors(&rr, descriptors, "", headers, "10.0.0.1:12345")
+	}
+	if len(descriptors) != 1 {
+		t.Fatalf("expected 1 descriptor, got %v", descriptors)
+	}
+	expected := []types.DescriptorEntry{
+		{Key: GenericKeyDescriptorKey, Value: "api"},
+		{Key: DestinationClusterDescriptorKey, Value: "test"},
+		{Key: RemoteAddressDescriptorKey, Value: "10.0.0.1"},
+	}
+	for i, entry := range descriptors[0].Entries {
+		if entry != expected[i] {
+			t.Errorf("expected entry %v, got %v", expected[i], entry)
+		}
+	}
+
+	headers["x-user"] = "alice"
+	descriptors = descriptors[:0]
+	for _, entry := range policy.GetApplicableRateLimit(0) {
+		descriptors = entry.PopulateDescriptors(&rr, descriptors, "", headers, "10.0.0.1:12345")
+	}
+	if len(descriptors) != 2 || descriptors[1].Entries[0] != (types.DescriptorEntry{Key: "user", Value: "alice"}) {
+		t.Errorf("unexpected descriptors %v", descriptors)
+	}
+
+	entries := policy.GetApplicableRateLimit(1)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 rate limit in stage 1, got %d", len(entries))
+	}
+	descriptors = entries[0].PopulateDescriptors(&rr, nil, "", headers, "")
+	if len(descriptors) != 1 || descriptors[0].Entries[0] != (types.DescriptorEntry{Key: ServiceDescriptorKey, Value: "com.alipay.test.TestService:1.0"}) {
+		t.Errorf("unexpected descriptors %v", descriptors)
+	}
+
+	if len(policy.GetApplicableRateLimit(2)) != 0 {
+		t.Error("expected no rate limit in stage 2")
+	}
+}
@@ -34,6 +34,7 @@
 package router
 
 import (
+	"container/list"
 	"fmt"
 	"sort"
 	"strings"
@@ -61,6 +62,15 @@ func NewRouteMatcher(config interface{}) (types.Routers, error) {
 	}
 
 	if config, ok := config.(*v2.Proxy); ok {
+		routeConfig := &configImpl{
+			name:                config.Name,
+			routeMatcher:        routerMatcher,
+			internalOnlyHeaders: list.New(),
+		}
+		for _, header := range config.InternalOnlyHeaders {
+			routeConfig.internalOnlyHeaders.PushBack(strings.ToLower(header))
+		}
+
 		for _, virtualHost := range config.VirtualHosts {
 			// if virtualHost is nil, it is a invalid config, panic in NewVirtualHostImpl
 			//if nil == virtualHost {
@@ -71,6 +81,7 @@ func NewRouteMatcher(config interface{}) (types.Routers, error) {
 			if err != nil {
 				return nil, err
 			}
+			vh.globalRouteConfig = routeConfig
 			for _, domain := range virtualHost.Domains {
 				// Note: we use domain in lowercase
 				domain = strings.ToLower(domain)
@@ -19,6 +19,7 @@ package router
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/alipay/sofa-mosn/pkg/log"
 	"github.com/alipay/sofa-mosn/pkg/types"
@@ -32,6 +33,9 @@ type routersManager struct {
 	routersSet  []types.Routers
 	rMutex      *sync.RWMutex
 	routerNames []string
+
+	// routers subscribed by the router config name, see GetRouters
+	routersWrappers map[string]*routersWrapper
 }
 
 func (rm *routersManager) AddRoutersSet(routers types.Routers) {
@@ -101,3 +105,74 @@ func (rm *routersManager) DeleteRouterNameInList(rNames []string) {
 		}
 	}
 }
+
+// GetRouters returns the routers of the router config name, which is used by the proxies
+// whose routers are subscribed from RDS. The routers route nothing until they are added
+// by AddOrUpdateRouters, and the later updates are seen by the returned routers directly.
+func (rm *routersManager) GetRouters(routerConfigName string) types.Routers {
+	rm.rMutex.Lock()
+	defer rm.rMutex.Unlock()
+
+	return rm.getOrCreateRoutersWrapper(routerConfigName)
+}
+
+// AddOrUpdateRouters hot swaps the routers of the router config name,
+// the proxies referencing the name use the new routers without rebuilding the listeners
+func (rm *routersManager) AddOrUpdateRouters(routerConfigName string, routers types.Routers) {
+	rm.rMutex.Lock()
+	defer rm.rMutex.Unlock()
+
+	log.DefaultLogger.Debugf("[RouterManager]AddOrUpdateRouters Called, router config name is %s", routerConfigName)
+	rm.getOrCreateRoutersWrapper(routerConfigName).routers.Store(&routersHolder{routers})
+}
+
+// getOrCreateRoutersWrapper should be called with the lock held
+func (rm *routersManager) getOrCreateRoutersWrapper(routerConfigName string) *routersWrapper {
+	if rm.routersWrappers == nil {
+		rm.routersWrappers = make(map[string]*routersWrapper)
+	}
+
+	wrapper, ok := rm.routersWrappers[routerConfigName]
+	if !ok {
+		wrapper = &routersWrapper{}
+		wrapper.routers.Store(&routersHolder{})
+		rm.routersWrappers[routerConfigName] = wrapper
+	}
+
+	return wrapper
+}
+
+// routersHolder makes the routers storable in atomic.Value, which does not accept nil
+type routersHolder struct {
+	routers types.Routers
+}
+
+// types.Routers
+// routersWrapper delegates to the current routers, which are swapped atomically
+type routersWrapper struct {
+	routers atomic.Value
+}
+
+func (rw *routersWrapper) current() types.Routers {
+	return rw.routers.Load().(*routersHolder).routers
+}
+
+func (rw *routersWrapper) Route(headers map[string]string, randomValue uint64) types.Route {
+	if routers := rw.current(); routers != nil {
+		return routers.Route(headers, randomValue)
+	}
+
+	return nil
+}
+
+func (rw *routersWrapper) AddRouter(routerName string) {
+	if routers := rw.current(); routers != nil {
+		routers.AddRouter(routerName)
+	}
+}
+
+func (rw *routersWrapper) DelRouter(routerName string) {
+	if routers := rw.current(); routers != nil {
+		routers.DelRouter(routerName)
+	}
+}
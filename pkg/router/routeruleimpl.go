@@ -29,6 +29,7 @@ import (
 	"github.com/alipay/sofa-mosn/pkg/log"
 	"github.com/alipay/sofa-mosn/pkg/protocol"
 	httpmosn "github.com/alipay/sofa-mosn/pkg/protocol/http"
+	"github.com/alipay/sofa-mosn/pkg/runtime"
 	"github.com/alipay/sofa-mosn/pkg/types"
 	multimap "github.com/jwangsadinata/go-multimap/slicemultimap"
 )
@@ -37,20 +38,53 @@ import (
 // new routerule implement basement
 func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (RouteRuleImplBase, error) {
 	routeRuleImplBase := RouteRuleImplBase{
+		routerName:    route.Name,
 		vHost:         vHost,
 		routerMatch:   route.Match,
 		routerAction:  route.Route,
 		clusterName:   route.Route.ClusterName,
 		randInstance:  rand.New(rand.NewSource(time.Now().UnixNano())),
-		configHeaders: getRouterHeades(route.Match.Headers),
+		configHeaders: GetRouterHeaders(route.Match.Headers),
+		runtime:       route.Match.Runtime,
+
+		configQueryParameters: getRouterQueryParameters(route.Match.QueryParameters),
+
+		prefixRewrite:   route.Route.PrefixRewrite,
+		hostRewrite:     route.Route.HostRewrite,
+		autoHostRewrite: route.Route.AutoHostRewrite,
+	}
+
+	if regexRewrite := route.Route.RegexRewrite; regexRewrite != nil {
+		regexPattern, err := regexp.Compile(regexRewrite.Pattern)
+		if err != nil {
+			return RouteRuleImplBase{}, err
+		}
+		routeRuleImplBase.regexRewrite = regexPattern
+		routeRuleImplBase.regexRewriteSubstitution = regexRewrite.Substitution
 	}
 
 	routeRuleImplBase.weightedClusters, routeRuleImplBase.totalClusterWeight = getWeightedClusterEntry(route.Route.WeightedClusters)
 
+	if route.Decorator != "" {
+		routeRuleImplBase.decorator = &decoratorImpl{
+			Operation: string(route.Decorator),
+		}
+	}
+
+	routeRuleImplBase.redirectRule = newRedirectRuleImpl(route)
+	routeRuleImplBase.requestHeadersParser = newHeaderParser(route.RequestHeadersToAdd, route.RequestHeadersToRemove)
+	routeRuleImplBase.responseHeadersParser = newHeaderParser(route.ResponseHeadersToAdd, route.ResponseHeadersToRemove)
+	routeRuleImplBase.hashPolicy = newHashPolicyImpl(route.Route.HashPolicy)
+	routeRuleImplBase.shadowPolicy = newShadowPolicyImpl(route.Route.ShadowPolicy)
+	routeRuleImplBase.rateLimitPolicy = newRateLimitPolicyImpl(route.Route.RateLimits)
+
 	routeRuleImplBase.policy = &routerPolicy{
-		retryOn:      false,
-		retryTimeout: 0,
-		numRetries:   0,
+		retryPolicy:  NewRetryPolicyImpl(route.Route.RetryPolicy),
+		hashPolicy:   routeRuleImplBase.hashPolicy,
+		shadowPolicy: routeRuleImplBase.shadowPolicy,
+		rateLimit:    routeRuleImplBase.rateLimitPolicy,
+		hedgePolicy:  newHedgePolicyImpl(route.Route.HedgePolicy),
+		corsPolicy:   newCorsPolicyImpl(route.Route.Cors),
 	}
 
 	// todo add header match to route base
@@ -67,8 +101,11 @@ func NewRouteRuleImplBase(vHost *VirtualHostImpl, route *v2.Router) (RouteRuleIm
 
 // Base implementation for all route entries.
 type RouteRuleImplBase struct {
+	routerName                  string
 	caseSensitive               bool
 	prefixRewrite               string
+	regexRewrite                *regexp.Regexp
+	regexRewriteSubstitution    string
 	hostRewrite                 string
 	includeVirtualHostRateLimit bool
 	corsPolicy                  types.CorsPolicy //todo
@@ -81,10 +118,7 @@ type RouteRuleImplBase struct {
 	clusterNotFoundResponseCode httpmosn.Code
 	timeout                     time.Duration
 	runtime                     v2.RuntimeUInt32
-	hostRedirect                string
-	pathRedirect                string
-	httpsRedirect               bool
-	retryPolicy                 *retryPolicyImpl
+	redirectRule                *redirectRuleImpl
 	rateLimitPolicy             *rateLimitPolicyImpl
 
 	routerAction v2.RouteAction
@@ -96,7 +130,7 @@ type RouteRuleImplBase struct {
 	configQueryParameters []types.QueryParameterMatcher
 	weightedClusters      map[string]weightedClusterEntry //key is the wcluster's name
 	totalClusterWeight    uint32
-	hashPolicy            hashPolicyImpl
+	hashPolicy            *hashPolicyImpl
 
 	metadataMatchCriteria *MetadataMatchCriteriaImpl
 	metaData              types.RouteMetaData
@@ -106,25 +140,26 @@ type RouteRuleImplBase struct {
 
 	opaqueConfig multimap.MultiMap
 
-	decorator          *types.Decorator
-	directResponseCode httpmosn.Code
-	directResponseBody string
-	policy             *routerPolicy
-	virtualClusters    *VirtualClusterEntry
-	randInstance       *rand.Rand
-	randMutex          sync.Mutex
+	decorator       types.TraceDecorator
+	policy          *routerPolicy
+	virtualClusters *VirtualClusterEntry
+	randInstance    *rand.Rand
+	randMutex       sync.Mutex
 }
 
 // types.RouterInfo
 func (rri *RouteRuleImplBase) GetRouterName() string {
 
-	return ""
+	return rri.routerName
 }
 
 // types.Route
 func (rri *RouteRuleImplBase) RedirectRule() types.RedirectRule {
+	if rri.redirectRule == nil {
+		return nil
+	}
 
-	return rri.RedirectRule()
+	return rri.redirectRule
 }
 
 func (rri *RouteRuleImplBase) RouteRule() types.RouteRule {
@@ -133,8 +168,7 @@ func (rri *RouteRuleImplBase) RouteRule() types.RouteRule {
 }
 
 func (rri *RouteRuleImplBase) TraceDecorator() types.TraceDecorator {
-
-	return nil
+	return rri.decorator
 }
 
 // types.RouteRule
@@ -146,15 +180,28 @@ func (rri *RouteRuleImplBase) ClusterName() string {
 		return rri.clusterName
 	}
 
+	// the weights may be changed by the runtime, so the total weight is summed up with the same snapshot
+	snapshot := runtime.Snapshot()
+	var totalWeight uint64
+	for _, weightCluster := range rri.weightedClusters {
+		totalWeight += weightCluster.weight(snapshot)
+	}
+
+	if totalWeight == 0 {
+		log.DefaultLogger.Errorf("total weight of the weighted clusters is zero, use the default cluster")
+		return rri.clusterName
+	}
+
 	// use randInstance to avoid global lock contention
 	rri.randMutex.Lock()
-	selectedValue := rri.randInstance.Intn(int(rri.totalClusterWeight))
+	selectedValue := rri.randInstance.Int63n(int64(totalWeight))
 	rri.randMutex.Unlock()
 
 	for _, weightCluster := range rri.weightedClusters {
 
-		selectedValue = selectedValue - int(weightCluster.clusterWeight)
-		if selectedValue <= 0 {
+		// selectedValue is in [0, totalWeight), so a cluster with zero weight is never selected
+		selectedValue = selectedValue - int64(weightCluster.weight(snapshot))
+		if selectedValue < 0 {
 			return weightCluster.clusterName
 		}
 	}
@@ -201,30 +248,83 @@ func (rri *RouteRuleImplBase) MetadataMatchCriteria(clusterName string) types.Me
 	return rri.metadataMatchCriteria
 }
 
-// todo
+// FinalizeRequestHeaders strips the internal only headers if the request is not from an internal address,
+// then applies the headers manipulation of the virtual host and the route, the route's one takes precedence
+func (rri *RouteRuleImplBase) FinalizeRequestHeaders(headers map[string]string, requestInfo types.RequestInfo) {
+	if rri.vHost != nil {
+		if rri.vHost.globalRouteConfig != nil && (requestInfo == nil || !isInternalAddress(requestInfo.DownstreamRemoteAddress())) {
+			rri.vHost.globalRouteConfig.stripInternalOnlyHeaders(headers)
+		}
+		rri.vHost.requestHeadersParser.evaluateHeaders(headers, requestInfo)
+	}
+	rri.requestHeadersParser.evaluateHeaders(headers, requestInfo)
+
+	if rri.hostRewrite != "" {
+		headers[protocol.MosnHeaderHostKey] = rri.hostRewrite
+	}
+}
+
+// FinalizeResponseHeaders applies the headers manipulation of the virtual host and the route, the route's one takes precedence
+func (rri *RouteRuleImplBase) FinalizeResponseHeaders(headers map[string]string, requestInfo types.RequestInfo) {
+	if rri.vHost != nil {
+		rri.vHost.responseHeadersParser.evaluateHeaders(headers, requestInfo)
+	}
+	rri.responseHeadersParser.evaluateHeaders(headers, requestInfo)
+}
+
+func (rri *RouteRuleImplBase) AutoHostRewrite() bool {
+	return rri.autoHostRewrite
+}
+
+// finalizePathHeader rewrites the path with the regex rewrite if it is set,
+// otherwise replaces the matched path with the prefix rewrite
 func (rri *RouteRuleImplBase) finalizePathHeader(headers map[string]string, matchedPath string) {
+	path, ok := headers[protocol.MosnHeaderPathKey]
+	if !ok || path == "" {
+		return
+	}
+
+	if rri.regexRewrite != nil {
+		headers[protocol.MosnHeaderPathKey] = rri.regexRewrite.ReplaceAllString(path, rri.regexRewriteSubstitution)
+		return
+	}
+
+	if rri.prefixRewrite == "" || len(path) < len(matchedPath) {
+		return
+	}
+
+	if rri.caseSensitive && !strings.HasPrefix(path, matchedPath) ||
+		!rri.caseSensitive && !strings.EqualFold(path[:len(matchedPath)], matchedPath) {
+		return
+	}
 
+	headers[protocol.MosnHeaderPathKey] = rri.prefixRewrite + path[len(matchedPath):]
 }
 
 func (rri *RouteRuleImplBase) matchRoute(headers map[string]string, randomValue uint64) bool {
-	// todo check runtime
-	// 1. match headers' KV
+	// 1. match runtime fraction
+	if !rri.matchRuntime(randomValue) {
+		log.DefaultLogger.Debugf("RouteRuleImplBase matchRoute, runtime fraction not matched")
+		return false
+	}
+
+	// 2. match headers' KV
 	if !ConfigUtilityInst.MatchHeaders(headers, rri.configHeaders) {
 		log.DefaultLogger.Errorf("RouteRuleImplBase matchRoute, match headers error")
 		return false
 	}
 
-	// 2. match query parameters
+	// 3. match query parameters
+	if len(rri.configQueryParameters) == 0 {
+		return true
+	}
+
 	var queryParams types.QueryParams
 
 	if QueryString, ok := headers[protocol.MosnHeaderQueryStringKey]; ok {
 		queryParams = httpmosn.ParseQueryString(QueryString)
 	}
 
-	if len(queryParams) == 0 {
-		return true
-	}
-
 	if !ConfigUtilityInst.MatchQueryParams(queryParams, rri.configQueryParameters) {
 		log.DefaultLogger.Errorf("RouteRuleImplBase matchRoute, match query params error")
 		return false
@@ -233,6 +333,17 @@ func (rri *RouteRuleImplBase) matchRoute(headers map[string]string, randomValue
 	return true
 }
 
+// matchRuntime matches if randomValue % 100 is less than the runtime value, which is a percentage,
+// the value is read from the runtime key and defaults to DefaultValue.
+// The route always matches if the runtime is not configured
+func (rri *RouteRuleImplBase) matchRuntime(randomValue uint64) bool {
+	if rri.runtime.RuntimeKey == "" && rri.runtime.DefaultValue == 0 {
+		return true
+	}
+
+	return runtime.Snapshot().FeatureEnabled(rri.runtime.RuntimeKey, uint64(rri.runtime.DefaultValue), randomValue)
+}
+
 func (rri *RouteRuleImplBase) WeightedCluster() map[string]weightedClusterEntry {
 	return rri.weightedClusters
 }
@@ -306,8 +417,12 @@ func (prri *PathRouteRuleImpl) Match(headers map[string]string, randomValue uint
 	return nil
 }
 
-// todo
+func (prri *PathRouteRuleImpl) RouteRule() types.RouteRule {
+	return prri
+}
+
 func (prri *PathRouteRuleImpl) FinalizeRequestHeaders(headers map[string]string, requestInfo types.RequestInfo) {
+	prri.RouteRuleImplBase.FinalizeRequestHeaders(headers, requestInfo)
 	prri.finalizePathHeader(headers, prri.path)
 }
 
@@ -344,7 +459,12 @@ func (prei *PrefixRouteRuleImpl) Match(headers map[string]string, randomValue ui
 	return nil
 }
 
+func (prei *PrefixRouteRuleImpl) RouteRule() types.RouteRule {
+	return prei
+}
+
 func (prei *PrefixRouteRuleImpl) FinalizeRequestHeaders(headers map[string]string, requestInfo types.RequestInfo) {
+	prei.RouteRuleImplBase.FinalizeRequestHeaders(headers, requestInfo)
 	prei.finalizePathHeader(headers, prei.prefix)
 }
 
@@ -381,6 +501,12 @@ func (rrei *RegexRouteRuleImpl) Match(headers map[string]string, randomValue uin
 	return nil
 }
 
+func (rrei *RegexRouteRuleImpl) RouteRule() types.RouteRule {
+	return rrei
+}
+
+// FinalizeRequestHeaders treats the whole path as matched, so the prefix rewrite replaces the path
 func (rrei *RegexRouteRuleImpl) FinalizeRequestHeaders(headers map[string]string, requestInfo types.RequestInfo) {
-	rrei.finalizePathHeader(headers, rrei.regexStr)
+	rrei.RouteRuleImplBase.FinalizeRequestHeaders(headers, requestInfo)
+	rrei.finalizePathHeader(headers, headers[protocol.MosnHeaderPathKey])
 }
@@ -21,9 +21,12 @@ import (
 	"math/rand"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/alipay/sofa-mosn/pkg/api/v2"
 	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/runtime"
+	"github.com/alipay/sofa-mosn/pkg/types"
 )
 
 func TestPrefixRouteRuleImpl(t *testing.T) {
@@ -245,3 +248,387 @@ func TestWeightedClusterSelect(t *testing.T) {
 		t.Errorf("wanted invalid weighted cluster init but not")
 	}
 }
+
+func TestRouteRuleTraceDecorator(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+
+	route := &v2.Router{
+		Match: v2.RouterMatch{Prefix: "/"},
+		Route: v2.RouteAction{ClusterName: "test"},
+	}
+	rr, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+	if rr.TraceDecorator() != nil {
+		t.Error("expected no trace decorator")
+	}
+
+	route.Decorator = "test-operation"
+	rr, _ = NewRouteRuleImplBase(virtualHostImpl, route)
+	decorator := rr.TraceDecorator()
+	if decorator == nil || decorator.GetOperation() != "test-operation" {
+		t.Fatal("expected trace decorator with operation test-operation")
+	}
+}
+
+func TestRouteRuleHashPolicy(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+
+	route := &v2.Router{
+		Match: v2.RouterMatch{Prefix: "/"},
+		Route: v2.RouteAction{ClusterName: "test"},
+	}
+	rr, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+	if rr.Policy().LoadBalancerPolicy() != nil {
+		t.Error("expected no load balancer policy")
+	}
+
+	route.Route.HashPolicy = []v2.HashPolicy{
+		{Header: "X-User"},
+		{Cookie: "session", CookieTTL: time.Hour},
+		{SourceIP: true},
+	}
+	rr, _ = NewRouteRuleImplBase(virtualHostImpl, route)
+	lbPolicy := rr.Policy().LoadBalancerPolicy()
+	if lbPolicy == nil || lbPolicy.HashPolicy() == nil {
+		t.Fatal("expected hash policy")
+	}
+	hashPolicy := lbPolicy.HashPolicy()
+
+	var cookieAdded string
+	addCookie := func(key string, path string, ttl time.Duration) string {
+		cookieAdded = key
+		return "generated"
+	}
+
+	headers := map[string]string{
+		"x-user": "alice",
+		"cookie": `a=b; session="abc"`,
+	}
+	if key := hashPolicy.GenerateHash("10.0.0.1:12345", headers, addCookie); key != "alice;abc;10.0.0.1" {
+		t.Errorf("unexpected hash key %s", key)
+	}
+	if cookieAdded != "" {
+		t.Error("cookie should not be added if present")
+	}
+
+	if key := hashPolicy.GenerateHash("", map[string]string{}, addCookie); key != "generated" {
+		t.Errorf("unexpected hash key %s", key)
+	}
+	if cookieAdded != "session" {
+		t.Error("cookie should be added if not present")
+	}
+}
+
+func TestRouteRuleShadowPolicy(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+
+	route := &v2.Router{
+		Match: v2.RouterMatch{Prefix: "/"},
+		Route: v2.RouteAction{ClusterName: "test"},
+	}
+	rr, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+	if rr.Policy().ShadowPolicy() != nil {
+		t.Error("expected no shadow policy")
+	}
+
+	route.Route.ShadowPolicy = &v2.ShadowPolicy{
+		Cluster:    "shadow",
+		RuntimeKey: "shadow.enabled",
+		Percent:    20,
+	}
+	rr, _ = NewRouteRuleImplBase(virtualHostImpl, route)
+	shadowPolicy := rr.Policy().ShadowPolicy()
+	if shadowPolicy == nil {
+		t.Fatal("expected shadow policy")
+	}
+	if shadowPolicy.ClusterName() != "shadow" || shadowPolicy.RuntimeKey() != "shadow.enabled" || shadowPolicy.Percent() != 20 {
+		t.Errorf("unexpected shadow policy %+v", shadowPolicy)
+	}
+}
+
+func TestRouteRuleRedirectRule(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+	headers := map[string]string{
+		protocol.MosnHeaderHostKey:        "example.com",
+		protocol.MosnHeaderPathKey:        "/old/index.html",
+		protocol.MosnHeaderQueryStringKey: "a=b",
+	}
+
+	route := &v2.Router{
+		Match: v2.RouterMatch{Prefix: "/old"},
+		Route: v2.RouteAction{ClusterName: "test"},
+	}
+	rr, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+	if rr.RedirectRule() != nil {
+		t.Error("expected no redirect rule")
+	}
+
+	testCases := []struct {
+		redirect v2.RedirectAction
+		location string
+		code     int
+	}{
+		{v2.RedirectAction{SchemeRedirect: "https"}, "https://example.com/old/index.html?a=b", 301},
+		{v2.RedirectAction{HostRedirect: "new.com", ResponseCode: 307}, "http://new.com/old/index.html?a=b", 307},
+		{v2.RedirectAction{PathRedirect: "/new", StripQuery: true}, "http://example.com/new", 301},
+		{v2.RedirectAction{PrefixRewrite: "/new", ResponseCode: 302}, "http://example.com/new/index.html?a=b", 302},
+	}
+	for i, tc := range testCases {
+		route.Redirect = tc.redirect
+		rr, _ = NewRouteRuleImplBase(virtualHostImpl, route)
+		redirectRule := rr.RedirectRule()
+		if redirectRule == nil {
+			t.Fatalf("#%d expected redirect rule", i)
+		}
+		if location := redirectRule.NewPath(headers); location != tc.location {
+			t.Errorf("#%d expected location %s, got %s", i, tc.location, location)
+		}
+		if redirectRule.ResponseCode() != tc.code {
+			t.Errorf("#%d expected code %d, got %d", i, tc.code, redirectRule.ResponseCode())
+		}
+	}
+
+	route.Redirect = v2.RedirectAction{}
+	route.DirectResponse = &v2.DirectResponseAction{StatusCode: 503, Body: "unavailable"}
+	rr, _ = NewRouteRuleImplBase(virtualHostImpl, route)
+	redirectRule := rr.RedirectRule()
+	if redirectRule == nil || redirectRule.NewPath(headers) != "" ||
+		redirectRule.ResponseCode() != 503 || redirectRule.ResponseBody() != "unavailable" {
+		t.Errorf("unexpected direct response %+v", redirectRule)
+	}
+}
+
+func TestRouteRuleRewrite(t *testing.T) {
+	testCases := []struct {
+		match    v2.RouterMatch
+		action   v2.RouteAction
+		path     string
+		expected string
+		host     string
+	}{
+		{v2.RouterMatch{Prefix: "/api"}, v2.RouteAction{PrefixRewrite: "/v1"}, "/api/users", "/v1/users", "example.com"},
+		{v2.RouterMatch{Prefix: "/api/"}, v2.RouteAction{PrefixRewrite: "/"}, "/api/users", "/users", "example.com"},
+		{v2.RouterMatch{Path: "/API"}, v2.RouteAction{PrefixRewrite: "/new"}, "/api", "/new", "example.com"},
+		{v2.RouterMatch{Regex: "^/service/.*"}, v2.RouteAction{PrefixRewrite: "/new"}, "/service/foo", "/new", "example.com"},
+		{v2.RouterMatch{Prefix: "/"}, v2.RouteAction{
+			RegexRewrite: &v2.RegexRewrite{Pattern: "^/service/([^/]+)(/.*)$", Substitution: "$2/instance/$1"},
+		}, "/service/foo/v1/api", "/v1/api/instance/foo", "example.com"},
+		{v2.RouterMatch{Prefix: "/"}, v2.RouteAction{HostRewrite: "backend.com"}, "/foo", "/foo", "backend.com"},
+	}
+	for i, tc := range testCases {
+		tc.action.ClusterName = "test"
+		virtualHost, err := NewVirtualHostImpl(&v2.VirtualHost{
+			Name:    "test",
+			Domains: []string{"*"},
+			Routers: []v2.Router{{Match: tc.match, Route: tc.action}},
+		}, false)
+		if err != nil {
+			t.Fatalf("#%d create virtual host failed: %v", i, err)
+		}
+
+		headers := map[string]string{
+			protocol.MosnHeaderHostKey: "example.com",
+			protocol.MosnHeaderPathKey: tc.path,
+		}
+		route := virtualHost.GetRouteFromEntries(headers, 1)
+		if route == nil {
+			t.Fatalf("#%d no route matched", i)
+		}
+		route.RouteRule().FinalizeRequestHeaders(headers, nil)
+		if headers[protocol.MosnHeaderPathKey] != tc.expected {
+			t.Errorf("#%d expected path %s, got %s", i, tc.expected, headers[protocol.MosnHeaderPathKey])
+		}
+		if headers[protocol.MosnHeaderHostKey] != tc.host {
+			t.Errorf("#%d expected host %s, got %s", i, tc.host, headers[protocol.MosnHeaderHostKey])
+		}
+	}
+}
+
+func TestRouteRuleQueryParameters(t *testing.T) {
+	virtualHostImpl, err := NewVirtualHostImpl(&v2.VirtualHost{
+		Name:    "test",
+		Domains: []string{"*"},
+		Routers: []v2.Router{
+			{
+				Match: v2.RouterMatch{
+					Prefix: "/",
+					QueryParameters: []v2.QueryParameterMatcher{
+						{Name: "version", Value: "v1"},
+						{Name: "user", Value: "^test-[0-9]+$", Regex: true},
+						{Name: "debug"},
+					},
+				},
+				Route: v2.RouteAction{ClusterName: "test"},
+			},
+		},
+	}, false)
+	if err != nil {
+		t.Fatal("create virtual host failed", err)
+	}
+
+	testCases := []struct {
+		query    string
+		expected bool
+	}{
+		{"version=v1&user=test-1&debug", true},
+		{"version=v1&user=test-1&debug=true", true},
+		{"version=v2&user=test-1&debug", false},
+		{"version=v1&user=test-a&debug", false},
+		{"version=v1&user=test-1", false},
+		{"", false},
+	}
+	for i, tc := range testCases {
+		headers := map[string]string{
+			protocol.MosnHeaderPathKey:        "/",
+			protocol.MosnHeaderQueryStringKey: tc.query,
+		}
+		if matched := virtualHostImpl.GetRouteFromEntries(headers, 1) != nil; matched != tc.expected {
+			t.Errorf("#%d query %s expected matched %v, got %v", i, tc.query, tc.expected, matched)
+		}
+	}
+}
+
+func TestRouteRuleRuntimeFraction(t *testing.T) {
+	headers := map[string]string{protocol.MosnHeaderPathKey: "/"}
+
+	testCases := []struct {
+		runtime     v2.RuntimeUInt32
+		randomValue uint64
+		expected    bool
+	}{
+		{v2.RuntimeUInt32{}, 99, true},
+		{v2.RuntimeUInt32{DefaultValue: 10}, 9, true},
+		{v2.RuntimeUInt32{DefaultValue: 10}, 110, false},
+		{v2.RuntimeUInt32{DefaultValue: 100}, 199, true},
+		{v2.RuntimeUInt32{RuntimeKey: "routing.canary"}, 0, false},
+		{v2.RuntimeUInt32{RuntimeKey: "routing.runtime_fraction", DefaultValue: 10}, 29, true},
+		{v2.RuntimeUInt32{RuntimeKey: "routing.runtime_fraction", DefaultValue: 10}, 30, false},
+	}
+
+	runtime.DefaultLoader().MergeValues(map[string]string{"routing.runtime_fraction": "30"})
+	defer runtime.DefaultLoader().MergeValues(map[string]string{"routing.runtime_fraction": ""})
+
+	for i, tc := range testCases {
+		virtualHostImpl, err := NewVirtualHostImpl(&v2.VirtualHost{
+			Name:    "test",
+			Domains: []string{"*"},
+			Routers: []v2.Router{
+				{
+					Match: v2.RouterMatch{Prefix: "/", Runtime: tc.runtime},
+					Route: v2.RouteAction{ClusterName: "test"},
+				},
+			},
+		}, false)
+		if err != nil {
+			t.Fatal("create virtual host failed", err)
+		}
+		if matched := virtualHostImpl.GetRouteFromEntries(headers, tc.randomValue) != nil; matched != tc.expected {
+			t.Errorf("#%d expected matched %v, got %v", i, tc.expected, matched)
+		}
+	}
+}
+
+func TestWeightedClusterRuntime(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+	route := &v2.Router{
+		Match: v2.RouterMatch{Prefix: "/"},
+		Route: v2.RouteAction{
+			ClusterName: "defaultCluster",
+			WeightedClusters: []v2.WeightedCluster{
+				{Cluster: v2.ClusterWeight{Name: "w1", Weight: 100}, RuntimeKeyPrefix: "routing.traffic_shift"},
+				{Cluster: v2.ClusterWeight{Name: "w2", Weight: 0}, RuntimeKeyPrefix: "routing.traffic_shift"},
+			},
+		},
+	}
+	rr, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+
+	for i := 0; i < 100; i++ {
+		if name := rr.ClusterName(); name != "w1" {
+			t.Fatalf("expected configured weight selects w1, got %s", name)
+		}
+	}
+
+	// shift all the traffic to w2
+	runtime.DefaultLoader().MergeValues(map[string]string{
+		"routing.traffic_shift.w1": "0",
+		"routing.traffic_shift.w2": "100",
+	})
+	defer runtime.DefaultLoader().MergeValues(map[string]string{
+		"routing.traffic_shift.w1": "",
+		"routing.traffic_shift.w2": "",
+	})
+
+	for i := 0; i < 100; i++ {
+		if name := rr.ClusterName(); name != "w2" {
+			t.Fatalf("expected runtime weight selects w2, got %s", name)
+		}
+	}
+
+	// falls back to the default cluster if all the weights are zero
+	runtime.DefaultLoader().MergeValues(map[string]string{"routing.traffic_shift.w2": "0"})
+	if name := rr.ClusterName(); name != "defaultCluster" {
+		t.Errorf("expected default cluster, got %s", name)
+	}
+}
+
+func TestRouteRuleHedgePolicy(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+
+	route := &v2.Router{
+		Match: v2.RouterMatch{Prefix: "/"},
+		Route: v2.RouteAction{ClusterName: "test"},
+	}
+	rr, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+	if rr.Policy().HedgePolicy() != nil {
+		t.Error("expected no hedge policy")
+	}
+
+	route.Route.HedgePolicy = &v2.HedgePolicy{HedgeDelay: 50 * time.Millisecond}
+	rr, _ = NewRouteRuleImplBase(virtualHostImpl, route)
+	hedgePolicy := rr.Policy().HedgePolicy()
+	if hedgePolicy == nil || hedgePolicy.HedgeDelay() != 50*time.Millisecond {
+		t.Errorf("unexpected hedge policy %+v", hedgePolicy)
+	}
+}
+
+func TestRouteRuleRetryPolicy(t *testing.T) {
+	virtualHostImpl := &VirtualHostImpl{virtualHostName: "test"}
+
+	route := &v2.Router{
+		Match: v2.RouterMatch{Prefix: "/"},
+		Route: v2.RouteAction{ClusterName: "test"},
+	}
+	rr, _ := NewRouteRuleImplBase(virtualHostImpl, route)
+	if retryPolicy := rr.Policy().RetryPolicy(); retryPolicy == nil || retryPolicy.RetryOn() {
+		t.Error("expected retry off by default")
+	}
+
+	route.Route.RetryPolicy = &v2.RetryPolicy{
+		RetryOn:              true,
+		NumRetries:           2,
+		RetryConditions:      []string{"connect-failure", "retriable-status-codes", "unknown"},
+		RetriableStatusCodes: []uint32{409},
+		BackOffBaseInterval:  10 * time.Millisecond,
+		AvoidPreviousHosts:   true,
+	}
+	rr, _ = NewRouteRuleImplBase(virtualHostImpl, route)
+	retryPolicy := rr.Policy().RetryPolicy()
+	if !retryPolicy.RetryOn() || retryPolicy.NumRetries() != 2 || !retryPolicy.AvoidPreviousHosts() {
+		t.Errorf("unexpected retry policy %+v", retryPolicy)
+	}
+	if retryPolicy.RetryConditions() != types.RetryOnConnectFailure|types.RetryOnRetriableStatusCodes {
+		t.Errorf("unexpected retry conditions %b", retryPolicy.RetryConditions())
+	}
+	if codes := retryPolicy.RetriableStatusCodes(); len(codes) != 1 || codes[0] != 409 {
+		t.Errorf("unexpected retriable status codes %v", codes)
+	}
+	if base, max := retryPolicy.BackOff(); base != 10*time.Millisecond || max != 100*time.Millisecond {
+		t.Errorf("unexpected back off interval %v, %v", base, max)
+	}
+
+	// retry on 5xx by default
+	route.Route.RetryPolicy = &v2.RetryPolicy{RetryOn: true}
+	rr, _ = NewRouteRuleImplBase(virtualHostImpl, route)
+	if rr.Policy().RetryPolicy().RetryConditions() != types.RetryOn5xx {
+		t.Errorf("unexpected default retry conditions %b", rr.Policy().RetryPolicy().RetryConditions())
+	}
+}
@@ -18,16 +18,24 @@
 package router
 
 import (
+	"net"
 	"strings"
 	"time"
 
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	httpmosn "github.com/alipay/sofa-mosn/pkg/protocol/http"
 	"github.com/alipay/sofa-mosn/pkg/types"
 )
 
-type headerParser struct {
-	headersToAdd    []types.Pair
-	headersToRemove []*lowerCaseString
-}
+const cookieHeader = "cookie"
+
+// forwardedProtoHeader is used as the scheme of the redirect if the scheme is not rewritten
+const forwardedProtoHeader = "x-forwarded-proto"
+
+// the default base interval of the retry backoff
+const defaultRetryBaseInterval = 25 * time.Millisecond
 
 type matchable interface {
 	Match(headers map[string]string, randomValue uint64) types.Route
@@ -47,6 +55,19 @@ type RouteBase interface {
 type shadowPolicyImpl struct {
 	cluster    string
 	runtimeKey string
+	percent    uint32
+}
+
+func newShadowPolicyImpl(shadowPolicy *v2.ShadowPolicy) *shadowPolicyImpl {
+	if shadowPolicy == nil || shadowPolicy.Cluster == "" {
+		return nil
+	}
+
+	return &shadowPolicyImpl{
+		cluster:    shadowPolicy.Cluster,
+		runtimeKey: shadowPolicy.RuntimeKey,
+		percent:    shadowPolicy.Percent,
+	}
 }
 
 func (spi *shadowPolicyImpl) ClusterName() string {
@@ -57,6 +78,116 @@ func (spi *shadowPolicyImpl) RuntimeKey() string {
 	return spi.runtimeKey
 }
 
+func (spi *shadowPolicyImpl) Percent() uint32 {
+	return spi.percent
+}
+
+// redirectRuleImpl is either a redirect or a direct response
+type redirectRuleImpl struct {
+	redirect       bool
+	schemeRedirect string
+	hostRedirect   string
+	pathRedirect   string
+	prefixRewrite  string
+	matchedPrefix  string
+	stripQuery     bool
+	responseCode   int
+	responseBody   string
+}
+
+func newRedirectRuleImpl(route *v2.Router) *redirectRuleImpl {
+	if route.DirectResponse != nil {
+		return &redirectRuleImpl{
+			responseCode: int(route.DirectResponse.StatusCode),
+			responseBody: route.DirectResponse.Body,
+		}
+	}
+
+	redirect := route.Redirect
+	if redirect.SchemeRedirect == "" && redirect.HostRedirect == "" &&
+		redirect.PathRedirect == "" && redirect.PrefixRewrite == "" {
+		return nil
+	}
+
+	responseCode := int(redirect.ResponseCode)
+	if responseCode == 0 {
+		responseCode = httpmosn.MovedPermanently
+	}
+
+	return &redirectRuleImpl{
+		redirect:       true,
+		schemeRedirect: redirect.SchemeRedirect,
+		hostRedirect:   redirect.HostRedirect,
+		pathRedirect:   redirect.PathRedirect,
+		prefixRewrite:  redirect.PrefixRewrite,
+		matchedPrefix:  route.Match.Prefix,
+		stripQuery:     redirect.StripQuery,
+		responseCode:   responseCode,
+	}
+}
+
+// NewPath returns the redirect location in the form of scheme://host/path?query,
+// the parts not rewritten are taken from the request
+func (rri *redirectRuleImpl) NewPath(headers map[string]string) string {
+	if !rri.redirect {
+		return ""
+	}
+
+	scheme := rri.schemeRedirect
+	if scheme == "" {
+		scheme = "http"
+		if proto, ok := headers[forwardedProtoHeader]; ok && proto != "" {
+			scheme = proto
+		}
+	}
+
+	host := rri.hostRedirect
+	if host == "" {
+		host = headers[protocol.MosnHeaderHostKey]
+	}
+
+	path := headers[protocol.MosnHeaderPathKey]
+	if rri.pathRedirect != "" {
+		path = rri.pathRedirect
+	} else if rri.prefixRewrite != "" && rri.matchedPrefix != "" && len(path) >= len(rri.matchedPrefix) {
+		// the prefix is matched by the route already, maybe case insensitive
+		path = rri.prefixRewrite + path[len(rri.matchedPrefix):]
+	}
+
+	if query, ok := headers[protocol.MosnHeaderQueryStringKey]; ok && query != "" &&
+		!rri.stripQuery && !strings.Contains(path, "?") {
+		path = path + "?" + query
+	}
+
+	return scheme + "://" + host + path
+}
+
+func (rri *redirectRuleImpl) ResponseCode() int {
+	return rri.responseCode
+}
+
+func (rri *redirectRuleImpl) ResponseBody() string {
+	return rri.responseBody
+}
+
+type hedgePolicyImpl struct {
+	hedgeDelay time.Duration
+}
+
+func newHedgePolicyImpl(hedgePolicy *v2.HedgePolicy) *hedgePolicyImpl {
+	if hedgePolicy == nil || hedgePolicy.HedgeDelay <= 0 {
+		return nil
+	}
+
+	return &hedgePolicyImpl{
+		hedgeDelay: hedgePolicy.HedgeDelay,
+	}
+}
+
+func (hpi *hedgePolicyImpl) HedgeDelay() time.Duration {
+	return hpi.hedgeDelay
+}
+
 type lowerCaseString struct {
 	str string
 }
@@ -73,46 +204,188 @@ func (lcs *lowerCaseString) Get() string {
 	return lcs.str
 }
 
+// types.HashPolicy
 type hashPolicyImpl struct {
-	hashImpl []*hashMethod
+	hashImpl []hashMethod
+}
+
+func newHashPolicyImpl(hashPolicies []v2.HashPolicy) *hashPolicyImpl {
+	if len(hashPolicies) == 0 {
+		return nil
+	}
+
+	hp := &hashPolicyImpl{}
+	for _, policy := range hashPolicies {
+		switch {
+		case policy.Header != "":
+			hp.hashImpl = append(hp.hashImpl, &headerHashMethod{
+				headerName: strings.ToLower(policy.Header),
+			})
+		case policy.Cookie != "":
+			hp.hashImpl = append(hp.hashImpl, &cookieHashMethod{
+				name: policy.Cookie,
+				path: policy.CookiePath,
+				ttl:  policy.CookieTTL,
+			})
+		case policy.SourceIP:
+			hp.hashImpl = append(hp.hashImpl, &sourceIPHashMethod{})
+		}
+	}
+
+	return hp
+}
+
+// GenerateHash combines the keys of all hash methods, the methods without key are ignored
+func (hp *hashPolicyImpl) GenerateHash(downstreamAddress string, headers map[string]string,
+	addCookieCb types.AddCookieCallback) types.HashedValue {
+	var key string
+
+	for _, method := range hp.hashImpl {
+		if value, ok := method.evaluate(downstreamAddress, headers, addCookieCb); ok {
+			if key != "" {
+				key += ";"
+			}
+			key += value
+		}
+	}
+
+	return types.HashedValue(key)
+}
+
+type hashMethod interface {
+	evaluate(downstreamAddress string, headers map[string]string, addCookieCb types.AddCookieCallback) (string, bool)
+}
+
+type headerHashMethod struct {
+	headerName string
+}
+
+func (m *headerHashMethod) evaluate(downstreamAddress string, headers map[string]string,
+	addCookieCb types.AddCookieCallback) (string, bool) {
+	value, ok := headers[m.headerName]
+	return value, ok
+}
+
+// cookieHashMethod generates the cookie by the callback if the cookie is not present and the ttl is set
+type cookieHashMethod struct {
+	name string
+	path string
+	ttl  time.Duration
+}
+
+func (m *cookieHashMethod) evaluate(downstreamAddress string, headers map[string]string,
+	addCookieCb types.AddCookieCallback) (string, bool) {
+	if value, ok := getCookieValue(headers, m.name); ok {
+		return value, true
+	}
+
+	if m.ttl > 0 && addCookieCb != nil {
+		return addCookieCb(m.name, m.path, m.ttl), true
+	}
+
+	return "", false
+}
+
+type sourceIPHashMethod struct{}
+
+func (m *sourceIPHashMethod) evaluate(downstreamAddress string, headers map[string]string,
+	addCookieCb types.AddCookieCallback) (string, bool) {
+	if downstreamAddress == "" {
+		return "", false
+	}
+
+	if ip, _, err := net.SplitHostPort(downstreamAddress); err == nil {
+		return ip, true
+	}
+
+	return downstreamAddress, true
 }
 
-type hashMethod struct {
+// getCookieValue finds the cookie named name in the cookie header, such as "k1=v1; k2=v2"
+func getCookieValue(headers map[string]string, name string) (string, bool) {
+	cookies, ok := headers[cookieHeader]
+	if !ok {
+		return "", false
+	}
+
+	for _, cookie := range strings.Split(cookies, ";") {
+		kv := strings.SplitN(strings.TrimSpace(cookie), "=", 2)
+		if len(kv) == 2 && kv[0] == name {
+			return strings.Trim(kv[1], `"`), true
+		}
+	}
+
+	return "", false
 }
 
 type decoratorImpl struct {
 	Operation string
 }
 
-func (di *decoratorImpl) apply(span types.Span) {
+func (di *decoratorImpl) Apply(span types.Span) {
 	if di.Operation != "" {
 		span.SetOperation(di.Operation)
 	}
 }
 
-func (di *decoratorImpl) getOperation() string {
+func (di *decoratorImpl) GetOperation() string {
 	return di.Operation
 }
 
-type rateLimitPolicyImpl struct {
-	rateLimitEntries []types.RateLimitPolicyEntry
-	maxStageNumber   uint64
-}
+type retryPolicyImpl struct {
+	retryOn              bool
+	retryTimeout         time.Duration
+	numRetries           uint32
+	retryConditions      types.RetryCondition
+	retriableStatusCodes []int
+	baseInterval         time.Duration
+	maxInterval          time.Duration
+	retryBudgetPercent   uint32
+	avoidPreviousHosts   bool
+}
+
+// NewRetryPolicyImpl creates the retry policy, the retry is off if the config is nil
+func NewRetryPolicyImpl(config *v2.RetryPolicy) types.RetryPolicy {
+	if config == nil {
+		return &retryPolicyImpl{}
+	}
 
-func (rp *rateLimitPolicyImpl) Enabled() bool {
+	p := &retryPolicyImpl{
+		retryOn:            config.RetryOn,
+		retryTimeout:       config.RetryTimeout,
+		numRetries:         config.NumRetries,
+		baseInterval:       config.BackOffBaseInterval,
+		maxInterval:        config.BackOffMaxInterval,
+		retryBudgetPercent: config.RetryBudgetPercent,
+		avoidPreviousHosts: config.AvoidPreviousHosts,
+	}
 
-	return true
-}
+	for _, name := range config.RetryConditions {
+		if condition, ok := types.RetryConditionNames[name]; ok {
+			p.retryConditions |= condition
+		} else {
+			log.DefaultLogger.Warnf("retry condition %s is not supported", name)
+		}
+	}
+	if p.retryConditions == 0 {
+		p.retryConditions = types.RetryOn5xx
+	}
 
-func (rp *rateLimitPolicyImpl) GetApplicableRateLimit(stage string) []types.RateLimitPolicyEntry {
+	for _, code := range config.RetriableStatusCodes {
+		p.retriableStatusCodes = append(p.retriableStatusCodes, int(code))
+	}
 
-	return rp.rateLimitEntries
-}
+	if p.baseInterval <= 0 {
+		p.baseInterval = defaultRetryBaseInterval
+	}
+	if p.maxInterval <= 0 {
+		p.maxInterval = 10 * p.baseInterval
+	}
+	if p.maxInterval < p.baseInterval {
+		p.maxInterval = p.baseInterval
+	}
 
-type retryPolicyImpl struct {
-	retryOn      bool
-	retryTimeout time.Duration
-	numRetries   uint32
+	return p
 }
 
 func (p *retryPolicyImpl) RetryOn() bool {
@@ -127,75 +400,159 @@ func (p *retryPolicyImpl) NumRetries() uint32 {
 	return p.numRetries
 }
 
-// todo implement CorsPolicy
+func (p *retryPolicyImpl) RetryConditions() types.RetryCondition {
+	return p.retryConditions
+}
 
-type runtimeData struct {
-	key          string
-	defaultvalue uint64
+func (p *retryPolicyImpl) RetriableStatusCodes() []int {
+	return p.retriableStatusCodes
 }
 
-type rateLimitPolicyEntryImpl struct {
-	stage      uint64
-	disableKey string
-	actions    rateLimitAction
+func (p *retryPolicyImpl) BackOff() (time.Duration, time.Duration) {
+	return p.baseInterval, p.maxInterval
 }
 
-func (rpei *rateLimitPolicyEntryImpl) Stage() uint64 {
-	return rpei.stage
+func (p *retryPolicyImpl) RetryBudgetPercent() uint32 {
+	return p.retryBudgetPercent
 }
 
-func (rpei *rateLimitPolicyEntryImpl) DisableKey() string {
-	return rpei.disableKey
+func (p *retryPolicyImpl) AvoidPreviousHosts() bool {
+	return p.avoidPreviousHosts
 }
 
-func (rpei *rateLimitPolicyEntryImpl) PopulateDescriptors(route types.RouteRule, descriptors []types.Descriptor, localSrvCluster string,
-	headers map[string]string, remoteAddr string) {
+// types.CorsPolicy
+type corsPolicyImpl struct {
+	allowOrigins     []string
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	maxAge           string
+	allowCredentials bool
+	enabled          bool
 }
 
-type rateLimitAction interface{}
+func newCorsPolicyImpl(corsPolicy *v2.CorsPolicy) *corsPolicyImpl {
+	if corsPolicy == nil {
+		return nil
+	}
+
+	return &corsPolicyImpl{
+		allowOrigins:     corsPolicy.AllowOrigin,
+		allowMethods:     corsPolicy.AllowMethods,
+		allowHeaders:     corsPolicy.AllowHeaders,
+		exposeHeaders:    corsPolicy.ExposeHeaders,
+		maxAge:           corsPolicy.MaxAge,
+		allowCredentials: corsPolicy.AllowCredentials,
+		enabled:          corsPolicy.Enabled,
+	}
+}
+
+func (cpi *corsPolicyImpl) AllowOrigins() []string {
+	return cpi.allowOrigins
+}
+
+func (cpi *corsPolicyImpl) AllowMethods() string {
+	return cpi.allowMethods
+}
+
+func (cpi *corsPolicyImpl) AllowHeaders() string {
+	return cpi.allowHeaders
+}
+
+func (cpi *corsPolicyImpl) ExposeHeaders() string {
+	return cpi.exposeHeaders
+}
+
+func (cpi *corsPolicyImpl) MaxAga() string {
+	return cpi.maxAge
+}
+
+func (cpi *corsPolicyImpl) AllowCredentials() bool {
+	return cpi.allowCredentials
+}
+
+func (cpi *corsPolicyImpl) Enabled() bool {
+	return cpi.enabled
+}
+
+type runtimeData struct {
+	key          string
+	defaultvalue uint64
+}
 
 type weightedClusterEntry struct {
 	clusterName                  string
 	runtimeKey                   string
-	loader                       types.Loader
 	clusterWeight                uint32
 	clusterMetadataMatchCriteria *MetadataMatchCriteriaImpl
 }
 
+// weight returns the runtime weight if the runtime key is configured, or the configured weight
+func (wc *weightedClusterEntry) weight(snapshot types.RuntimeSnapshot) uint64 {
+	if wc.runtimeKey == "" {
+		return uint64(wc.clusterWeight)
+	}
+
+	return snapshot.GetInteger(wc.runtimeKey, uint64(wc.clusterWeight))
+}
+
 func (wc *weightedClusterEntry) GetClusterMetadataMatchCriteria() *MetadataMatchCriteriaImpl {
 	return wc.clusterMetadataMatchCriteria
 }
 
 type routerPolicy struct {
-	retryOn      bool
-	retryTimeout time.Duration
-	numRetries   uint32
+	retryPolicy  types.RetryPolicy
+	hashPolicy   *hashPolicyImpl
+	shadowPolicy *shadowPolicyImpl
+	rateLimit    *rateLimitPolicyImpl
+	hedgePolicy  *hedgePolicyImpl
+	corsPolicy   *corsPolicyImpl
 }
 
-func (p *routerPolicy) RetryOn() bool {
-	return p.retryOn
+func (p *routerPolicy) RetryPolicy() types.RetryPolicy {
+	return p.retryPolicy
 }
 
-func (p *routerPolicy) TryTimeout() time.Duration {
-	return p.retryTimeout
-}
+func (p *routerPolicy) ShadowPolicy() types.ShadowPolicy {
+	if p.shadowPolicy == nil {
+		return nil
+	}
 
-func (p *routerPolicy) NumRetries() uint32 {
-	return p.numRetries
+	return p.shadowPolicy
 }
 
-func (p *routerPolicy) RetryPolicy() types.RetryPolicy {
-	return p
-}
+func (p *routerPolicy) HedgePolicy() types.HedgePolicy {
+	if p.hedgePolicy == nil {
+		return nil
+	}
 
-func (p *routerPolicy) ShadowPolicy() types.ShadowPolicy {
-	return nil
+	return p.hedgePolicy
 }
 
 func (p *routerPolicy) CorsPolicy() types.CorsPolicy {
-	return nil
+	if p.corsPolicy == nil {
+		return nil
+	}
+
+	return p.corsPolicy
 }
 
 func (p *routerPolicy) LoadBalancerPolicy() types.LoadBalancerPolicy {
-	return nil
+	if p.hashPolicy == nil {
+		return nil
+	}
+
+	return p
+}
+
+func (p *routerPolicy) RateLimitPolicy() types.RateLimitPolicy {
+	if p.rateLimit == nil {
+		return nil
+	}
+
+	return p.rateLimit
+}
+
+func (p *routerPolicy) HashPolicy() types.HashPolicy {
+	return p.hashPolicy
 }
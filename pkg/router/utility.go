@@ -37,8 +37,8 @@ func getClusterMosnLBMetaDataMap(metadata v2.Metadata) types.RouteMetaData {
 	return metadataMap
 }
 
-// Note
-// "runtimeKey" and "loader" are not used currently
+// getWeightedClusterEntry returns the weighted clusters and the configured total weight,
+// the weight of a cluster is read from the runtime key "runtime_key_prefix.cluster_name" if the prefix is configured
 func getWeightedClusterEntry(weightedClusters []v2.WeightedCluster) (map[string]weightedClusterEntry, uint32) {
 	var weightedClusterEntries = make(map[string]weightedClusterEntry)
 	var totalWeight uint32 = 0
@@ -46,8 +46,14 @@ func getWeightedClusterEntry(weightedClusters []v2.WeightedCluster) (map[string]
 		subsetLBMetaData := weightedCluster.Cluster.MetadataMatch
 		totalWeight = totalWeight + weightedCluster.Cluster.Weight
 
+		var runtimeKey string
+		if weightedCluster.RuntimeKeyPrefix != "" {
+			runtimeKey = weightedCluster.RuntimeKeyPrefix + "." + weightedCluster.Cluster.Name
+		}
+
 		weightedClusterEntries[weightedCluster.Cluster.Name] = weightedClusterEntry{
 			clusterName:                  weightedCluster.Cluster.Name,
+			runtimeKey:                   runtimeKey,
 			clusterWeight:                weightedCluster.Cluster.Weight,
 			clusterMetadataMatchCriteria: NewMetadataMatchCriteriaImpl(subsetLBMetaData),
 		}
@@ -56,10 +62,11 @@ func getWeightedClusterEntry(weightedClusters []v2.WeightedCluster) (map[string]
 	return weightedClusterEntries, totalWeight
 }
 
-func getRouterHeades(heades []v2.HeaderMatcher) []*types.HeaderData {
+// GetRouterHeaders creates the header datas matched by ConfigUtilityInst.MatchHeaders
+func GetRouterHeaders(headers []v2.HeaderMatcher) []*types.HeaderData {
 	var headerDatas []*types.HeaderData
 
-	for _, header := range heades {
+	for _, header := range headers {
 		headerData := &types.HeaderData{
 			Name: &lowerCaseString{
 				header.Name,
@@ -69,10 +76,10 @@ func getRouterHeades(heades []v2.HeaderMatcher) []*types.HeaderData {
 		}
 
 		if header.Regex {
-			if pattern, err := regexp.Compile(header.Name); err != nil {
+			if pattern, err := regexp.Compile(header.Value); err == nil {
 				headerData.RegexPattern = pattern
 			} else {
-				log.DefaultLogger.Errorf("getRouterHeades compile error")
+				log.DefaultLogger.Errorf("GetRouterHeaders compile error: %v", err)
 				continue
 			}
 		}
@@ -83,3 +90,29 @@ func getRouterHeades(heades []v2.HeaderMatcher) []*types.HeaderData {
 
 	return headerDatas
 }
+
+// getRouterQueryParameters creates the query parameter matchers matched by ConfigUtilityInst.MatchQueryParams
+func getRouterQueryParameters(queryParameters []v2.QueryParameterMatcher) []types.QueryParameterMatcher {
+	var matchers []types.QueryParameterMatcher
+
+	for _, queryParameter := range queryParameters {
+		matcher := &queryParameterMatcher{
+			name:    queryParameter.Name,
+			value:   queryParameter.Value,
+			isRegex: queryParameter.Regex,
+		}
+
+		if queryParameter.Regex {
+			if pattern, err := regexp.Compile(queryParameter.Value); err == nil {
+				matcher.regexPattern = *pattern
+			} else {
+				log.DefaultLogger.Errorf("getRouterQueryParameters compile error: %v", err)
+				continue
+			}
+		}
+
+		matchers = append(matchers, matcher)
+	}
+
+	return matchers
+}
@@ -94,3 +94,39 @@ func Test_getWeightedClusterEntryAndVerify(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRouterHeaders(t *testing.T) {
+	headers := GetRouterHeaders([]v2.HeaderMatcher{
+		{Name: "x-fault", Value: "true"},
+	})
+	if len(headers) != 1 {
+		t.Fatalf("expected 1 header data, got %d", len(headers))
+	}
+
+	if !ConfigUtilityInst.MatchHeaders(map[string]string{"x-fault": "true"}, headers) {
+		t.Error("expected headers matched")
+	}
+	if ConfigUtilityInst.MatchHeaders(map[string]string{"x-fault": "false"}, headers) {
+		t.Error("expected headers not matched")
+	}
+}
+
+// the regex header matcher matches the header value with the pattern in Value,
+// an invalid pattern drops the matcher
+func TestGetRouterHeadersRegex(t *testing.T) {
+	headers := GetRouterHeaders([]v2.HeaderMatcher{
+		{Name: "x-fault", Value: "true"},
+		{Name: "caller", Value: "app-[0-9]+", Regex: true},
+		{Name: "invalid", Value: "app-[0-9", Regex: true},
+	})
+	if len(headers) != 2 {
+		t.Fatalf("expected 2 header datas, got %d", len(headers))
+	}
+
+	if !ConfigUtilityInst.MatchHeaders(map[string]string{"x-fault": "true", "caller": "app-1"}, headers) {
+		t.Error("expected headers matched")
+	}
+	if ConfigUtilityInst.MatchHeaders(map[string]string{"x-fault": "true", "caller": "app-x"}, headers) {
+		t.Error("expected regex header not matched")
+	}
+}
@@ -28,7 +28,13 @@ import (
 )
 
 func NewVirtualHostImpl(virtualHost *v2.VirtualHost, validateClusters bool) (*VirtualHostImpl, error) {
-	var virtualHostImpl = &VirtualHostImpl{virtualHostName: virtualHost.Name}
+	var virtualHostImpl = &VirtualHostImpl{
+		virtualHostName:       virtualHost.Name,
+		rateLimitPolicy:       newRateLimitPolicyImpl(virtualHost.RateLimits),
+		corsPolicy:            newCorsPolicyImpl(virtualHost.Cors),
+		requestHeadersParser:  newHeaderParser(virtualHost.RequestHeadersToAdd, virtualHost.RequestHeadersToRemove),
+		responseHeadersParser: newHeaderParser(virtualHost.ResponseHeadersToAdd, virtualHost.ResponseHeadersToRemove),
+	}
 
 	switch virtualHost.RequireTLS {
 	case "EXTERNALONLY":
@@ -111,7 +117,8 @@ type VirtualHostImpl struct {
 	routes                []RouteBase //route impl
 	virtualClusters       []VirtualClusterEntry
 	sslRequirements       types.SslRequirements
-	corsPolicy            types.CorsPolicy
+	corsPolicy            *corsPolicyImpl
+	rateLimitPolicy       *rateLimitPolicyImpl
 	globalRouteConfig     *configImpl
 	requestHeadersParser  *headerParser
 	responseHeadersParser *headerParser
@@ -123,13 +130,19 @@ func (vh *VirtualHostImpl) Name() string {
 }
 
 func (vh *VirtualHostImpl) CorsPolicy() types.CorsPolicy {
+	if vh.corsPolicy == nil {
+		return nil
+	}
 
-	return nil
+	return vh.corsPolicy
 }
 
 func (vh *VirtualHostImpl) RateLimitPolicy() types.RateLimitPolicy {
+	if vh.rateLimitPolicy == nil {
+		return nil
+	}
 
-	return nil
+	return vh.rateLimitPolicy
 }
 
 func (vh *VirtualHostImpl) GetRouteFromEntries(headers map[string]string, randomValue uint64) types.Route {
@@ -0,0 +1,236 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package runtime provides the runtime values, which are used to change the
+// percentages and toggles without pushing new config, for example shifting
+// traffic between the weighted clusters.
+//
+// The values are merged from layers, a later layer overrides the former ones:
+//   - disk: the files under root/subdirectory
+//   - override: the files under root/override_subdirectory
+//   - admin: the values set by the admin api
+//
+// The key of a disk value is the file path relative to the layer directory with
+// "/" replaced by ".", the value is the trimmed file content. For example, the file
+// root/subdirectory/fault/http/abort/abort_percent is loaded as fault.http.abort.abort_percent.
+// The root is usually a symlink, so a new version can be deployed by swapping the symlink
+// and reloading the runtime.
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// Layer names
+const (
+	DiskLayer     = "disk"
+	OverrideLayer = "override"
+	AdminLayer    = "admin"
+)
+
+var defaultLoader atomic.Value
+
+func init() {
+	defaultLoader.Store(newLoader("", "", ""))
+}
+
+// Init replaces the default loader with a loader reads the disk layers
+// under the root directory
+func Init(root, subdirectory, overrideSubdirectory string) error {
+	l := newLoader(root, subdirectory, overrideSubdirectory)
+	if err := l.Reload(); err != nil {
+		return err
+	}
+
+	defaultLoader.Store(l)
+	return nil
+}
+
+// DefaultLoader returns the loader used by mosn
+func DefaultLoader() types.Loader {
+	return defaultLoader.Load().(types.Loader)
+}
+
+// Snapshot returns the current snapshot of the default loader
+func Snapshot() types.RuntimeSnapshot {
+	return DefaultLoader().Snapshot()
+}
+
+// types.Loader
+type loader struct {
+	root                 string
+	subdirectory         string
+	overrideSubdirectory string
+
+	// mux serializes the layers updating, the snapshot is read without lock
+	mux         sync.Mutex
+	diskLayers  []types.RuntimeLayer
+	adminValues map[string]string
+	snapshot    atomic.Value
+}
+
+// NewLoader creates a loader reads the disk layers under the root directory,
+// there is no disk layer if root is empty
+func NewLoader(root, subdirectory, overrideSubdirectory string) (types.Loader, error) {
+	l := newLoader(root, subdirectory, overrideSubdirectory)
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func newLoader(root, subdirectory, overrideSubdirectory string) *loader {
+	l := &loader{
+		root:                 root,
+		subdirectory:         subdirectory,
+		overrideSubdirectory: overrideSubdirectory,
+		adminValues:          make(map[string]string),
+	}
+	l.snapshot.Store(newSnapshot(l.layers()))
+
+	return l
+}
+
+func (l *loader) Snapshot() types.RuntimeSnapshot {
+	return l.snapshot.Load().(*snapshot)
+}
+
+func (l *loader) MergeValues(values map[string]string) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	// copy on write, the admin layer may be read by the current snapshot
+	adminValues := make(map[string]string, len(l.adminValues)+len(values))
+	for k, v := range l.adminValues {
+		adminValues[k] = v
+	}
+
+	for k, v := range values {
+		if v == "" {
+			delete(adminValues, k)
+		} else {
+			adminValues[k] = v
+		}
+	}
+
+	l.adminValues = adminValues
+	l.snapshot.Store(newSnapshot(l.layers()))
+}
+
+// Reload reloads the disk layers, the current snapshot is kept if any layer fails to load
+func (l *loader) Reload() error {
+	if l.root == "" {
+		return nil
+	}
+
+	disk, err := loadLayer(DiskLayer, filepath.Join(l.root, l.subdirectory))
+	if err != nil {
+		return err
+	}
+
+	diskLayers := []types.RuntimeLayer{disk}
+
+	if l.overrideSubdirectory != "" {
+		override, err := loadLayer(OverrideLayer, filepath.Join(l.root, l.overrideSubdirectory))
+		if err != nil {
+			return err
+		}
+		diskLayers = append(diskLayers, override)
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	l.diskLayers = diskLayers
+	l.snapshot.Store(newSnapshot(l.layers()))
+
+	log.DefaultLogger.Infof("runtime reloaded from %s", l.root)
+	return nil
+}
+
+// layers should be called with the lock held
+func (l *loader) layers() []types.RuntimeLayer {
+	layers := make([]types.RuntimeLayer, 0, len(l.diskLayers)+1)
+	layers = append(layers, l.diskLayers...)
+
+	return append(layers, types.RuntimeLayer{
+		Name:   AdminLayer,
+		Values: l.adminValues,
+	})
+}
+
+// loadLayer loads the files under the directory, the hidden files are ignored.
+// A directory that does not exist is loaded as an empty layer.
+func loadLayer(name, dir string) (types.RuntimeLayer, error) {
+	layer := types.RuntimeLayer{
+		Name:   name,
+		Values: make(map[string]string),
+	}
+
+	// the directory is usually a symlink, which is not followed by filepath.Walk
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.DefaultLogger.Warnf("runtime layer %s directory does not exist: %v", name, err)
+			return layer, nil
+		}
+		return layer, err
+	}
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(info.Name(), ".") && path != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		key := strings.Replace(filepath.ToSlash(rel), "/", ".", -1)
+		layer.Values[key] = strings.TrimSpace(string(content))
+
+		return nil
+	})
+
+	return layer, err
+}
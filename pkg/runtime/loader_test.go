@@ -0,0 +1,135 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeRuntimeFile(t *testing.T, dir, path, value string) {
+	file := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, []byte(value), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoaderLayers(t *testing.T) {
+	root, err := ioutil.TempDir("", "mosn_runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	writeRuntimeFile(t, root, "v1/base/fault/http/abort/abort_percent", "10\n")
+	writeRuntimeFile(t, root, "v1/base/routing/canary", "20")
+	writeRuntimeFile(t, root, "v1/base/.hidden", "1")
+	writeRuntimeFile(t, root, "v1/override/routing/canary", "30")
+	if err := os.Symlink(filepath.Join(root, "v1"), filepath.Join(root, "current")); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewLoader(filepath.Join(root, "current"), "base", "override")
+	if err != nil {
+		t.Fatalf("create loader failed: %v", err)
+	}
+
+	snapshot := l.Snapshot()
+	if v := snapshot.GetInteger("fault.http.abort.abort_percent", 0); v != 10 {
+		t.Errorf("disk value expected 10, got %d", v)
+	}
+	if v := snapshot.GetInteger("routing.canary", 0); v != 30 {
+		t.Errorf("override value expected 30, got %d", v)
+	}
+	if _, ok := snapshot.Get(".hidden"); ok {
+		t.Error("hidden file should be ignored")
+	}
+	if layers := snapshot.Layers(); len(layers) != 3 || layers[0].Name != DiskLayer ||
+		layers[1].Name != OverrideLayer || layers[2].Name != AdminLayer {
+		t.Errorf("unexpected layers: %+v", layers)
+	}
+
+	// admin layer overrides the disk layers, an empty value removes the key
+	l.MergeValues(map[string]string{"routing.canary": "40", "routing.admin": "1"})
+	if v := l.Snapshot().GetInteger("routing.canary", 0); v != 40 {
+		t.Errorf("admin value expected 40, got %d", v)
+	}
+	l.MergeValues(map[string]string{"routing.canary": ""})
+	if v := l.Snapshot().GetInteger("routing.canary", 0); v != 30 {
+		t.Errorf("admin value removed, expected 30, got %d", v)
+	}
+
+	// the old snapshot is not affected by the updates
+	if v := snapshot.GetInteger("routing.canary", 0); v != 30 {
+		t.Errorf("old snapshot is changed, got %d", v)
+	}
+	if _, ok := snapshot.Get("routing.admin"); ok {
+		t.Error("old snapshot is changed by the admin layer")
+	}
+
+	// swap the symlink and reload, the admin layer is kept
+	writeRuntimeFile(t, root, "v2/base/routing/canary", "50")
+	if err := os.Remove(filepath.Join(root, "current")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "v2"), filepath.Join(root, "current")); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reload(); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+
+	snapshot = l.Snapshot()
+	if v := snapshot.GetInteger("routing.canary", 0); v != 50 {
+		t.Errorf("reloaded value expected 50, got %d", v)
+	}
+	if _, ok := snapshot.Get("fault.http.abort.abort_percent"); ok {
+		t.Error("value of the old version should be removed")
+	}
+	if v, _ := snapshot.Get("routing.admin"); v != "1" {
+		t.Errorf("admin value should be kept, got %s", v)
+	}
+}
+
+func TestSnapshotGetInteger(t *testing.T) {
+	l := newLoader("", "", "")
+	l.MergeValues(map[string]string{"integer": "20", "invalid": "abc"})
+	snapshot := l.Snapshot()
+
+	if v := snapshot.GetInteger("integer", 1); v != 20 {
+		t.Errorf("expected 20, got %d", v)
+	}
+	if v := snapshot.GetInteger("invalid", 1); v != 1 {
+		t.Errorf("invalid value should fallback to default, got %d", v)
+	}
+	if v := snapshot.GetInteger("absent", 1); v != 1 {
+		t.Errorf("absent value should fallback to default, got %d", v)
+	}
+
+	if !snapshot.FeatureEnabled("integer", 0, 119) || snapshot.FeatureEnabled("integer", 0, 120) {
+		t.Error("feature should be enabled for 20 percent")
+	}
+	if !snapshot.FeatureEnabled("absent", 100, 99) || snapshot.FeatureEnabled("absent", 0, 0) {
+		t.Error("feature should fallback to default percent")
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package runtime
+
+import (
+	"strconv"
+
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// snapshot is immutable once created, so it can be read without lock
+type snapshot struct {
+	layers []types.RuntimeLayer
+	values map[string]string
+}
+
+// newSnapshot merges the layers, the values in the later layers override the former ones
+func newSnapshot(layers []types.RuntimeLayer) *snapshot {
+	values := make(map[string]string)
+	for _, layer := range layers {
+		for k, v := range layer.Values {
+			values[k] = v
+		}
+	}
+
+	return &snapshot{
+		layers: layers,
+		values: values,
+	}
+}
+
+func (s *snapshot) Get(key string) (string, bool) {
+	value, ok := s.values[key]
+	return value, ok
+}
+
+func (s *snapshot) GetInteger(key string, defaultValue uint64) uint64 {
+	value, ok := s.values[key]
+	if !ok {
+		return defaultValue
+	}
+
+	integer, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return integer
+}
+
+func (s *snapshot) FeatureEnabled(key string, defaultValue uint64, randomValue uint64) bool {
+	return randomValue%100 < s.GetInteger(key, defaultValue)
+}
+
+func (s *snapshot) Layers() []types.RuntimeLayer {
+	return s.layers
+}
@@ -283,6 +283,15 @@ func (ch *connHandler) StopListeners(lctx context.Context, close bool) error {
 	return errGlobal
 }
 
+func (ch *connHandler) ListListeners(lctx context.Context) []types.Listener {
+	listeners := make([]types.Listener, 0, len(ch.listeners))
+
+	for _, l := range ch.listeners {
+		listeners = append(listeners, l.listener)
+	}
+	return listeners
+}
+
 func (ch *connHandler) ListListenersFD(lctx context.Context) []uintptr {
 	fds := make([]uintptr, len(ch.listeners))
 
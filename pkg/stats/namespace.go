@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"strings"
+
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// Tag keys parsed from the metrics namespace
+const (
+	TagListener = "listener"
+	TagCluster  = "cluster"
+	TagHost     = "host"
+)
+
+// Tag is a dimension of a metric parsed from its namespace, like the cluster name
+type Tag struct {
+	Key   string
+	Value string
+}
+
+var namespaceTags = []struct {
+	prefix string
+	key    string
+}{
+	{types.ListenerStatsPrefix, TagListener},
+	{types.ClusterStatsPrefix, TagCluster},
+	{types.HostStatsPrefix, TagHost},
+}
+
+// IsGaugeCounter returns true if the counter goes up and down, like active requests.
+// Exporters should report such counters as gauges.
+func IsGaugeCounter(name string) bool {
+	return strings.HasSuffix(name, "_active")
+}
+
+// ParseMetricsKey splits a dotted metrics key registered in go-metrics into a metric name and tags.
+// For example, "cluster.foo.upstream_request_total" is parsed into name "cluster.upstream_request_total"
+// and tag cluster=foo. Cluster names and host addresses may contain dots, so the tag value is everything
+// between the namespace prefix and the last dot. Keys in other namespaces are returned without tags.
+func ParseMetricsKey(key string) (string, []Tag) {
+	for _, nt := range namespaceTags {
+		if !strings.HasPrefix(key, nt.prefix) {
+			continue
+		}
+
+		rest := key[len(nt.prefix):]
+		if idx := strings.LastIndex(rest, "."); idx > 0 {
+			return nt.key + "." + rest[idx+1:], []Tag{{Key: nt.key, Value: rest[:idx]}}
+		}
+	}
+
+	// global stats are registered with an empty namespace
+	return strings.TrimPrefix(key, "."), nil
+}
@@ -0,0 +1,189 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package prometheus exposes the metrics registered in go-metrics in prometheus text format.
+//
+// Metrics keys are parsed by stats.ParseMetricsKey, the namespace components become labels:
+//
+//	cluster.foo.upstream_request_total -> mosn_cluster_upstream_request_total{cluster="foo"}
+//	host.10.0.0.1:80.upstream_request_total -> mosn_host_upstream_request_total{host="10.0.0.1:80"}
+//	listener.2045.downstream_request_total -> mosn_listener_downstream_request_total{listener="2045"}
+package prometheus
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/stats"
+	"github.com/rcrowley/go-metrics"
+)
+
+// ContentType is the content type of prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricPrefix is prepended to every exported metric name
+const MetricPrefix = "mosn_"
+
+// metric types in prometheus
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+	typeSummary = "summary"
+)
+
+var quantiles = []float64{0.5, 0.9, 0.99}
+
+type sample struct {
+	suffix string
+	labels string
+	value  string
+}
+
+type family struct {
+	name    string
+	typ     string
+	samples []sample
+}
+
+// Handler returns a http handler exports all metrics in the registry
+func Handler(registry metrics.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+
+		if err := WriteMetrics(w, registry); err != nil {
+			log.DefaultLogger.Errorf("export prometheus metrics failed: %v", err)
+		}
+	})
+}
+
+// WriteMetrics writes all metrics in the registry to w in prometheus text format
+func WriteMetrics(w io.Writer, registry metrics.Registry) error {
+	families := make(map[string]*family)
+
+	registry.Each(func(key string, i interface{}) {
+		name, tags := stats.ParseMetricsKey(key)
+		name = MetricPrefix + sanitizeName(name)
+		labels := formatLabels(tags)
+
+		switch metric := i.(type) {
+		case metrics.Counter:
+			typ := typeCounter
+			if stats.IsGaugeCounter(name) {
+				typ = typeGauge
+			}
+			getFamily(families, name, typ).add("", labels, formatInt(metric.Count()))
+
+		case metrics.Gauge:
+			getFamily(families, name, typeGauge).add("", labels, formatInt(metric.Value()))
+
+		case metrics.Histogram:
+			h := metric.Snapshot()
+			f := getFamily(families, name, typeSummary)
+			ps := h.Percentiles(quantiles)
+
+			for idx, q := range quantiles {
+				quantileTag := stats.Tag{Key: "quantile", Value: strconv.FormatFloat(q, 'g', -1, 64)}
+				f.add("", formatLabels(append(tags[:len(tags):len(tags)], quantileTag)), formatFloat(ps[idx]))
+			}
+			f.add("_sum", labels, formatInt(h.Sum()))
+			f.add("_count", labels, formatInt(h.Count()))
+		}
+	})
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+
+	for _, name := range names {
+		f := families[name]
+
+		sort.SliceStable(f.samples, func(i, j int) bool {
+			return f.samples[i].labels < f.samples[j].labels
+		})
+
+		bw.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+
+		for _, s := range f.samples {
+			bw.WriteString(f.name + s.suffix + s.labels + " " + s.value + "\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+func getFamily(families map[string]*family, name, typ string) *family {
+	f, ok := families[name]
+	if !ok {
+		f = &family{
+			name: name,
+			typ:  typ,
+		}
+		families[name] = f
+	}
+
+	return f
+}
+
+func (f *family) add(suffix, labels, value string) {
+	f.samples = append(f.samples, sample{
+		suffix: suffix,
+		labels: labels,
+		value:  value,
+	})
+}
+
+// sanitizeName replaces the characters not allowed in prometheus metric name with '_'
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatLabels(tags []stats.Tag) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	labels := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		labels = append(labels, tag.Key+`="`+labelValueEscaper.Replace(tag.Value)+`"`)
+	}
+
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func formatInt(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prometheus
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	metrics.GetOrRegisterCounter("cluster.outbound|9080||reviews.default.upstream_request_request_total", registry).Inc(5)
+	metrics.GetOrRegisterCounter("cluster.local.upstream_request_request_total", registry).Inc(2)
+	metrics.GetOrRegisterCounter("cluster.local.upstream_request_request_active", registry).Inc(1)
+	metrics.GetOrRegisterCounter("host.127.0.0.1:8080.upstream_request_request_total", registry).Inc(3)
+	metrics.GetOrRegisterGauge("listener.2045.downstream_bytes_read_current", registry).Update(64)
+	metrics.GetOrRegisterCounter(".downstream_connection_total", registry).Inc(1)
+	h := metrics.GetOrRegisterHistogram("listener.2045.downstream_request_time", registry, metrics.NewUniformSample(100))
+	h.Update(10)
+	h.Update(30)
+
+	buf := &bytes.Buffer{}
+	if err := WriteMetrics(buf, registry); err != nil {
+		t.Fatalf("write metrics failed: %v", err)
+	}
+	output := buf.String()
+
+	expected := []string{
+		"# TYPE mosn_cluster_upstream_request_request_total counter\n" +
+			`mosn_cluster_upstream_request_request_total{cluster="local"} 2` + "\n" +
+			`mosn_cluster_upstream_request_request_total{cluster="outbound|9080||reviews.default"} 5` + "\n",
+		"# TYPE mosn_cluster_upstream_request_request_active gauge\n",
+		`mosn_host_upstream_request_request_total{host="127.0.0.1:8080"} 3`,
+		"# TYPE mosn_listener_downstream_bytes_read_current gauge\n" +
+			`mosn_listener_downstream_bytes_read_current{listener="2045"} 64` + "\n",
+		"# TYPE mosn_downstream_connection_total counter\nmosn_downstream_connection_total 1\n",
+		"# TYPE mosn_listener_downstream_request_time summary\n",
+		`mosn_listener_downstream_request_time{listener="2045",quantile="0.5"} 20`,
+		`mosn_listener_downstream_request_time_sum{listener="2045"} 40`,
+		`mosn_listener_downstream_request_time_count{listener="2045"} 2`,
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Errorf("expected %q in output:\n%s", e, output)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("cluster.c1.upstream_connection_total", registry).Inc(1)
+
+	w := httptest.NewRecorder()
+	Handler(registry).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), `mosn_cluster_upstream_connection_total{cluster="c1"} 1`) {
+		t.Errorf("unexpected response: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package stats
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/rcrowley/go-metrics"
+)
+
+// DefaultFlushInterval is the default interval of flushing metrics to sinks
+const DefaultFlushInterval = 5 * time.Second
+
+// Sink is a destination which metrics are pushed to
+type Sink interface {
+	// Flush pushes a snapshot of all metrics in the registry to the sink
+	Flush(registry metrics.Registry)
+
+	// Close releases the resources used by the sink
+	Close() error
+}
+
+// SinkCreator creates a Sink according to config
+type SinkCreator func(config map[string]interface{}) (Sink, error)
+
+var creatorSink = make(map[string]SinkCreator)
+
+// RegisterSink registers the sinkType as SinkCreator
+func RegisterSink(sinkType string, creator SinkCreator) {
+	creatorSink[sinkType] = creator
+}
+
+// CreateSink creates a Sink according to sinkType
+func CreateSink(sinkType string, config map[string]interface{}) (Sink, error) {
+	if creator, ok := creatorSink[sinkType]; ok {
+		sink, err := creator(config)
+		if err != nil {
+			return nil, fmt.Errorf("create stats sink failed: %v", err)
+		}
+		return sink, nil
+	}
+	return nil, fmt.Errorf("unsupported stats sink type: %v", sinkType)
+}
+
+// Flusher flushes the metrics in the registry to sinks every interval
+type Flusher struct {
+	registry metrics.Registry
+	interval time.Duration
+	sinks    []Sink
+	stopChan chan struct{}
+	once     sync.Once
+}
+
+// NewFlusher creates a Flusher, DefaultFlushInterval is used if interval is not positive
+func NewFlusher(registry metrics.Registry, interval time.Duration, sinks []Sink) *Flusher {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	return &Flusher{
+		registry: registry,
+		interval: interval,
+		sinks:    sinks,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start starts flushing in a new goroutine, it does nothing if there is no sink
+func (f *Flusher) Start() {
+	if len(f.sinks) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				f.Flush()
+			case <-f.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// Flush flushes the metrics to all sinks immediately
+func (f *Flusher) Flush() {
+	for _, sink := range f.sinks {
+		sink.Flush(f.registry)
+	}
+}
+
+// Stop stops flushing and closes all sinks
+func (f *Flusher) Stop() {
+	f.once.Do(func() {
+		close(f.stopChan)
+
+		for _, sink := range f.sinks {
+			if err := sink.Close(); err != nil {
+				log.DefaultLogger.Errorf("close stats sink failed: %v", err)
+			}
+		}
+	})
+}
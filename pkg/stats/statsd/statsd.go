@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package statsd implements a stats sink which pushes metrics to statsd or DogStatsD over udp.
+//
+// Counters are sent as deltas since last flush, gauges are sent as they are,
+// histograms are sent as gauges of their mean, max and percentiles.
+// With DogStatsD enabled, the namespace components become tags:
+//
+//	cluster.foo.upstream_request_total -> cluster.upstream_request_total:1|c|#cluster:foo
+package statsd
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/config"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/stats"
+	"github.com/rcrowley/go-metrics"
+)
+
+func init() {
+	stats.RegisterSink(v2.STATSD_SINK, CreateStatsdSink)
+}
+
+// maxPacketSize keeps a udp packet in a single ethernet frame
+const maxPacketSize = 1432
+
+// statsd metric types
+const (
+	typeCounter = "c"
+	typeGauge   = "g"
+)
+
+var histogramPercentiles = []float64{0.5, 0.99}
+var histogramPercentileNames = []string{"p50", "p99"}
+
+type statsdSink struct {
+	config *v2.StatsdSink
+	conn   net.Conn
+	buf    bytes.Buffer
+	// last flushed value of counters, statsd counters are deltas
+	counters map[string]int64
+}
+
+// CreateStatsdSink creates a statsd sink according to config
+func CreateStatsdSink(conf map[string]interface{}) (stats.Sink, error) {
+	sinkConfig, err := config.ParseStatsdSink(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStatsdSink(sinkConfig)
+}
+
+// NewStatsdSink creates a statsd sink pushes metrics to config.Address
+func NewStatsdSink(config *v2.StatsdSink) (stats.Sink, error) {
+	conn, err := net.Dial("udp", config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &statsdSink{
+		config:   config,
+		conn:     conn,
+		counters: make(map[string]int64),
+	}, nil
+}
+
+// Flush is called by a single stats.Flusher goroutine
+func (s *statsdSink) Flush(registry metrics.Registry) {
+	registry.Each(func(key string, i interface{}) {
+		name, tags := s.metricName(key)
+
+		switch metric := i.(type) {
+		case metrics.Counter:
+			count := metric.Count()
+
+			if stats.IsGaugeCounter(name) {
+				s.write(name, strconv.FormatInt(count, 10), typeGauge, tags)
+				return
+			}
+
+			delta := count - s.counters[key]
+			s.counters[key] = count
+			if delta != 0 {
+				s.write(name, strconv.FormatInt(delta, 10), typeCounter, tags)
+			}
+
+		case metrics.Gauge:
+			s.write(name, strconv.FormatInt(metric.Value(), 10), typeGauge, tags)
+
+		case metrics.Histogram:
+			h := metric.Snapshot()
+			if h.Count() == 0 {
+				return
+			}
+
+			s.write(name+".mean", formatFloat(h.Mean()), typeGauge, tags)
+			s.write(name+".max", strconv.FormatInt(h.Max(), 10), typeGauge, tags)
+
+			for idx, p := range h.Percentiles(histogramPercentiles) {
+				s.write(name+"."+histogramPercentileNames[idx], formatFloat(p), typeGauge, tags)
+			}
+		}
+	})
+
+	s.send()
+}
+
+func (s *statsdSink) Close() error {
+	return s.conn.Close()
+}
+
+// metricName returns the statsd metric name and the tags of the metrics key,
+// tags are kept in the name if DogStatsD is not enabled
+func (s *statsdSink) metricName(key string) (string, []stats.Tag) {
+	var name string
+	var tags []stats.Tag
+
+	if s.config.DogStatsD {
+		name, tags = stats.ParseMetricsKey(key)
+	} else {
+		name = strings.TrimPrefix(key, ".")
+	}
+
+	if s.config.Prefix != "" {
+		name = s.config.Prefix + "." + name
+	}
+
+	return name, tags
+}
+
+// write appends a line of "name:value|type|#tag:value" into the packet buffer
+func (s *statsdSink) write(name, value, metricType string, tags []stats.Tag) {
+	line := sanitize(name) + ":" + value + "|" + metricType
+
+	if len(tags) > 0 {
+		tagStrings := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			tagStrings = append(tagStrings, sanitize(tag.Key)+":"+sanitizeTagValue(tag.Value))
+		}
+		line += "|#" + strings.Join(tagStrings, ",")
+	}
+
+	if s.buf.Len() > 0 && s.buf.Len()+len(line)+1 > maxPacketSize {
+		s.send()
+	}
+
+	if s.buf.Len() > 0 {
+		s.buf.WriteByte('\n')
+	}
+	s.buf.WriteString(line)
+}
+
+func (s *statsdSink) send() {
+	if s.buf.Len() == 0 {
+		return
+	}
+
+	if _, err := s.conn.Write(s.buf.Bytes()); err != nil {
+		log.DefaultLogger.Warnf("statsd sink send metrics to %s failed: %v", s.config.Address, err)
+	}
+
+	s.buf.Reset()
+}
+
+// sanitize replaces the characters reserved by statsd protocol
+var sanitizer = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", ",", "_", " ", "_", "\n", "_")
+
+func sanitize(s string) string {
+	return sanitizer.Replace(s)
+}
+
+// tag values may contain ':', like host address, only the tag separators are replaced
+var tagValueSanitizer = strings.NewReplacer("|", "_", "@", "_", "#", "_", ",", "_", " ", "_", "\n", "_")
+
+func sanitizeTagValue(s string) string {
+	return tagValueSanitizer.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,166 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package statsd
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/stats"
+	"github.com/rcrowley/go-metrics"
+)
+
+func listen(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func readLines(t *testing.T, conn net.PacketConn) []string {
+	var lines []string
+	buf := make([]byte, 65536)
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		if n > maxPacketSize {
+			t.Errorf("packet size %d exceeds %d", n, maxPacketSize)
+		}
+		lines = append(lines, strings.Split(string(buf[:n]), "\n")...)
+	}
+
+	sort.Strings(lines)
+	return lines
+}
+
+func expectLines(t *testing.T, got, expected []string) {
+	sort.Strings(expected)
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected lines\ngot:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestStatsdSink(t *testing.T) {
+	server := listen(t)
+	defer server.Close()
+
+	sink, err := CreateStatsdSink(map[string]interface{}{
+		"address": server.LocalAddr().String(),
+		"prefix":  "mosn",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	registry := metrics.NewRegistry()
+	total := metrics.GetOrRegisterCounter("cluster.foo.upstream_request_total", registry)
+	active := metrics.GetOrRegisterCounter("cluster.foo.upstream_request_active", registry)
+	gauge := metrics.GetOrRegisterGauge("listener.bar.connections", registry)
+	total.Inc(3)
+	active.Inc(2)
+	gauge.Update(5)
+
+	sink.Flush(registry)
+	expectLines(t, readLines(t, server), []string{
+		"mosn.cluster.foo.upstream_request_total:3|c",
+		"mosn.cluster.foo.upstream_request_active:2|g",
+		"mosn.listener.bar.connections:5|g",
+	})
+
+	// counters are sent as deltas, unchanged counters are skipped
+	total.Inc(1)
+	active.Dec(1)
+	sink.Flush(registry)
+	expectLines(t, readLines(t, server), []string{
+		"mosn.cluster.foo.upstream_request_total:1|c",
+		"mosn.cluster.foo.upstream_request_active:1|g",
+		"mosn.listener.bar.connections:5|g",
+	})
+}
+
+func TestDogStatsdSink(t *testing.T) {
+	server := listen(t)
+	defer server.Close()
+
+	sink, err := NewStatsdSink(&v2.StatsdSink{
+		Address:   server.LocalAddr().String(),
+		DogStatsD: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	registry := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("host.127.0.0.1:8080.upstream_request_total", registry).Inc(1)
+	histogram := metrics.GetOrRegisterHistogram("cluster.foo.request_time", registry, metrics.NewUniformSample(100))
+	for i := int64(1); i <= 4; i++ {
+		histogram.Update(i)
+	}
+
+	sink.Flush(registry)
+	expectLines(t, readLines(t, server), []string{
+		"host.upstream_request_total:1|c|#host:127.0.0.1:8080",
+		"cluster.request_time.mean:2.5|g|#cluster:foo",
+		"cluster.request_time.max:4|g|#cluster:foo",
+		"cluster.request_time.p50:2.5|g|#cluster:foo",
+		"cluster.request_time.p99:4|g|#cluster:foo",
+	})
+}
+
+func TestStatsdSinkPacketSize(t *testing.T) {
+	server := listen(t)
+	defer server.Close()
+
+	sink, err := NewStatsdSink(&v2.StatsdSink{Address: server.LocalAddr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := metrics.NewRegistry()
+	for i := 0; i < 200; i++ {
+		metrics.GetOrRegisterGauge("listener.bar.gauge_"+strings.Repeat("x", i%10)+string(rune('a'+i%26))+string(rune('a'+i/26)), registry).Update(int64(i))
+	}
+
+	flusher := stats.NewFlusher(registry, time.Second, []stats.Sink{sink})
+	flusher.Flush()
+	flusher.Stop()
+
+	if lines := readLines(t, server); len(lines) != 200 {
+		t.Errorf("expected 200 lines, got %d", len(lines))
+	}
+}
+
+func TestCreateStatsdSinkInvalidConfig(t *testing.T) {
+	if _, err := stats.CreateSink(v2.STATSD_SINK, map[string]interface{}{}); err == nil {
+		t.Error("expected error for missing address")
+	}
+	if _, err := stats.CreateSink("unknown", nil); err == nil {
+		t.Error("expected error for unknown sink type")
+	}
+}
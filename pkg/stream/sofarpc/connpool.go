@@ -74,8 +74,11 @@ func (p *connPool) NewStream(context context.Context, streamID string,
 	if !p.host.ClusterInfo().ResourceManager().Requests().CanCreate() {
 		cb.OnFailure(streamID, types.Overflow, nil)
 	} else {
-		// todo: update host stats
 		atomic.AddUint64(&activeClient.totalStream, 1)
+		p.host.HostStats().UpstreamRequestTotal.Inc(1)
+		p.host.HostStats().UpstreamRequestActive.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestTotal.Inc(1)
+		p.host.ClusterInfo().Stats().UpstreamRequestActive.Inc(1)
 		p.host.ClusterInfo().ResourceManager().Requests().Increase()
 		streamEncoder := activeClient.codecClient.NewStream(context, streamID, responseDecoder)
 		cb.OnReady(streamID, streamEncoder, p.host)
@@ -101,7 +104,8 @@ func (p *connPool) onConnectionEvent(client *activeClient, event types.Connectio
 }
 
 func (p *connPool) onStreamDestroy(client *activeClient) {
-	// todo: update host stats
+	p.host.HostStats().UpstreamRequestActive.Dec(1)
+	p.host.ClusterInfo().Stats().UpstreamRequestActive.Dec(1)
 	p.host.ClusterInfo().ResourceManager().Requests().Decrease()
 }
 
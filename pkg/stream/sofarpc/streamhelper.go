@@ -41,24 +41,34 @@ func (s *stream) encodeSterilize(headers interface{}) interface{} {
 			delete(headerMaps, types.HeaderStatus)
 			statusCode, _ := strconv.Atoi(status)
 
+			rpcStatus, rpcStatusOk := headerMaps[types.HeaderRpcStatus]
+			delete(headerMaps, types.HeaderRpcStatus)
+
 			if statusCode != types.SuccessCode {
 				var err error
 				var respHeaders interface{}
 
 				//Build Router Unavailable Response Msg
-				switch statusCode {
-				case types.RouterUnavailableCode, types.NoHealthUpstreamCode, types.UpstreamOverFlowCode:
+				switch {
+				case rpcStatusOk:
+					//Response status set by the stream filters, e.g. fault inject
+					rpcStatusCode, _ := strconv.Atoi(rpcStatus)
+					respHeaders, err = sofarpc.BuildSofaRespMsg(s.context, headerMaps, int16(rpcStatusCode))
+				case statusCode == types.RouterUnavailableCode || statusCode == types.NoHealthUpstreamCode || statusCode == types.UpstreamOverFlowCode:
 					//No available path
 					respHeaders, err = sofarpc.BuildSofaRespMsg(s.context, headerMaps, sofarpc.RESPONSE_STATUS_CLIENT_SEND_ERROR)
-				case types.CodecExceptionCode:
+				case statusCode == types.CodecExceptionCode:
 					//Decode or Encode Error
 					respHeaders, err = sofarpc.BuildSofaRespMsg(s.context, headerMaps, sofarpc.RESPONSE_STATUS_CODEC_EXCEPTION)
-				case types.DeserialExceptionCode:
+				case statusCode == types.DeserialExceptionCode:
 					//Hessian Exception
 					respHeaders, err = sofarpc.BuildSofaRespMsg(s.context, headerMaps, sofarpc.RESPONSE_STATUS_SERVER_DESERIAL_EXCEPTION)
-				case types.TimeoutExceptionCode:
+				case statusCode == types.TimeoutExceptionCode:
 					//Response Timeout
 					respHeaders, err = sofarpc.BuildSofaRespMsg(s.context, headerMaps, sofarpc.RESPONSE_STATUS_TIMEOUT)
+				case statusCode == types.RateLimitedCode:
+					//Rejected by rate limiting
+					respHeaders, err = sofarpc.BuildSofaRespMsg(s.context, headerMaps, sofarpc.RESPONSE_STATUS_SERVER_THREADPOOL_BUSY)
 				default:
 					respHeaders, err = sofarpc.BuildSofaRespMsg(s.context, headerMaps, sofarpc.RESPONSE_STATUS_UNKNOWN)
 				}
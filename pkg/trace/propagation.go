@@ -0,0 +1,262 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"strings"
+
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/protocol/sofarpc/models"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// B3 propagation headers, see https://github.com/openzipkin/b3-propagation
+// http headers are lower cased by mosn
+const (
+	B3TraceID      = "x-b3-traceid"
+	B3SpanID       = "x-b3-spanid"
+	B3ParentSpanID = "x-b3-parentspanid"
+	B3Sampled      = "x-b3-sampled"
+	B3Flags        = "x-b3-flags"
+	B3Single       = "b3"
+)
+
+// W3C trace context header, see https://www.w3.org/TR/trace-context/
+const (
+	W3CTraceParent = "traceparent"
+	w3cVersion     = "00"
+)
+
+type samplingState int
+
+const (
+	samplingDefer samplingState = iota
+	samplingAccept
+	samplingDeny
+)
+
+// spanContext is the span context propagated across processes
+type spanContext struct {
+	traceID  string
+	spanID   string
+	rpcID    string
+	sampling samplingState
+}
+
+// extract the span context from request headers in the format of protocol
+func extract(proto types.Protocol, headers map[string]string) (spanContext, bool) {
+	switch proto {
+	case protocol.HTTP1, protocol.HTTP2:
+		if sc, ok := extractW3C(headers); ok {
+			return sc, true
+		}
+		return extractB3(headers)
+	case protocol.SofaRPC:
+		return extractSofa(headers)
+	}
+
+	return spanContext{}, false
+}
+
+// inject the span context into request headers in the format of protocol
+func inject(proto types.Protocol, span *Span, headers map[string]string) {
+	switch proto {
+	case protocol.HTTP1, protocol.HTTP2:
+		injectB3(span, headers)
+		injectW3C(span, headers)
+	case protocol.SofaRPC:
+		injectSofa(span, headers)
+	}
+}
+
+func extractB3(headers map[string]string) (spanContext, bool) {
+	if single, ok := headers[B3Single]; ok {
+		return parseB3Single(single)
+	}
+
+	sc := spanContext{
+		traceID:  strings.ToLower(headers[B3TraceID]),
+		spanID:   strings.ToLower(headers[B3SpanID]),
+		sampling: parseB3Sampled(headers[B3Sampled]),
+	}
+
+	if headers[B3Flags] == "1" {
+		sc.sampling = samplingAccept
+	}
+
+	if !isValidID(sc.traceID, 16, 32) || !isValidID(sc.spanID, 16, 16) {
+		return spanContext{sampling: sc.sampling}, false
+	}
+
+	return sc, true
+}
+
+// parseB3Single parses b3: {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId},
+// the sampling state only form is also allowed
+func parseB3Single(value string) (spanContext, bool) {
+	parts := strings.Split(strings.ToLower(value), "-")
+
+	if len(parts) == 1 {
+		return spanContext{sampling: parseB3Sampled(parts[0])}, false
+	}
+
+	sc := spanContext{
+		traceID: parts[0],
+		spanID:  parts[1],
+	}
+
+	if len(parts) > 2 {
+		sc.sampling = parseB3Sampled(parts[2])
+	}
+
+	if !isValidID(sc.traceID, 16, 32) || !isValidID(sc.spanID, 16, 16) {
+		return spanContext{sampling: sc.sampling}, false
+	}
+
+	return sc, true
+}
+
+func parseB3Sampled(value string) samplingState {
+	switch value {
+	case "1", "true", "d":
+		return samplingAccept
+	case "0", "false":
+		return samplingDeny
+	}
+	return samplingDefer
+}
+
+func injectB3(span *Span, headers map[string]string) {
+	// the single header is replaced by the multiple headers
+	delete(headers, B3Single)
+	delete(headers, B3Flags)
+
+	headers[B3TraceID] = span.traceID
+	headers[B3SpanID] = span.spanID
+
+	if span.parentSpanID != "" {
+		headers[B3ParentSpanID] = span.parentSpanID
+	} else {
+		delete(headers, B3ParentSpanID)
+	}
+
+	if span.sampled {
+		headers[B3Sampled] = "1"
+	} else {
+		headers[B3Sampled] = "0"
+	}
+}
+
+// extractW3C parses traceparent: {version}-{trace-id}-{parent-id}-{trace-flags}
+func extractW3C(headers map[string]string) (spanContext, bool) {
+	value, ok := headers[W3CTraceParent]
+	if !ok {
+		return spanContext{}, false
+	}
+
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(value)), "-")
+	if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" || !isHex(parts[3], 2) {
+		return spanContext{}, false
+	}
+
+	// future versions may append fields, version 00 has exactly 4 fields
+	if parts[0] == w3cVersion && len(parts) != 4 {
+		return spanContext{}, false
+	}
+
+	if !isValidID(parts[1], 32, 32) || !isValidID(parts[2], 16, 16) {
+		return spanContext{}, false
+	}
+
+	sc := spanContext{
+		traceID:  parts[1],
+		spanID:   parts[2],
+		sampling: samplingDeny,
+	}
+
+	// the sampled flag is the least significant bit
+	if strings.IndexByte("13579bdf", parts[3][1]) >= 0 {
+		sc.sampling = samplingAccept
+	}
+
+	return sc, true
+}
+
+func injectW3C(span *Span, headers map[string]string) {
+	flags := "00"
+	if span.sampled {
+		flags = "01"
+	}
+
+	headers[W3CTraceParent] = w3cVersion + "-" + padID(span.traceID, 32) + "-" + span.spanID + "-" + flags
+}
+
+// extractSofa extracts the span context from the SOFA tracer headers in bolt,
+// SOFA tracer does not propagate the sampling decision
+func extractSofa(headers map[string]string) (spanContext, bool) {
+	traceID, ok := headers[models.TRACER_ID_KEY]
+	if !ok || traceID == "" {
+		return spanContext{}, false
+	}
+
+	return spanContext{
+		traceID: traceID,
+		rpcID:   headers[models.RPC_ID_KEY],
+	}, true
+}
+
+func injectSofa(span *Span, headers map[string]string) {
+	headers[models.TRACER_ID_KEY] = span.traceID
+	headers[models.RPC_ID_KEY] = span.rpcID
+}
+
+// isValidID returns true if id is a non-zero lower hex string, and its length is min or max
+func isValidID(id string, min, max int) bool {
+	if len(id) != min && len(id) != max {
+		return false
+	}
+
+	if !isHex(id, len(id)) {
+		return false
+	}
+
+	return strings.Trim(id, "0") != ""
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// padID left pads the hex id with zeros to length
+func padID(id string, length int) string {
+	if len(id) >= length {
+		return id
+	}
+	return strings.Repeat("0", length-len(id)) + id
+}
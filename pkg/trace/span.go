@@ -0,0 +1,144 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// SpanKind is the role of the span in a request
+type SpanKind string
+
+// Group of span kind
+const (
+	SpanKindServer SpanKind = "SERVER"
+	SpanKindClient SpanKind = "CLIENT"
+)
+
+// Span implements types.Span
+// A span is used by a single stream, the setters are not goroutine safe
+type Span struct {
+	tracer *Tracer
+
+	traceID      string
+	spanID       string
+	parentSpanID string
+	// rpcID is the hierarchical span id used by SOFA tracer, like 0.1.2
+	rpcID      string
+	childCount int
+	sampled    bool
+
+	kind      SpanKind
+	operation string
+	tags      map[string]string
+	startTime time.Time
+	duration  time.Duration
+	finished  uint32
+}
+
+func (s *Span) TraceID() string {
+	return s.traceID
+}
+
+func (s *Span) SpanID() string {
+	return s.spanID
+}
+
+func (s *Span) ParentSpanID() string {
+	return s.parentSpanID
+}
+
+func (s *Span) Sampled() bool {
+	return s.sampled
+}
+
+// Kind returns SpanKindServer or SpanKindClient
+func (s *Span) Kind() SpanKind {
+	return s.kind
+}
+
+// Operation returns the operation name of the span
+func (s *Span) Operation() string {
+	return s.operation
+}
+
+// Tags returns the tags of the span
+func (s *Span) Tags() map[string]string {
+	return s.tags
+}
+
+// Tag returns the tag value of key
+func (s *Span) Tag(key string) string {
+	return s.tags[key]
+}
+
+// StartTime returns the start time of the span
+func (s *Span) StartTime() time.Time {
+	return s.startTime
+}
+
+// Duration returns the duration of the span, valid after the span is finished
+func (s *Span) Duration() time.Duration {
+	return s.duration
+}
+
+func (s *Span) SetOperation(operation string) {
+	s.operation = operation
+}
+
+func (s *Span) SetTag(key string, value string) {
+	s.tags[key] = value
+}
+
+// FinishSpan can be called multiple times, only the first call takes effect
+func (s *Span) FinishSpan() {
+	if !atomic.CompareAndSwapUint32(&s.finished, 0, 1) {
+		return
+	}
+
+	s.duration = time.Since(s.startTime)
+
+	if s.sampled && s.tracer.reporter != nil {
+		s.tracer.reporter.Report(s)
+	}
+}
+
+func (s *Span) InjectContext(protocol types.Protocol, requestHeaders map[string]string) {
+	inject(protocol, s, requestHeaders)
+}
+
+func (s *Span) SpawnChild(operationName string, startTime time.Time) types.Span {
+	s.childCount++
+
+	return &Span{
+		tracer:       s.tracer,
+		traceID:      s.traceID,
+		spanID:       newSpanID(),
+		parentSpanID: s.spanID,
+		rpcID:        s.rpcID + "." + strconv.Itoa(s.childCount),
+		sampled:      s.sampled,
+		kind:         SpanKindClient,
+		operation:    operationName,
+		tags:         make(map[string]string),
+		startTime:    startTime,
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package trace implements distributed tracing of the requests proxied by mosn.
+//
+// A server span is started for each downstream request, and a client span is spawned
+// for each upstream request. The span context is extracted from and injected into
+// request headers in B3 and W3C trace context formats for http, and in SOFA tracer format
+// for SofaRPC. Sampled spans are reported by a Reporter, such as zipkin.
+package trace
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// Span tags set by the proxy
+const (
+	TagProtocol        = "protocol"
+	TagHTTPMethod      = "http.method"
+	TagHTTPPath        = "http.path"
+	TagStatusCode      = "http.status_code"
+	TagService         = "service"
+	TagPeerAddress     = "peer.address"
+	TagUpstreamCluster = "upstream_cluster"
+	TagError           = "error"
+)
+
+// Reporter reports the finished spans
+type Reporter interface {
+	// Report is called when a sampled span is finished, it should not block
+	Report(span *Span)
+
+	// Close flushes the reported spans and releases the resources used by the reporter
+	Close() error
+}
+
+// ReporterCreator creates a Reporter according to config
+type ReporterCreator func(config map[string]interface{}) (Reporter, error)
+
+var creatorReporter = make(map[string]ReporterCreator)
+
+// RegisterReporter registers the reporterType as ReporterCreator
+func RegisterReporter(reporterType string, creator ReporterCreator) {
+	creatorReporter[reporterType] = creator
+}
+
+// CreateReporter creates a Reporter according to reporterType
+func CreateReporter(reporterType string, config map[string]interface{}) (Reporter, error) {
+	if creator, ok := creatorReporter[reporterType]; ok {
+		reporter, err := creator(config)
+		if err != nil {
+			return nil, fmt.Errorf("create trace reporter failed: %v", err)
+		}
+		return reporter, nil
+	}
+	return nil, fmt.Errorf("unsupported trace reporter type: %v", reporterType)
+}
+
+type driverHolder struct {
+	driver types.Driver
+}
+
+var globalDriver atomic.Value
+
+// SetDriver sets the driver used by proxy, tracing is disabled if driver is nil
+func SetDriver(driver types.Driver) {
+	globalDriver.Store(driverHolder{driver})
+}
+
+// GetDriver returns the driver used by proxy, nil if tracing is disabled
+func GetDriver() types.Driver {
+	if holder, ok := globalDriver.Load().(driverHolder); ok {
+		return holder.driver
+	}
+	return nil
+}
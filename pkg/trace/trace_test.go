@@ -0,0 +1,222 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/protocol/sofarpc/models"
+)
+
+type mockReporter struct {
+	spans []*Span
+}
+
+func (r *mockReporter) Report(span *Span) {
+	r.spans = append(r.spans, span)
+}
+
+func (r *mockReporter) Close() error {
+	return nil
+}
+
+func TestStartNewTrace(t *testing.T) {
+	reporter := &mockReporter{}
+	tracer := NewTracer(reporter, NewSampler(1))
+
+	span := tracer.Start(protocol.HTTP1, map[string]string{}, "/foo", time.Now())
+	if len(span.TraceID()) != 32 || len(span.SpanID()) != 16 || span.ParentSpanID() != "" {
+		t.Errorf("unexpected span ids: %s %s %s", span.TraceID(), span.SpanID(), span.ParentSpanID())
+	}
+	if !span.Sampled() {
+		t.Error("span should be sampled")
+	}
+
+	child := span.SpawnChild("cluster", time.Now())
+	if child.TraceID() != span.TraceID() || child.ParentSpanID() != span.SpanID() {
+		t.Errorf("child span is not in the trace")
+	}
+
+	child.FinishSpan()
+	span.FinishSpan()
+	span.FinishSpan()
+
+	if len(reporter.spans) != 2 {
+		t.Fatalf("expected 2 spans reported, got %d", len(reporter.spans))
+	}
+	if reporter.spans[0].Kind() != SpanKindClient || reporter.spans[1].Kind() != SpanKindServer {
+		t.Errorf("unexpected span kinds")
+	}
+}
+
+func TestSampler(t *testing.T) {
+	reporter := &mockReporter{}
+	tracer := NewTracer(reporter, NewSampler(0))
+
+	span := tracer.Start(protocol.HTTP1, map[string]string{}, "/foo", time.Now())
+	span.FinishSpan()
+	if span.Sampled() || len(reporter.spans) != 0 {
+		t.Error("span should not be sampled")
+	}
+
+	// the upstream sampling decision is respected
+	span = tracer.Start(protocol.HTTP1, map[string]string{B3Sampled: "1"}, "/foo", time.Now())
+	if !span.Sampled() {
+		t.Error("span should be sampled by upstream decision")
+	}
+
+	sampler := NewSampler(0.5)
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		if sampler(newTraceID()) {
+			sampled++
+		}
+	}
+	if sampled < 400 || sampled > 600 {
+		t.Errorf("unexpected sampled count %d of 1000", sampled)
+	}
+
+	traceID := newTraceID()
+	if sampler(traceID) != sampler(traceID) {
+		t.Error("sampling decision should be consistent for a trace")
+	}
+}
+
+func TestB3Propagation(t *testing.T) {
+	tracer := NewTracer(nil, NewSampler(1))
+
+	headers := map[string]string{
+		B3TraceID: "463ac35c9f6413ad48485a3953bb6124",
+		B3SpanID:  "a2fb4a1d1a96d312",
+		B3Sampled: "0",
+	}
+	span := tracer.Start(protocol.HTTP1, headers, "/foo", time.Now())
+	if span.TraceID() != "463ac35c9f6413ad48485a3953bb6124" || span.ParentSpanID() != "a2fb4a1d1a96d312" || span.Sampled() {
+		t.Errorf("unexpected span context: %s %s %v", span.TraceID(), span.ParentSpanID(), span.Sampled())
+	}
+
+	child := span.SpawnChild("cluster", time.Now())
+	child.InjectContext(protocol.HTTP2, headers)
+	if headers[B3TraceID] != span.TraceID() || headers[B3SpanID] != child.SpanID() ||
+		headers[B3ParentSpanID] != span.SpanID() || headers[B3Sampled] != "0" {
+		t.Errorf("unexpected b3 headers: %v", headers)
+	}
+
+	// single header
+	span = tracer.Start(protocol.HTTP1, map[string]string{
+		B3Single: "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90",
+	}, "/foo", time.Now())
+	if span.TraceID() != "80f198ee56343ba864fe8b2a57d3eff7" || span.ParentSpanID() != "e457b5a2e4d86bd1" || !span.Sampled() {
+		t.Errorf("unexpected span context: %s %s %v", span.TraceID(), span.ParentSpanID(), span.Sampled())
+	}
+
+	// invalid ids start a new trace
+	span = tracer.Start(protocol.HTTP1, map[string]string{B3TraceID: "xyz", B3SpanID: "0000000000000000"}, "/foo", time.Now())
+	if span.TraceID() == "xyz" || span.ParentSpanID() != "" {
+		t.Errorf("invalid b3 headers should be ignored")
+	}
+}
+
+func TestW3CPropagation(t *testing.T) {
+	tracer := NewTracer(nil, NewSampler(0))
+
+	headers := map[string]string{
+		W3CTraceParent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		B3TraceID:      "463ac35c9f6413ad48485a3953bb6124",
+		B3SpanID:       "a2fb4a1d1a96d312",
+	}
+	span := tracer.Start(protocol.HTTP1, headers, "/foo", time.Now())
+	if span.TraceID() != "0af7651916cd43dd8448eb211c80319c" || span.ParentSpanID() != "b7ad6b7169203331" || !span.Sampled() {
+		t.Errorf("unexpected span context: %s %s %v", span.TraceID(), span.ParentSpanID(), span.Sampled())
+	}
+
+	child := span.SpawnChild("cluster", time.Now())
+	child.InjectContext(protocol.HTTP1, headers)
+	expected := "00-0af7651916cd43dd8448eb211c80319c-" + child.SpanID() + "-01"
+	if headers[W3CTraceParent] != expected {
+		t.Errorf("expected traceparent %s, got %s", expected, headers[W3CTraceParent])
+	}
+
+	// 64 bits trace id is padded
+	span = tracer.Start(protocol.HTTP1, map[string]string{B3TraceID: "48485a3953bb6124", B3SpanID: "a2fb4a1d1a96d312"}, "/foo", time.Now())
+	headers = map[string]string{}
+	span.InjectContext(protocol.HTTP1, headers)
+	if !strings.HasPrefix(headers[W3CTraceParent], "00-000000000000000048485a3953bb6124-") {
+		t.Errorf("unexpected traceparent %s", headers[W3CTraceParent])
+	}
+
+	for _, invalid := range []string{
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b71692033-01",
+	} {
+		if _, ok := extractW3C(map[string]string{W3CTraceParent: invalid}); ok {
+			t.Errorf("traceparent %s should be invalid", invalid)
+		}
+	}
+}
+
+func TestSofaPropagation(t *testing.T) {
+	tracer := NewTracer(nil, NewSampler(1))
+
+	headers := map[string]string{
+		models.TRACER_ID_KEY: "0a0fe8ec1542003466061100126460",
+		models.RPC_ID_KEY:    "0.1",
+	}
+	span := tracer.Start(protocol.SofaRPC, headers, "com.alipay.test.TestService:1.0", time.Now())
+	if span.TraceID() != "0a0fe8ec1542003466061100126460" {
+		t.Errorf("unexpected trace id %s", span.TraceID())
+	}
+
+	for _, rpcID := range []string{"0.1.1", "0.1.2"} {
+		child := span.SpawnChild("cluster", time.Now())
+		child.InjectContext(protocol.SofaRPC, headers)
+		if headers[models.TRACER_ID_KEY] != span.TraceID() || headers[models.RPC_ID_KEY] != rpcID {
+			t.Errorf("unexpected sofa headers: %v", headers)
+		}
+	}
+
+	// a new trace starts with rpc id 0
+	span = tracer.Start(protocol.SofaRPC, map[string]string{}, "service", time.Now())
+	headers = map[string]string{}
+	span.SpawnChild("cluster", time.Now()).InjectContext(protocol.SofaRPC, headers)
+	if headers[models.RPC_ID_KEY] != "0.1" {
+		t.Errorf("unexpected rpc id %s", headers[models.RPC_ID_KEY])
+	}
+}
+
+func TestGlobalDriver(t *testing.T) {
+	if GetDriver() != nil {
+		t.Fatal("tracing should be disabled by default")
+	}
+
+	tracer := NewTracer(nil, NewSampler(1))
+	SetDriver(tracer)
+	if GetDriver() != tracer {
+		t.Error("driver is not set")
+	}
+
+	SetDriver(nil)
+	if GetDriver() != nil {
+		t.Error("driver is not reset")
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// DefaultSampleRate samples all new traces
+const DefaultSampleRate = 1.0
+
+// Sampler decides whether a new trace is sampled
+type Sampler func(traceID string) bool
+
+// NewSampler returns a Sampler samples the fraction rate of traces.
+// The decision is made by the hash of trace id, so that all proxies
+// make the same decision for a trace.
+func NewSampler(rate float64) Sampler {
+	if rate >= 1 {
+		return func(string) bool { return true }
+	}
+
+	if rate <= 0 {
+		return func(string) bool { return false }
+	}
+
+	boundary := uint64(rate * math.MaxUint64)
+
+	return func(traceID string) bool {
+		h := fnv.New64a()
+		h.Write([]byte(traceID))
+		return h.Sum64() < boundary
+	}
+}
+
+// Tracer implements types.Driver
+type Tracer struct {
+	reporter Reporter
+	sampler  Sampler
+}
+
+// NewTracer creates a Tracer reports sampled spans to reporter
+func NewTracer(reporter Reporter, sampler Sampler) *Tracer {
+	return &Tracer{
+		reporter: reporter,
+		sampler:  sampler,
+	}
+}
+
+// Start creates a server span, the sampling decision of the upstream is respected if there is one
+func (t *Tracer) Start(protocol types.Protocol, requestHeaders map[string]string, operationName string, startTime time.Time) types.Span {
+	span := &Span{
+		tracer:    t,
+		spanID:    newSpanID(),
+		kind:      SpanKindServer,
+		operation: operationName,
+		tags:      make(map[string]string),
+		startTime: startTime,
+	}
+
+	sc, ok := extract(protocol, requestHeaders)
+	if ok {
+		span.traceID = sc.traceID
+		span.parentSpanID = sc.spanID
+		span.rpcID = sc.rpcID
+	} else {
+		span.traceID = newTraceID()
+	}
+
+	if span.rpcID == "" {
+		span.rpcID = "0"
+	}
+
+	switch sc.sampling {
+	case samplingAccept:
+		span.sampled = true
+	case samplingDeny:
+		span.sampled = false
+	default:
+		span.sampled = t.sampler(span.traceID)
+	}
+
+	return span
+}
+
+// Close closes the reporter
+func (t *Tracer) Close() error {
+	if t.reporter != nil {
+		return t.reporter.Close()
+	}
+	return nil
+}
+
+// newTraceID returns 128 bits random id in hex
+func newTraceID() string {
+	return randomID(16)
+}
+
+// newSpanID returns 64 bits random id in hex
+func newSpanID() string {
+	return randomID(8)
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,301 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package zipkin implements a trace reporter which posts spans to a zipkin collector
+// in zipkin v2 json format, see https://zipkin.io/zipkin-api/#/default/post_spans
+package zipkin
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/config"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/trace"
+	"github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+func init() {
+	trace.RegisterReporter(v2.ZIPKIN_REPORTER, CreateZipkinReporter)
+}
+
+// Default values of zipkin reporter config
+const (
+	DefaultServiceName   = "mosn"
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = time.Second
+
+	queueSize   = 10000
+	postTimeout = 5 * time.Second
+)
+
+type endpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	IPv4        string `json:"ipv4,omitempty"`
+	IPv6        string `json:"ipv6,omitempty"`
+	Port        int    `json:"port,omitempty"`
+}
+
+type tag struct {
+	key   string
+	value string
+}
+
+// tags is marshaled as a json object sorted by key
+type tags []tag
+
+func (ts tags) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	for i, t := range ts {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(t.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(t.value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type span struct {
+	TraceID        string    `json:"traceId"`
+	ID             string    `json:"id"`
+	ParentID       string    `json:"parentId,omitempty"`
+	Name           string    `json:"name,omitempty"`
+	Kind           string    `json:"kind,omitempty"`
+	Timestamp      int64     `json:"timestamp"`
+	Duration       int64     `json:"duration"`
+	LocalEndpoint  *endpoint `json:"localEndpoint,omitempty"`
+	RemoteEndpoint *endpoint `json:"remoteEndpoint,omitempty"`
+	Tags           tags      `json:"tags,omitempty"`
+}
+
+type zipkinReporter struct {
+	config *v2.ZipkinReporter
+	client *http.Client
+
+	spanChan chan *span
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+// CreateZipkinReporter creates a zipkin reporter according to config
+func CreateZipkinReporter(conf map[string]interface{}) (trace.Reporter, error) {
+	reporterConfig, err := config.ParseZipkinReporter(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewZipkinReporter(reporterConfig), nil
+}
+
+// NewZipkinReporter creates a zipkin reporter posts spans to config.CollectorEndpoint in batches
+func NewZipkinReporter(config *v2.ZipkinReporter) trace.Reporter {
+	if config.ServiceName == "" {
+		config.ServiceName = DefaultServiceName
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+
+	r := &zipkinReporter{
+		config:   config,
+		client:   &http.Client{Timeout: postTimeout},
+		spanChan: make(chan *span, queueSize),
+		stopChan: make(chan struct{}),
+	}
+
+	r.wg.Add(1)
+	go r.loop()
+
+	return r
+}
+
+// Report converts the span to zipkin model, the span is dropped if the queue is full
+func (r *zipkinReporter) Report(s *trace.Span) {
+	select {
+	case r.spanChan <- r.convert(s):
+	default:
+		log.DefaultLogger.Warnf("zipkin reporter queue is full, span %s is dropped", s.SpanID())
+	}
+}
+
+func (r *zipkinReporter) Close() error {
+	r.once.Do(func() {
+		close(r.stopChan)
+	})
+	r.wg.Wait()
+	return nil
+}
+
+func (r *zipkinReporter) loop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*span, 0, r.config.BatchSize)
+
+	for {
+		select {
+		case s := <-r.spanChan:
+			batch = append(batch, s)
+			if len(batch) >= r.config.BatchSize {
+				r.post(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.post(batch)
+			batch = batch[:0]
+		case <-r.stopChan:
+			// flush the spans reported before close
+			for {
+				select {
+				case s := <-r.spanChan:
+					batch = append(batch, s)
+					if len(batch) >= r.config.BatchSize {
+						r.post(batch)
+						batch = batch[:0]
+					}
+				default:
+					r.post(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *zipkinReporter) post(batch []*span) {
+	if len(batch) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		log.DefaultLogger.Errorf("zipkin reporter marshal spans failed: %v", err)
+		return
+	}
+
+	resp, err := r.client.Post(r.config.CollectorEndpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.DefaultLogger.Warnf("zipkin reporter post %d spans to %s failed: %v", len(batch), r.config.CollectorEndpoint, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.DefaultLogger.Warnf("zipkin reporter post %d spans to %s failed: %s", len(batch), r.config.CollectorEndpoint, resp.Status)
+	}
+}
+
+func (r *zipkinReporter) convert(s *trace.Span) *span {
+	zs := &span{
+		TraceID:   normalizeID(s.TraceID()),
+		ID:        s.SpanID(),
+		ParentID:  s.ParentSpanID(),
+		Name:      s.Operation(),
+		Kind:      string(s.Kind()),
+		Timestamp: s.StartTime().UnixNano() / int64(time.Microsecond),
+		Duration:  int64(s.Duration() / time.Microsecond),
+		LocalEndpoint: &endpoint{
+			ServiceName: r.config.ServiceName,
+		},
+	}
+
+	// zipkin treats zero duration as an incomplete span
+	if zs.Duration == 0 {
+		zs.Duration = 1
+	}
+
+	for k, v := range s.Tags() {
+		zs.Tags = append(zs.Tags, tag{k, v})
+	}
+	sort.Slice(zs.Tags, func(i, j int) bool {
+		return zs.Tags[i].key < zs.Tags[j].key
+	})
+
+	if peer := s.Tag(trace.TagPeerAddress); peer != "" {
+		zs.RemoteEndpoint = newEndpoint(peer)
+		if s.Kind() == trace.SpanKindClient {
+			zs.RemoteEndpoint.ServiceName = s.Tag(trace.TagUpstreamCluster)
+		}
+	}
+
+	return zs
+}
+
+func newEndpoint(address string) *endpoint {
+	ep := &endpoint{}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	} else {
+		ep.Port, _ = strconv.Atoi(port)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() != nil {
+			ep.IPv4 = ip.String()
+		} else {
+			ep.IPv6 = ip.String()
+		}
+	}
+
+	return ep
+}
+
+// normalizeID left pads the id with zeros to 16 or 32 hex characters as zipkin requires,
+// trace ids propagated by other formats may be shorter
+func normalizeID(id string) string {
+	length := 16
+	if len(id) > 16 {
+		length = 32
+	}
+	if len(id) >= length {
+		return id
+	}
+	return strings.Repeat("0", length-len(id)) + id
+}
@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zipkin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/protocol"
+	"github.com/alipay/sofa-mosn/pkg/trace"
+	"github.com/json-iterator/go"
+)
+
+type receivedSpan struct {
+	TraceID        string              `json:"traceId"`
+	ID             string              `json:"id"`
+	ParentID       string              `json:"parentId"`
+	Name           string              `json:"name"`
+	Kind           string              `json:"kind"`
+	Duration       int64               `json:"duration"`
+	LocalEndpoint  endpoint            `json:"localEndpoint"`
+	RemoteEndpoint endpoint            `json:"remoteEndpoint"`
+	Tags           jsoniter.RawMessage `json:"tags"`
+}
+
+type collector struct {
+	mux   sync.Mutex
+	spans []receivedSpan
+	posts int
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	var spans []receivedSpan
+	if err := json.Unmarshal(body, &spans); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mux.Lock()
+	c.spans = append(c.spans, spans...)
+	c.posts++
+	c.mux.Unlock()
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func TestZipkinReporter(t *testing.T) {
+	c := &collector{}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	reporter, err := CreateZipkinReporter(map[string]interface{}{
+		"collector_endpoint": server.URL + "/api/v2/spans",
+		"service_name":       "test",
+		"batch_size":         float64(2),
+		"flush_interval":     "1h",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := trace.NewTracer(reporter, trace.NewSampler(1))
+
+	headers := map[string]string{
+		trace.B3TraceID: "48485a3953bb6124",
+		trace.B3SpanID:  "a2fb4a1d1a96d312",
+	}
+	server1 := tracer.Start(protocol.HTTP1, headers, "/foo", time.Now())
+	server1.SetTag(trace.TagHTTPMethod, "GET")
+	server1.SetTag(trace.TagPeerAddress, "10.0.0.1:34567")
+
+	client := server1.SpawnChild("backend", time.Now())
+	client.SetTag(trace.TagUpstreamCluster, "backend")
+	client.SetTag(trace.TagPeerAddress, "10.0.0.2:8080")
+	client.FinishSpan()
+	server1.FinishSpan()
+
+	// the third span is flushed on close
+	server2 := tracer.Start(protocol.HTTP1, map[string]string{}, "/bar", time.Now())
+	server2.FinishSpan()
+
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.posts != 2 || len(c.spans) != 3 {
+		t.Fatalf("expected 3 spans in 2 posts, got %d spans in %d posts", len(c.spans), c.posts)
+	}
+
+	cs, ss := c.spans[0], c.spans[1]
+	if cs.Kind != "CLIENT" || ss.Kind != "SERVER" {
+		t.Fatalf("unexpected span kinds %s %s", cs.Kind, ss.Kind)
+	}
+
+	if ss.TraceID != "48485a3953bb6124" || ss.ParentID != "a2fb4a1d1a96d312" || ss.Name != "/foo" {
+		t.Errorf("unexpected server span %+v", ss)
+	}
+	if cs.TraceID != ss.TraceID || cs.ParentID != ss.ID || cs.Name != "backend" {
+		t.Errorf("unexpected client span %+v", cs)
+	}
+	if ss.LocalEndpoint.ServiceName != "test" || ss.Duration <= 0 {
+		t.Errorf("unexpected server span %+v", ss)
+	}
+	if cs.RemoteEndpoint.ServiceName != "backend" || cs.RemoteEndpoint.IPv4 != "10.0.0.2" || cs.RemoteEndpoint.Port != 8080 {
+		t.Errorf("unexpected client remote endpoint %+v", cs.RemoteEndpoint)
+	}
+	if tags := string(ss.Tags); !strings.Contains(tags, `"http.method":"GET"`) || !strings.Contains(tags, `"peer.address":"10.0.0.1:34567"`) {
+		t.Errorf("unexpected server span tags %s", tags)
+	}
+	if len(c.spans[2].TraceID) != 32 || c.spans[2].Name != "/bar" {
+		t.Errorf("unexpected span %+v", c.spans[2])
+	}
+}
+
+func TestZipkinReporterInvalidConfig(t *testing.T) {
+	if _, err := trace.CreateReporter("zipkin", map[string]interface{}{}); err == nil {
+		t.Error("expected error for missing collector_endpoint")
+	}
+	if _, err := trace.CreateReporter("zipkin", map[string]interface{}{
+		"collector_endpoint": "http://127.0.0.1:9411/api/v2/spans",
+		"flush_interval":     "abc",
+	}); err == nil {
+		t.Error("expected error for invalid flush_interval")
+	}
+	if _, err := trace.CreateReporter("unknown", nil); err == nil {
+		t.Error("expected error for unknown reporter type")
+	}
+}
@@ -56,6 +56,7 @@ const (
 	LogDownstreamLocalAddress     string = "DownstreamLocalAddress"
 	LogDownstreamRemoteAddress    string = "DownstreamRemoteAddress"
 	LogUpstreamHostSelectedGetter string = "UpstreamHostSelected"
+	LogRouteName                  string = "RouteName"
 )
 
 const (
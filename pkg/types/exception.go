@@ -31,6 +31,7 @@ const (
 	HeaderStremEnd      = "x-mosn-endstream"
 	HeaderRpcService    = "x-mosn-rpc-service"
 	HeaderRpcMethod     = "x-mosn-rpc-method"
+	HeaderRpcStatus     = "x-mosn-rpc-status"
 )
 
 // Error messages
@@ -47,6 +48,7 @@ const (
 	DeserialExceptionCode int = 3
 	SuccessCode           int = 200
 	RouterUnavailableCode int = 404
+	RateLimitedCode       int = 429
 	NoHealthUpstreamCode  int = 500
 	UpstreamOverFlowCode  int = 503
 	TimeoutExceptionCode  int = 504
@@ -26,8 +26,11 @@ type LoadBalancerType string
 
 // The load balancer's types
 const (
-	RoundRobin LoadBalancerType = "RoundRobin"
-	Random     LoadBalancerType = "Random"
+	RoundRobin   LoadBalancerType = "RoundRobin"
+	Random       LoadBalancerType = "Random"
+	RingHash     LoadBalancerType = "RingHash"
+	Maglev       LoadBalancerType = "Maglev"
+	LeastRequest LoadBalancerType = "LeastRequest"
 )
 
 // LoadBalancer is a upstream load balancer.
@@ -52,6 +55,16 @@ type LoadBalancerContext interface {
 	DownstreamHeaders() map[string]string
 }
 
+// HostPredicateContext is an optional interface of the LoadBalancerContext,
+// the host rejected by the context is selected again, e.g. the host has been tried by the request
+type HostPredicateContext interface {
+	// ShouldSelectAnotherHost returns true if the host should not be selected
+	ShouldSelectAnotherHost(host Host) bool
+
+	// HostSelectionRetryCount returns the max times to select another host
+	HostSelectionRetryCount() int
+}
+
 // SubSetLoadBalancer is a subset of LoadBalancer
 type SubSetLoadBalancer interface {
 	LoadBalancer
@@ -76,7 +76,7 @@ import (
 
 // The prefix
 const (
-	ListenerStatsPrefix = "listener.%d."
+	ListenerStatsPrefix = "listener."
 )
 
 // Listener is a wrapper of tcp listener
@@ -379,6 +379,9 @@ type ConnectionHandler interface {
 	// The close indicates whether the listening sockets will be closed.
 	StopListeners(lctx context.Context, close bool) error
 
+	// ListListeners reports all listeners the ConnectionHandler has
+	ListListeners(lctx context.Context) []Listener
+
 	// ListListenersFD reports all listeners' fd
 	ListListenersFD(lctx context.Context) []uintptr
 
@@ -17,13 +17,53 @@
 
 package types
 
+import "time"
+
+// OutlierResult is the result of a request which is not a response code, like connection failure
+type OutlierResult int
+
+// Group of outlier result
+const (
+	// the connection to the host is established
+	ResultConnectSuccess OutlierResult = iota
+	// the connection to the host is failed
+	ResultConnectFailed
+	// the request to the host is timeout
+	ResultRequestTimeout
+	// the request to the host is reset, such as the connection is closed by the host
+	ResultRequestFailed
+)
+
+// Detector detects the hosts which are outliers in a cluster,
+// the outliers are ejected from the healthy hosts for a while
 type Detector interface {
+	// AddChangedStateCb adds a callback called when a host is ejected or brought back
 	AddChangedStateCb(cb func(host Host))
 
+	// SuccessRateAverage returns the average success rate of the hosts in last interval,
+	// -1 if there are not enough hosts with enough requests
 	SuccessRateAverage() float64
 
+	// SuccessRateEjectionThreshold returns the success rate in last interval below which a host is ejected,
+	// -1 if there are not enough hosts with enough requests
 	SuccessRateEjectionThreshold() float64
 }
 
+// DetectorHostMonitor records the results of requests sent to a host
 type DetectorHostMonitor interface {
+	// PutResponseCode records the response code of a request, the code is in http status code semantics
+	PutResponseCode(code int)
+
+	// PutResult records the result of a request which has no response
+	PutResult(result OutlierResult)
+
+	// NumEjections returns the ejection times of the host, the ejection time grows with it
+	NumEjections() uint32
+
+	// LastEjectionTime returns the time of the last ejection, zero if the host is never ejected
+	LastEjectionTime() time.Time
+
+	// SuccessRate returns the success rate of the host in last interval,
+	// -1 if there are not enough requests
+	SuccessRate() float64
 }
@@ -64,7 +64,7 @@ type RouterConfigManager interface {
 
 // Route is a route instance
 type Route interface {
-	// RedirectRule returns the redirect rule
+	// RedirectRule returns the redirect rule, nil if the route is neither a redirect route nor a direct response route
 	RedirectRule() RedirectRule
 
 	// RouteRule returns the route rule
@@ -102,6 +102,18 @@ type RouteRule interface {
 	// MetadataMatchCriteria returns the metadata that a subset load balancer should match when selecting an upstream host
 	// as we may use weighted cluster's metadata, so need to input cluster's name
 	MetadataMatchCriteria(clusterName string) MetadataMatchCriteria
+
+	// GetRouterName returns the route's name
+	GetRouterName() string
+
+	// FinalizeRequestHeaders applies the request headers manipulation of the route and its virtual host
+	FinalizeRequestHeaders(headers map[string]string, requestInfo RequestInfo)
+
+	// FinalizeResponseHeaders applies the response headers manipulation of the route and its virtual host
+	FinalizeResponseHeaders(headers map[string]string, requestInfo RequestInfo)
+
+	// AutoHostRewrite returns true if the host header is rewritten with the hostname of the selected upstream host
+	AutoHostRewrite() bool
 }
 
 // Policy defines a group of route policy
@@ -113,6 +125,10 @@ type Policy interface {
 	CorsPolicy() CorsPolicy
 
 	LoadBalancerPolicy() LoadBalancerPolicy
+
+	RateLimitPolicy() RateLimitPolicy
+
+	HedgePolicy() HedgePolicy
 }
 
 // CorsPolicy is a type of Policy
@@ -137,18 +153,21 @@ type LoadBalancerPolicy interface {
 	HashPolicy() HashPolicy
 }
 
-type AddCookieCallback func(key string, ttl int)
+// AddCookieCallback sets a cookie on the response, and returns the cookie value
+type AddCookieCallback func(key string, path string, ttl time.Duration) string
 
 // HashPolicy is a type of Policy
 type HashPolicy interface {
-	GenerateHash(downstreamAddress string, headers map[string]string, addCookieCb AddCookieCallback)
+	// GenerateHash generates the hash key used by consistent hash load balancers,
+	// an empty value is returned if no key can be generated
+	GenerateHash(downstreamAddress string, headers map[string]string, addCookieCb AddCookieCallback) HashedValue
 }
 
 // RateLimitPolicy is a type of Policy
 type RateLimitPolicy interface {
 	Enabled() bool
 
-	GetApplicableRateLimit(stage string) []RateLimitPolicyEntry
+	GetApplicableRateLimit(stage uint64) []RateLimitPolicyEntry
 }
 
 type RateLimitPolicyEntry interface {
@@ -156,7 +175,9 @@ type RateLimitPolicyEntry interface {
 
 	DisableKey() string
 
-	PopulateDescriptors(route RouteRule, descriptors []Descriptor, localSrvCluster string, headers map[string]string, remoteAddr string)
+	// PopulateDescriptors appends the descriptor generated for the request to descriptors and returns the result,
+	// descriptors is returned unchanged if the descriptor can not be generated
+	PopulateDescriptors(route RouteRule, descriptors []Descriptor, localSrvCluster string, headers map[string]string, remoteAddr string) []Descriptor
 }
 
 // LimitStatus type
@@ -177,7 +198,7 @@ type DescriptorEntry struct {
 
 // Descriptor contains a list pf DescriptorEntry
 type Descriptor struct {
-	entries []DescriptorEntry
+	Entries []DescriptorEntry
 }
 
 // RetryCheckStatus type
@@ -197,6 +218,51 @@ type RetryPolicy interface {
 	TryTimeout() time.Duration
 
 	NumRetries() uint32
+
+	// RetryConditions returns the conditions in which the request should be retried
+	RetryConditions() RetryCondition
+
+	// RetriableStatusCodes returns the status codes retried on RetryOnRetriableStatusCodes
+	RetriableStatusCodes() []int
+
+	// BackOff returns the base interval and the max interval of the exponential backoff between retries
+	BackOff() (baseInterval time.Duration, maxInterval time.Duration)
+
+	// RetryBudgetPercent returns the max percentage of the active requests of the cluster can be retried,
+	// zero means the retries are only limited by the circuit breakers
+	RetryBudgetPercent() uint32
+
+	// AvoidPreviousHosts returns true if the hosts already tried should not be selected again by the retries
+	AvoidPreviousHosts() bool
+}
+
+// RetryCondition is a bit set of the conditions in which the request should be retried
+type RetryCondition uint32
+
+// Retry conditions
+const (
+	// RetryOn5xx retries on 5xx status, reset or connect failure
+	RetryOn5xx RetryCondition = 1 << iota
+	// RetryOnGatewayError retries on 502, 503 and 504 status
+	RetryOnGatewayError
+	// RetryOnConnectFailure retries if the connection to the upstream is failed
+	RetryOnConnectFailure
+	// RetryOnReset retries if the upstream resets the stream
+	RetryOnReset
+	// RetryOnRetriableStatusCodes retries on the status codes in RetriableStatusCodes
+	RetryOnRetriableStatusCodes
+	// RetryOnServerBusy retries if the sofa rpc upstream replies the thread pool busy status
+	RetryOnServerBusy
+)
+
+// RetryConditionNames maps the names in the config to the retry conditions
+var RetryConditionNames = map[string]RetryCondition{
+	"5xx":                    RetryOn5xx,
+	"gateway-error":          RetryOnGatewayError,
+	"connect-failure":        RetryOnConnectFailure,
+	"reset":                  RetryOnReset,
+	"retriable-status-codes": RetryOnRetriableStatusCodes,
+	"server-busy":            RetryOnServerBusy,
 }
 
 type DoRetryCallback func()
@@ -207,11 +273,20 @@ type RetryState interface {
 	ShouldRetry(respHeaders map[string]string, resetReson string, doRetryCb DoRetryCallback) bool
 }
 
+// HedgePolicy is a type of Policy
+type HedgePolicy interface {
+	// HedgeDelay returns the time to wait for the response before sending a hedged request to another host
+	HedgeDelay() time.Duration
+}
+
 // ShadowPolicy is a type of Policy
 type ShadowPolicy interface {
 	ClusterName() string
 
 	RuntimeKey() string
+
+	// Percent returns the percentage of requests to be mirrored, in the range of [0, 100]
+	Percent() uint32
 }
 
 type VirtualServer interface {
@@ -249,18 +324,24 @@ type MetadataMatchEntry interface {
 	Value() string
 }
 
+// RedirectRule replies the request without any upstream, by a redirect or a direct response
 type RedirectRule interface {
+	// NewPath returns the location of the redirect, empty for a direct response
 	NewPath(headers map[string]string) string
 
-	ResponseCode() interface{}
+	// ResponseCode returns the status code of the reply
+	ResponseCode() int
 
+	// ResponseBody returns the body of the direct response
 	ResponseBody() string
 }
 
+// TraceDecorator decorates the span of the requests matched the route
 type TraceDecorator interface {
-	operate(span Span)
+	// Apply sets the operation of the span
+	Apply(span Span)
 
-	getOperation() string
+	GetOperation() string
 }
 
 type MetadataMatchCriterion interface {
@@ -279,18 +360,17 @@ type MetadataMatchCriteria interface {
 	MergeMatchCriteria(metadataMatches map[string]interface{}) MetadataMatchCriteria
 }
 
-type Decorator interface {
-	apply(span Span)
-	getOperation() string
-}
-
 // HashedValue is a value as md5's result
 // TODO: change hashed value to [16]string
 // currently use string for easily debug
 type HashedValue string
 
+// HeaderFormat formats the value of a header to add
 type HeaderFormat interface {
+	// Format returns the header value, the request info variables in the value are replaced
 	Format(info RequestInfo) string
+
+	// Append returns true if the value is appended to the existing value, otherwise the header is set
 	Append() bool
 }
 
@@ -366,8 +446,6 @@ type PathMatchCriterion interface {
 	Matcher() string
 }
 
-type Loader struct{}
-
 type RouteMetaData map[string]HashedValue
 
 // GenerateHashedValue generates generates hashed valued with md5
@@ -0,0 +1,55 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+// RuntimeLayer is a named set of runtime values, the values in the later layers
+// override the ones in the former layers
+type RuntimeLayer struct {
+	Name   string
+	Values map[string]string
+}
+
+// RuntimeSnapshot is an immutable view of the runtime values
+type RuntimeSnapshot interface {
+	// Get returns the raw value of the key
+	Get(key string) (string, bool)
+
+	// GetInteger returns the key's value as an integer,
+	// the defaultValue is returned if the key is absent or not an integer
+	GetInteger(key string, defaultValue uint64) uint64
+
+	// FeatureEnabled returns true if randomValue % 100 is less than the key's value,
+	// which is a percentage, the defaultValue is used if the key is absent
+	FeatureEnabled(key string, defaultValue uint64, randomValue uint64) bool
+
+	// Layers returns the layers the snapshot is merged from
+	Layers() []RuntimeLayer
+}
+
+// Loader loads the runtime layers and publishes the merged snapshot,
+// the snapshot is swapped atomically when the layers change
+type Loader interface {
+	// Snapshot returns the current snapshot
+	Snapshot() RuntimeSnapshot
+
+	// MergeValues sets the values in the admin layer, an empty value removes the key
+	MergeValues(values map[string]string)
+
+	// Reload reloads the disk layers
+	Reload() error
+}
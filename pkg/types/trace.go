@@ -19,18 +19,38 @@ package types
 
 import "time"
 
+// Span records an operation of a request, such as handling a downstream request
+// or sending an upstream request
 type Span interface {
+	// TraceID returns the id of the trace which the span belongs to
+	TraceID() string
+
+	// SpanID returns the id of the span
+	SpanID() string
+
+	// ParentSpanID returns the id of the parent span, empty if the span is a root span
+	ParentSpanID() string
+
+	// Sampled returns true if the span will be reported
+	Sampled() bool
+
 	SetOperation(operation string)
 
 	SetTag(key string, value string)
 
+	// FinishSpan records the end time of the span and reports it if sampled
 	FinishSpan()
 
-	InjectContext()
+	// InjectContext writes the span context into requestHeaders in the propagation format of protocol
+	InjectContext(protocol Protocol, requestHeaders map[string]string)
 
-	SpawnChild() Span
+	// SpawnChild creates a client span whose parent is the span
+	SpawnChild(operationName string, startTime time.Time) Span
 }
 
+// Driver creates spans for requests
 type Driver interface {
-	start(requestHeaders map[string]string, operationName string, startTime time.Time) Span
+	// Start creates a server span, the parent span context is extracted from requestHeaders
+	// in the propagation format of protocol, a new trace is started if there is none
+	Start(protocol Protocol, requestHeaders map[string]string, operationName string, startTime time.Time) Span
 }
@@ -209,9 +209,22 @@ type HostInfo interface {
 
 	HostStats() HostStats
 
-	// TODO: add deploy locality
+	// Priority returns the priority of the host set which the host belongs to
+	Priority() uint32
+
+	// Locality returns the host's deploy locality, in the form of region/zone/sub_zone
+	Locality() string
+
+	// LocalityWeight returns the weight of the host's locality, zero means locality weighted lb is disabled
+	LocalityWeight() uint32
 }
 
+// The stats namespace prefix of cluster and host
+const (
+	ClusterStatsPrefix = "cluster."
+	HostStatsPrefix    = "host."
+)
+
 // HostStats defines a host's statistics information
 type HostStats struct {
 	Namespace                                      string
@@ -295,6 +308,7 @@ type Resource interface {
 	Increase()
 	Decrease()
 	Max() uint64
+	Cur() int64
 }
 
 // ClusterStats defines a cluster's statistics information
@@ -323,6 +337,9 @@ type ClusterStats struct {
 	UpstreamRequestRemoteReset                     metrics.Counter
 	UpstreamRequestRetry                           metrics.Counter
 	UpstreamRequestRetryOverflow                   metrics.Counter
+	UpstreamRequestHedge                           metrics.Counter
+	UpstreamRequestHedgeOverflow                   metrics.Counter
+	UpstreamRequestHedgeWin                        metrics.Counter
 	UpstreamRequestTimeout                         metrics.Counter
 	UpstreamRequestFailureEject                    metrics.Counter
 	UpstreamRequestPendingOverflow                 metrics.Counter
@@ -330,6 +347,12 @@ type ClusterStats struct {
 	LBSubSetsActive                                metrics.Counter
 	LBSubsetsCreated                               metrics.Counter
 	LBSubsetsRemoved                               metrics.Counter
+	OutlierDetectionEjectionsActive                metrics.Counter
+	OutlierDetectionEjectionsTotal                 metrics.Counter
+	OutlierDetectionEjectionsOverflow              metrics.Counter
+	OutlierDetectionEjectionsConsecutive5xx        metrics.Counter
+	OutlierDetectionEjectionsGatewayFailure        metrics.Counter
+	OutlierDetectionEjectionsSuccessRate           metrics.Counter
 }
 
 type CreateConnectionData struct {
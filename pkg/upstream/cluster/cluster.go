@@ -38,6 +38,7 @@ type cluster struct {
 	mux                            sync.RWMutex
 	initHelper                     concreteClusterInitHelper
 	healthChecker                  types.HealthChecker
+	outlierDetector                *outlierDetector
 }
 
 type concreteClusterInitHelper interface {
@@ -87,6 +88,15 @@ func newCluster(clusterConfig v2.Cluster, sourceAddr net.Addr, addedViaAPI bool,
 
 	case v2.LB_ROUNDROBIN:
 		cluster.info.lbType = types.RoundRobin
+
+	case v2.LB_RINGHASH:
+		cluster.info.lbType = types.RingHash
+
+	case v2.LB_MAGLEV:
+		cluster.info.lbType = types.Maglev
+
+	case v2.LB_LEASTREQUEST:
+		cluster.info.lbType = types.LeastRequest
 	}
 
 	// TODO: init more props: maxrequestsperconn, connecttimeout, connectionbuflimit
@@ -100,14 +110,20 @@ func newCluster(clusterConfig v2.Cluster, sourceAddr net.Addr, addedViaAPI bool,
 
 	var lb types.LoadBalancer
 
+	lbPrioritySet := cluster.PrioritySet()
+	if clusterConfig.ZoneAware.Enable {
+		// load balancers choose hosts from the local zone preferentially
+		lbPrioritySet = newZoneAwarePrioritySet(lbPrioritySet, clusterConfig.ZoneAware)
+	}
+
 	if cluster.Info().LbSubsetInfo().IsEnabled() {
 		// use subset loadbalancer
-		lb = NewSubsetLoadBalancer(cluster.Info().LbType(), cluster.PrioritySet(), cluster.Info().Stats(),
+		lb = NewSubsetLoadBalancer(cluster.Info().LbType(), lbPrioritySet, cluster.Info().Stats(),
 			cluster.Info().LbSubsetInfo())
 
 	} else {
 		// use common loadbalancer
-		lb = NewLoadBalancer(cluster.Info().LbType(), cluster.PrioritySet())
+		lb = NewLoadBalancer(cluster.Info().LbType(), lbPrioritySet)
 	}
 
 	cluster.info.lbInstance = lb
@@ -134,7 +150,7 @@ func (c *cluster) Initialize(cb func()) {
 }
 
 func newClusterStats(config v2.Cluster) types.ClusterStats {
-	nameSpace := fmt.Sprintf("cluster.%s", config.Name)
+	nameSpace := types.ClusterStatsPrefix + config.Name
 
 	return types.ClusterStats{
 		Namespace:                                      nameSpace,
@@ -162,10 +178,21 @@ func newClusterStats(config v2.Cluster) types.ClusterStats {
 		UpstreamRequestTimeout:                         metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_request_request_timeout"), nil),
 		UpstreamRequestFailureEject:                    metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_request_failure_eject"), nil),
 		UpstreamRequestPendingOverflow:                 metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_request_pending_overflow"), nil),
+		UpstreamRequestRetry:                           metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_request_retry"), nil),
+		UpstreamRequestRetryOverflow:                   metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_request_retry_overflow"), nil),
+		UpstreamRequestHedge:                           metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_request_hedge"), nil),
+		UpstreamRequestHedgeOverflow:                   metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_request_hedge_overflow"), nil),
+		UpstreamRequestHedgeWin:                        metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_request_hedge_win"), nil),
 		LBSubSetsFallBack:                              metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_LBSubSetsFallBack"), nil),
 		LBSubSetsActive:                                metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_LBSubSetsActive"), nil),
 		LBSubsetsCreated:                               metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_LBSubsetsCreated"), nil),
 		LBSubsetsRemoved:                               metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "upstream_LBSubsetsRemoved"), nil),
+		OutlierDetectionEjectionsActive:                metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "outlier_detection_ejections_active"), nil),
+		OutlierDetectionEjectionsTotal:                 metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "outlier_detection_ejections_total"), nil),
+		OutlierDetectionEjectionsOverflow:              metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "outlier_detection_ejections_overflow"), nil),
+		OutlierDetectionEjectionsConsecutive5xx:        metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "outlier_detection_ejections_consecutive_5xx"), nil),
+		OutlierDetectionEjectionsGatewayFailure:        metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "outlier_detection_ejections_consecutive_gateway_failure"), nil),
+		OutlierDetectionEjectionsSuccessRate:           metrics.GetOrRegisterCounter(fmt.Sprintf("%s.%s", nameSpace, "outlier_detection_ejections_success_rate"), nil),
 	}
 }
 
@@ -184,12 +211,13 @@ func (c *cluster) PrioritySet() types.PrioritySet {
 func (c *cluster) SetHealthChecker(hc types.HealthChecker) {
 	c.healthChecker = hc
 	c.healthChecker.SetCluster(c)
-	c.healthChecker.Start()
+	// the callback is added before starting, so the results of the first checks are not missed
 	c.healthChecker.AddHostCheckCompleteCb(func(host types.Host, changedState bool) {
 		if changedState {
 			c.refreshHealthHosts(host)
 		}
 	})
+	c.healthChecker.Start()
 }
 
 func (c *cluster) HealthChecker() types.HealthChecker {
@@ -197,19 +225,40 @@ func (c *cluster) HealthChecker() types.HealthChecker {
 }
 
 func (c *cluster) OutlierDetector() types.Detector {
-	// TODO
-	return nil
+	if c.outlierDetector == nil {
+		return nil
+	}
+
+	return c.outlierDetector
+}
+
+// initOutlierDetector should be called on the final cluster struct, as the detector keeps a reference to it
+func (c *cluster) initOutlierDetector(config v2.OutlierDetection) {
+	if !isOutlierDetectionEnabled(config) {
+		return
+	}
+
+	c.outlierDetector = newOutlierDetector(c, config)
+	c.outlierDetector.start()
+}
+
+// stopOutlierDetector stops the detector and brings back the ejected hosts,
+// it is called when the cluster is removed or replaced, as the hosts may be reused
+func (c *cluster) stopOutlierDetector() {
+	if c.outlierDetector != nil {
+		c.outlierDetector.stop()
+	}
 }
 
 // update health-hostSet for only one hostSet, reduce update times
 func (c *cluster) refreshHealthHosts(host types.Host) {
 	if host.Health() {
 		log.DefaultLogger.Debugf("Add health host %s to cluster's healthHostSet by refreshHealthHosts", host.AddressString())
-		addHealthyHost(c.prioritySet.hostSets, host)
 	} else {
 		log.DefaultLogger.Debugf("Del host %s from cluster's healthHostSet by refreshHealthHosts", host.AddressString())
-		delHealthHost(c.prioritySet.hostSets, host)
 	}
+
+	updateHealthyHosts(c.prioritySet.HostSetsByPriority(), host)
 }
 
 // refresh health hosts globally
@@ -372,89 +421,39 @@ func getHealthHost(hosts []types.Host) []types.Host {
 	return healthyHost
 }
 
+// getHealthHostsPerLocality keeps the index of localities aligned with hostsPerLocality,
+// a locality without healthy hosts is left empty
 func getHealthHostsPerLocality(hhpl [][]types.Host) [][]types.Host {
+	if len(hhpl) == 0 {
+		return nil
+	}
+
 	var healthyHostPerLocality = make([][]types.Host, len(hhpl))
-	hi := 0
 
 	for i := range hhpl {
-		hj := 0
-		for j := range hhpl[i] {
-			if hhpl[i][j].Health() {
-				healthyHostPerLocality[hi] = append(healthyHostPerLocality[hi], hhpl[i][j])
-				hj++
-			}
-		}
-		if hj > 0 {
-			hi++
-		}
+		healthyHostPerLocality[i] = getHealthHost(hhpl[i])
 	}
 	return healthyHostPerLocality
 }
 
-func addHealthyHost(hostSets []types.HostSet, host types.Host) {
+// updateHealthyHosts recalculates the healthy hosts of the hostSet which the host belongs to.
+// The healthy hosts are read by load balancers concurrently, so they are rebuilt instead of modified in place.
+func updateHealthyHosts(hostSets []types.HostSet, host types.Host) {
 	// Note: currently, one host only belong to a hostSet
-
 	for i, hostSet := range hostSets {
 		found := false
 
 		for _, h := range hostSet.Hosts() {
 			if h.AddressString() == host.AddressString() {
-				log.DefaultLogger.Debugf("add healthy host = %s, in priority = %d", host.AddressString(), i)
+				log.DefaultLogger.Debugf("update healthy hosts for host = %s, in priority = %d", host.AddressString(), i)
 				found = true
 				break
 			}
 		}
 
 		if found {
-			newHealthHost := hostSet.HealthyHosts()
-			newHealthHost = append(newHealthHost, host)
-			newHealthyHostPerLocality := hostSet.HealthHostsPerLocality()
-			newHealthyHostPerLocality[len(newHealthyHostPerLocality)-1] = append(newHealthyHostPerLocality[len(newHealthyHostPerLocality)-1], host)
-
-			hostSet.UpdateHosts(hostSet.Hosts(), newHealthHost, hostSet.HostsPerLocality(),
-				newHealthyHostPerLocality, nil, nil)
-			break
-		}
-	}
-}
-
-func delHealthHost(hostSets []types.HostSet, host types.Host) {
-	for i, hostSet := range hostSets {
-		// Note: currently, one host only belong to a hostSet
-		found := false
-
-		for _, h := range hostSet.Hosts() {
-			if h.AddressString() == host.AddressString() {
-				log.DefaultLogger.Debugf("del healthy host = %s, in priority = %d", host.AddressString(), i)
-				found = true
-				break
-			}
-		}
-
-		if found {
-			newHealthHost := hostSet.HealthyHosts()
-			newHealthyHostPerLocality := hostSet.HealthHostsPerLocality()
-
-			for i, hh := range newHealthHost {
-				if host.Hostname() == hh.Hostname() {
-					//remove
-					newHealthHost = append(newHealthHost[:i], newHealthHost[i+1:]...)
-					break
-				}
-			}
-
-			for i := range newHealthyHostPerLocality {
-				for j := range newHealthyHostPerLocality[i] {
-
-					if host.Hostname() == newHealthyHostPerLocality[i][j].Hostname() {
-						newHealthyHostPerLocality[i] = append(newHealthyHostPerLocality[i][:j], newHealthyHostPerLocality[i][j+1:]...)
-						break
-					}
-				}
-			}
-
-			hostSet.UpdateHosts(hostSet.Hosts(), newHealthHost, hostSet.HostsPerLocality(),
-				newHealthyHostPerLocality, nil, nil)
+			hostSet.UpdateHosts(hostSet.Hosts(), getHealthHost(hostSet.Hosts()), hostSet.HostsPerLocality(),
+				getHealthHostsPerLocality(hostSet.HostsPerLocality()), nil, nil)
 			break
 		}
 	}
@@ -140,6 +140,7 @@ func (cm *clusterManager) updateCluster(clusterConf v2.Cluster, pcluster *primar
 
 	if concretedCluster, ok := pcluster.cluster.(*simpleInMemCluster); ok {
 		hosts := concretedCluster.hosts
+		concretedCluster.stopOutlierDetector()
 		cluster := NewCluster(clusterConf, cm.sourceAddr, addedViaAPI)
 		cluster.(*simpleInMemCluster).UpdateHosts(hosts)
 		cm.primaryClusters.Store(clusterConf.Name, &primaryCluster{
@@ -196,6 +197,9 @@ func (cm *clusterManager) RemovePrimaryCluster(clusterName string) error {
 		if !v.(*primaryCluster).addedViaAPI {
 			return fmt.Errorf("Remove Primary Cluster Failed, Cluster Name = %s not addedViaAPI", clusterName)
 		}
+		if concretedCluster, ok := v.(*primaryCluster).cluster.(*simpleInMemCluster); ok {
+			concretedCluster.stopOutlierDetector()
+		}
 		cm.primaryClusters.Delete(clusterName)
 		log.DefaultLogger.Debugf("Remove Primary Cluster, Cluster Name = %s", clusterName)
 		return nil
@@ -283,7 +287,7 @@ func (cm *clusterManager) TCPConnForCluster(lbCtx types.LoadBalancerContext, clu
 		return types.CreateConnectionData{}
 	}
 
-	host := clusterSnapshot.loadbalancer.ChooseHost(lbCtx)
+	host := chooseHost(clusterSnapshot.loadbalancer, lbCtx)
 
 	if host != nil {
 		return host.CreateConnection(nil)
@@ -301,7 +305,7 @@ func (cm *clusterManager) ConnPoolForCluster(balancerContext types.LoadBalancerC
 		return nil
 	}
 
-	host := clusterSnapshot.loadbalancer.ChooseHost(balancerContext)
+	host := chooseHost(clusterSnapshot.loadbalancer, balancerContext)
 
 	if host != nil {
 		addr := host.AddressString()
@@ -326,6 +330,23 @@ func (cm *clusterManager) ConnPoolForCluster(balancerContext types.LoadBalancerC
 	return nil
 }
 
+// chooseHost chooses a host by the load balancer, the host is selected again if the context rejects it,
+// the last selected host is used if all the selections are rejected
+func chooseHost(lb types.LoadBalancer, context types.LoadBalancerContext) types.Host {
+	host := lb.ChooseHost(context)
+
+	predicate, ok := context.(types.HostPredicateContext)
+	if !ok {
+		return host
+	}
+
+	for i := 0; i < predicate.HostSelectionRetryCount() && host != nil && predicate.ShouldSelectAnotherHost(host); i++ {
+		host = lb.ChooseHost(context)
+	}
+
+	return host
+}
+
 func (cm *clusterManager) Shutdown() error {
 	return nil
 }
@@ -46,7 +46,8 @@ func (dc *dynamicClusterBase) updateDynamicHostList(newHosts []types.Host, curre
 		for i := 0; i < len(currentHosts); {
 			curNh := currentHosts[i]
 
-			if nh.AddressString() == curNh.AddressString() {
+			// the host is replaced if its priority or locality is changed, as they are not updated in place
+			if nh.AddressString() == curNh.AddressString() && sameLocality(nh, curNh) {
 				curNh.SetWeight(nh.Weight())
 				finalHosts = append(finalHosts, curNh)
 				currentHosts = append(currentHosts[:i], currentHosts[i+1:]...)
@@ -75,6 +76,11 @@ func (dc *dynamicClusterBase) updateDynamicHostList(newHosts []types.Host, curre
 	return changed, finalHosts, hostsAdded, hostsRemoved
 }
 
+func sameLocality(h1 types.Host, h2 types.Host) bool {
+	return h1.Priority() == h2.Priority() && h1.Locality() == h2.Locality() &&
+		h1.LocalityWeight() == h2.LocalityWeight()
+}
+
 // SimpleCluster
 type simpleInMemCluster struct {
 	dynamicClusterBase
@@ -85,11 +91,14 @@ type simpleInMemCluster struct {
 func newSimpleInMemCluster(clusterConfig v2.Cluster, sourceAddr net.Addr, addedViaAPI bool) *simpleInMemCluster {
 	cluster := newCluster(clusterConfig, sourceAddr, addedViaAPI, nil)
 
-	return &simpleInMemCluster{
+	sc := &simpleInMemCluster{
 		dynamicClusterBase: dynamicClusterBase{
 			cluster: cluster,
 		},
 	}
+	sc.initOutlierDetector(clusterConfig.OutlierDetection)
+
+	return sc
 }
 
 func (sc *simpleInMemCluster) UpdateHosts(newHosts []types.Host) {
@@ -105,10 +114,7 @@ func (sc *simpleInMemCluster) UpdateHosts(newHosts []types.Host) {
 
 	if changed {
 		sc.hosts = finalHosts
-		// todo: need to consider how to update healthyHost
-		// Note: currently, we only use priority 0
-		sc.prioritySet.GetOrCreateHostSet(0).UpdateHosts(sc.hosts,
-			sc.hosts, nil, nil, hostsAdded, hostsRemoved)
+		sc.updatePrioritySet(hostsAdded, hostsRemoved)
 
 		if sc.healthChecker != nil {
 			sc.healthChecker.OnClusterMemberUpdate(hostsAdded, hostsRemoved)
@@ -124,3 +130,76 @@ func (sc *simpleInMemCluster) UpdateHosts(newHosts []types.Host) {
 		log.DefaultLogger.Debugf("after update final host index = %d, address = %s,", i, f.AddressString())
 	}
 }
+
+// updatePrioritySet splits the hosts into host sets by priority, and the hosts in a
+// host set are grouped by locality
+func (sc *simpleInMemCluster) updatePrioritySet(hostsAdded []types.Host, hostsRemoved []types.Host) {
+	hostsPerPriority := groupHostsByPriority(sc.hosts)
+	addedPerPriority := groupHostsByPriority(hostsAdded)
+	removedPerPriority := groupHostsByPriority(hostsRemoved)
+
+	// host sets without hosts are kept, as the priority set never shrinks
+	priorities := len(sc.prioritySet.HostSetsByPriority())
+	if len(hostsPerPriority) > priorities {
+		priorities = len(hostsPerPriority)
+	}
+
+	for priority := 0; priority < priorities; priority++ {
+		hosts := hostsAt(hostsPerPriority, priority)
+		hostsPerLocality := groupHostsByLocality(hosts)
+
+		sc.prioritySet.GetOrCreateHostSet(uint32(priority)).UpdateHosts(hosts, getHealthHost(hosts),
+			hostsPerLocality, getHealthHostsPerLocality(hostsPerLocality),
+			hostsAt(addedPerPriority, priority), hostsAt(removedPerPriority, priority))
+	}
+}
+
+// groupHostsByPriority returns the hosts indexed by priority
+func groupHostsByPriority(hosts []types.Host) [][]types.Host {
+	var hostsPerPriority [][]types.Host
+
+	for _, host := range hosts {
+		priority := int(host.Priority())
+		for len(hostsPerPriority) <= priority {
+			hostsPerPriority = append(hostsPerPriority, nil)
+		}
+		hostsPerPriority[priority] = append(hostsPerPriority[priority], host)
+	}
+
+	return hostsPerPriority
+}
+
+// groupHostsByLocality groups the hosts by locality in the order they first appear,
+// nil is returned if the locality is not set for any host
+func groupHostsByLocality(hosts []types.Host) [][]types.Host {
+	var hostsPerLocality [][]types.Host
+	localityIndex := make(map[string]int)
+	localityEnabled := false
+
+	for _, host := range hosts {
+		if host.Locality() != "" || host.LocalityWeight() > 0 {
+			localityEnabled = true
+		}
+
+		idx, ok := localityIndex[host.Locality()]
+		if !ok {
+			idx = len(hostsPerLocality)
+			localityIndex[host.Locality()] = idx
+			hostsPerLocality = append(hostsPerLocality, nil)
+		}
+		hostsPerLocality[idx] = append(hostsPerLocality[idx], host)
+	}
+
+	if !localityEnabled {
+		return nil
+	}
+
+	return hostsPerLocality
+}
+
+func hostsAt(hostsPerPriority [][]types.Host, priority int) []types.Host {
+	if priority < len(hostsPerPriority) {
+		return hostsPerPriority[priority]
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 
 	"github.com/alipay/sofa-mosn/pkg/api/v2"
 	"github.com/alipay/sofa-mosn/pkg/log"
@@ -114,7 +115,7 @@ func NewHost(config v2.Host, clusterInfo types.ClusterInfo) types.Host {
 }
 
 func newHostStats(config v2.Host) types.HostStats {
-	nameSpace := fmt.Sprintf("host.%s", config.Address)
+	nameSpace := types.HostStatsPrefix + config.Address
 
 	return types.HostStats{
 		Namespace:                                      nameSpace,
@@ -189,7 +190,9 @@ func (h *host) Health() bool {
 func (h *host) SetHealthChecker(healthCheck types.HealthCheckHostMonitor) {
 }
 
+// SetOutlierDetector may be called when the host is in use, the proxy reads the detector concurrently
 func (h *host) SetOutlierDetector(outlierDetector types.DetectorHostMonitor) {
+	h.outlierDetector.Store(outlierDetectorHolder{outlierDetector})
 }
 
 func (h *host) Weight() uint32 {
@@ -208,6 +211,11 @@ func (h *host) SetUsed(used bool) {
 	h.used = used
 }
 
+// outlierDetectorHolder wraps the detector, as atomic.Value can not store nil or values of different types
+type outlierDetectorHolder struct {
+	monitor types.DetectorHostMonitor
+}
+
 // HostInfo
 type hostInfo struct {
 	hostname      string
@@ -219,7 +227,13 @@ type hostInfo struct {
 	metaData      types.RouteMetaData
 	tlsDisable    bool
 
-	// TODO: locality, outlier, healthchecker
+	outlierDetector atomic.Value // outlierDetectorHolder
+
+	priority       uint32
+	locality       string
+	localityWeight uint32
+
+	// TODO: healthchecker
 }
 
 func newHostInfo(addr net.Addr, config v2.Host, clusterInfo types.ClusterInfo) hostInfo {
@@ -231,6 +245,10 @@ func newHostInfo(addr net.Addr, config v2.Host, clusterInfo types.ClusterInfo) h
 		stats:         newHostStats(config),
 		metaData:      GenerateHostMetadata(config.MetaData),
 		tlsDisable:    config.TLSDisable,
+
+		priority:       config.Priority,
+		locality:       config.Locality,
+		localityWeight: config.LocalityWeight,
 	}
 }
 
@@ -251,6 +269,10 @@ func (hi *hostInfo) ClusterInfo() types.ClusterInfo {
 }
 
 func (hi *hostInfo) OutlierDetector() types.DetectorHostMonitor {
+	if holder, ok := hi.outlierDetector.Load().(outlierDetectorHolder); ok {
+		return holder.monitor
+	}
+
 	return nil
 }
 
@@ -270,6 +292,18 @@ func (hi *hostInfo) HostStats() types.HostStats {
 	return hi.stats
 }
 
+func (hi *hostInfo) Priority() uint32 {
+	return hi.priority
+}
+
+func (hi *hostInfo) Locality() string {
+	return hi.locality
+}
+
+func (hi *hostInfo) LocalityWeight() uint32 {
+	return hi.localityWeight
+}
+
 // GenerateHostMetadata
 // generate host's metadata in map[string]types.HashedValue type
 func GenerateHostMetadata(metadata v2.Metadata) types.RouteMetaData {
@@ -18,7 +18,10 @@
 package cluster
 
 import (
+	"hash/fnv"
 	"math/rand"
+	"sort"
+	"strconv"
 
 	"time"
 
@@ -35,6 +38,12 @@ func NewLoadBalancer(lbType types.LoadBalancerType, prioritySet types.PrioritySe
 	switch lbType {
 	case types.RoundRobin:
 		return newSmoothWeightedRRLoadBalancer(prioritySet)
+	case types.RingHash:
+		return newRingHashLoadBalancer(prioritySet)
+	case types.Maglev:
+		return newMaglevLoadBalancer(prioritySet)
+	case types.LeastRequest:
+		return newLeastRequestLoadBalancer(prioritySet)
 	default:
 		return newRandomLoadbalancer(prioritySet)
 	}
@@ -44,6 +53,138 @@ type loadbalancer struct {
 	prioritySet types.PrioritySet
 }
 
+// defaultOverprovisioningFactor scales the healthy percentage of priorities and localities, in percent.
+// With the factor 140, a priority takes all the traffic as long as more than 1/1.4 (about 71%) of its hosts are healthy.
+const defaultOverprovisioningFactor = 140
+
+// chooseHealthyHosts returns the healthy hosts of the priority and the locality chosen by the values
+func (lb *loadbalancer) chooseHealthyHosts(priorityValue uint64, localityValue uint64) []types.Host {
+	hostSets := lb.prioritySet.HostSetsByPriority()
+
+	priority := choosePriority(hostSets, priorityValue)
+	if priority < 0 {
+		return nil
+	}
+
+	hostSet := hostSets[priority]
+	healthyHostsPerLocality := hostSet.HealthHostsPerLocality()
+	if locality := chooseLocality(hostSet.HostsPerLocality(), healthyHostsPerLocality, localityValue); locality >= 0 {
+		return healthyHostsPerLocality[locality]
+	}
+
+	return hostSet.HealthyHosts()
+}
+
+// healthPercent returns the healthy percentage scaled by the overprovisioning factor, no more than 100
+func healthPercent(healthy int, total int) uint64 {
+	if total == 0 {
+		return 0
+	}
+
+	if percent := uint64(defaultOverprovisioningFactor * healthy / total); percent < 100 {
+		return percent
+	}
+
+	return 100
+}
+
+// choosePriority chooses a priority by value in proportion to the priority load.
+// The traffic stays on priority 0 until its health percentage drops below 100, and the missing part
+// spills over to the lower priorities in order. If the total health of all priorities is less than 100,
+// the load is normalized by the total health.
+// It returns -1 if there is no priority with healthy hosts.
+func choosePriority(hostSets []types.HostSet, value uint64) int {
+	switch len(hostSets) {
+	case 0:
+		return -1
+	case 1:
+		return 0
+	}
+
+	var totalHealth uint64
+	for _, hostSet := range hostSets {
+		totalHealth += healthPercent(len(hostSet.HealthyHosts()), len(hostSet.Hosts()))
+	}
+
+	if totalHealth == 0 {
+		return -1
+	}
+
+	if totalHealth > 100 {
+		totalHealth = 100
+	}
+
+	first := -1
+	remaining := uint64(100)
+	value %= 100
+
+	for i, hostSet := range hostSets {
+		health := healthPercent(len(hostSet.HealthyHosts()), len(hostSet.Hosts()))
+		if health == 0 {
+			continue
+		}
+
+		if first < 0 {
+			first = i
+		}
+
+		load := health * 100 / totalHealth
+		if load > remaining {
+			load = remaining
+		}
+
+		if value < load {
+			return i
+		}
+
+		value -= load
+		remaining -= load
+	}
+
+	// the load left by rounding belongs to the first priority with healthy hosts
+	return first
+}
+
+// chooseLocality chooses a locality by value in proportion to the locality weights, the weight of
+// a locality is scaled by its health percentage, so the traffic moves away from the locality with too
+// many unhealthy hosts.
+// It returns -1 if the locality weights are not set, the healthy hosts of the priority should be used.
+func chooseLocality(hostsPerLocality [][]types.Host, healthyHostsPerLocality [][]types.Host, value uint64) int {
+	if len(hostsPerLocality) == 0 || len(hostsPerLocality) != len(healthyHostsPerLocality) {
+		return -1
+	}
+
+	var totalWeight uint64
+	for i, hosts := range hostsPerLocality {
+		totalWeight += localityWeight(hosts, healthyHostsPerLocality[i])
+	}
+
+	if totalWeight == 0 {
+		return -1
+	}
+
+	value %= totalWeight
+	for i, hosts := range hostsPerLocality {
+		weight := localityWeight(hosts, healthyHostsPerLocality[i])
+		if value < weight {
+			return i
+		}
+		value -= weight
+	}
+
+	return -1
+}
+
+// localityWeight returns the weight of a locality scaled by its health percentage,
+// all the hosts in a locality share the same locality weight
+func localityWeight(hosts []types.Host, healthyHosts []types.Host) uint64 {
+	if len(hosts) == 0 {
+		return 0
+	}
+
+	return uint64(hosts[0].LocalityWeight()) * healthPercent(len(healthyHosts), len(hosts))
+}
+
 type randomLoadBalancer struct {
 	loadbalancer
 	randInstance *rand.Rand
@@ -60,16 +201,10 @@ func newRandomLoadbalancer(prioritySet types.PrioritySet) types.LoadBalancer {
 }
 
 func (l *randomLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
-	hostSets := l.prioritySet.HostSetsByPriority()
-	if len(hostSets) == 0 {
-		return nil
-	}
-
 	l.randMutex.Lock()
 	defer l.randMutex.Unlock()
-	idx := l.randInstance.Intn(len(hostSets))
-	hostset := hostSets[idx]
-	hosts := hostset.HealthyHosts()
+
+	hosts := l.chooseHealthyHosts(uint64(l.randInstance.Int63()), uint64(l.randInstance.Int63()))
 	//logger := log.ByContext(context)
 
 	if len(hosts) == 0 {
@@ -233,30 +368,29 @@ func (l *smoothWeightedRRLoadBalancer) ChooseHost(context types.LoadBalancerCont
 	var selectedHostWeighted *hostSmoothWeighted
 	var selectedHost types.Host
 
-	hostSets := l.prioritySet.HostSetsByPriority()
-	for _, hosts := range hostSets {
-		for _, host := range hosts.HealthyHosts() {
-
-			if _, ok := l.hostsWeighted[host.AddressString()]; !ok {
-				// insert new health-host in case UpdateHost not timely
-				l.hostsWeighted[host.AddressString()] = &hostSmoothWeighted{
-					weight:          int(host.Weight()),
-					effectiveWeight: int(host.Weight()),
-				}
+	// the priority and the locality are chosen randomly, the round robin works among their healthy hosts
+	hosts := l.chooseHealthyHosts(uint64(rand.Int63()), uint64(rand.Int63()))
+	for _, host := range hosts {
+
+		if _, ok := l.hostsWeighted[host.AddressString()]; !ok {
+			// insert new health-host in case UpdateHost not timely
+			l.hostsWeighted[host.AddressString()] = &hostSmoothWeighted{
+				weight:          int(host.Weight()),
+				effectiveWeight: int(host.Weight()),
 			}
+		}
 
-			hostW, _ := l.hostsWeighted[host.AddressString()]
-			hostW.currentWeight += hostW.effectiveWeight
-			totalWeight += hostW.effectiveWeight
+		hostW, _ := l.hostsWeighted[host.AddressString()]
+		hostW.currentWeight += hostW.effectiveWeight
+		totalWeight += hostW.effectiveWeight
 
-			if hostW.effectiveWeight < hostW.weight {
-				hostW.effectiveWeight++
-			}
+		if hostW.effectiveWeight < hostW.weight {
+			hostW.effectiveWeight++
+		}
 
-			if selectedHostWeighted == nil || hostW.currentWeight > selectedHostWeighted.currentWeight {
-				selectedHostWeighted = hostW
-				selectedHost = host
-			}
+		if selectedHostWeighted == nil || hostW.currentWeight > selectedHostWeighted.currentWeight {
+			selectedHostWeighted = hostW
+			selectedHost = host
 		}
 	}
 
@@ -267,3 +401,402 @@ func (l *smoothWeightedRRLoadBalancer) ChooseHost(context types.LoadBalancerCont
 	selectedHostWeighted.currentWeight -= totalWeight
 	return selectedHost
 }
+
+// hashKey hashes s into uint64, fnv-1a is mixed by the murmur3 finalizer for a better distribution
+func hashKey(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	k := h.Sum64()
+
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+
+	return k
+}
+
+// hostWeight treats the zero weight as 1, as the weight is optional in host config
+func hostWeight(host types.Host) uint64 {
+	if w := host.Weight(); w > 0 {
+		return uint64(w)
+	}
+	return 1
+}
+
+// consistentHashTable chooses host by hash
+type consistentHashTable interface {
+	chooseHost(hash uint64) types.Host
+}
+
+// consistentHashLoadBalancer is the common part of ring hash and maglev load balancers.
+// A table is built for each priority on member updated, the priority is chosen by the hash as well,
+// and the locality weights are ignored. If no hash key generated by the context, a random hash is used.
+type consistentHashLoadBalancer struct {
+	loadbalancer
+	newTable func(hosts []types.Host) consistentHashTable
+	// tables stores []consistentHashTable, indexed by priority
+	tables atomic.Value
+	mux    sync.Mutex
+}
+
+func newConsistentHashLoadBalancer(prioritySet types.PrioritySet,
+	newTable func(hosts []types.Host) consistentHashTable) *consistentHashLoadBalancer {
+	lb := &consistentHashLoadBalancer{
+		loadbalancer: loadbalancer{
+			prioritySet: prioritySet,
+		},
+		newTable: newTable,
+	}
+
+	lb.refresh()
+	prioritySet.AddMemberUpdateCb(func(priority uint32, hostsAdded []types.Host, hostsRemoved []types.Host) {
+		lb.refresh()
+	})
+
+	return lb
+}
+
+func (lb *consistentHashLoadBalancer) refresh() {
+	lb.mux.Lock()
+	defer lb.mux.Unlock()
+
+	hostSets := lb.prioritySet.HostSetsByPriority()
+	tables := make([]consistentHashTable, len(hostSets))
+
+	for i, hostSet := range hostSets {
+		if hosts := hostSet.HealthyHosts(); len(hosts) > 0 {
+			tables[i] = lb.newTable(hosts)
+		}
+	}
+
+	lb.tables.Store(tables)
+}
+
+func (lb *consistentHashLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	tables, _ := lb.tables.Load().([]consistentHashTable)
+
+	var hash uint64
+	if context != nil {
+		if key := context.ComputeHashKey(); key != "" {
+			hash = hashKey(string(key))
+		} else {
+			hash = uint64(rand.Int63())
+		}
+	} else {
+		hash = uint64(rand.Int63())
+	}
+
+	if priority := choosePriority(lb.prioritySet.HostSetsByPriority(), hash); priority >= 0 &&
+		priority < len(tables) && tables[priority] != nil {
+		return tables[priority].chooseHost(hash)
+	}
+
+	// the tables may be stale, fallback to the highest priority with healthy hosts
+	for _, table := range tables {
+		if table != nil {
+			return table.chooseHost(hash)
+		}
+	}
+
+	return nil
+}
+
+// default entries on ring of ring hash load balancer
+const (
+	defaultRingHashReplicas = 160
+	defaultMaxRingSize      = 1024 * 1024 * 8
+)
+
+func newRingHashLoadBalancer(prioritySet types.PrioritySet) types.LoadBalancer {
+	return newConsistentHashLoadBalancer(prioritySet, func(hosts []types.Host) consistentHashTable {
+		return newHashRing(hosts, defaultRingHashReplicas, defaultMaxRingSize)
+	})
+}
+
+type ringEntry struct {
+	hash uint64
+	host types.Host
+}
+
+// hashRing is the ketama style consistent hash ring, a hash is mapped to the first entry clockwise.
+// The host with the least weight has replicas entries on the ring, and others are in proportion to their weights,
+// so the entries of a host are not changed when other hosts are added or removed.
+type hashRing struct {
+	entries []ringEntry
+}
+
+func newHashRing(hosts []types.Host, replicas, maxRingSize uint64) *hashRing {
+	var totalWeight, minWeight uint64
+	for _, host := range hosts {
+		w := hostWeight(host)
+		totalWeight += w
+		if minWeight == 0 || w < minWeight {
+			minWeight = w
+		}
+	}
+
+	scale := float64(replicas) / float64(minWeight)
+	if float64(totalWeight)*scale > float64(maxRingSize) {
+		scale = float64(maxRingSize) / float64(totalWeight)
+	}
+
+	ring := &hashRing{
+		entries: make([]ringEntry, 0, int(float64(totalWeight)*scale)+len(hosts)),
+	}
+
+	for _, host := range hosts {
+		address := host.AddressString()
+
+		count := int(scale*float64(hostWeight(host)) + 0.5)
+		if count == 0 {
+			count = 1
+		}
+
+		for i := 0; i < count; i++ {
+			ring.entries = append(ring.entries, ringEntry{
+				hash: hashKey(address + "_" + strconv.Itoa(i)),
+				host: host,
+			})
+		}
+	}
+
+	sort.Slice(ring.entries, func(i, j int) bool {
+		return ring.entries[i].hash < ring.entries[j].hash
+	})
+
+	return ring
+}
+
+func (r *hashRing) chooseHost(hash uint64) types.Host {
+	if len(r.entries) == 0 {
+		return nil
+	}
+
+	idx := sort.Search(len(r.entries), func(i int) bool {
+		return r.entries[i].hash >= hash
+	})
+	if idx == len(r.entries) {
+		idx = 0
+	}
+
+	return r.entries[idx].host
+}
+
+// maglevTableSize should be a prime number much larger than the hosts number
+const maglevTableSize = 65537
+
+func newMaglevLoadBalancer(prioritySet types.PrioritySet) types.LoadBalancer {
+	return newConsistentHashLoadBalancer(prioritySet, func(hosts []types.Host) consistentHashTable {
+		return newMaglevTable(hosts, maglevTableSize)
+	})
+}
+
+// maglevTable is the lookup table described in the Maglev paper, the table is
+// filled by the permutation of each host in turn, weights are supported by skipping
+// the hosts with lower weight in some turns.
+type maglevTable struct {
+	table []types.Host
+}
+
+type maglevEntry struct {
+	host   types.Host
+	offset uint64
+	skip   uint64
+	next   uint64
+	weight uint64
+	target uint64
+}
+
+func newMaglevTable(hosts []types.Host, tableSize uint64) *maglevTable {
+	entries := make([]*maglevEntry, 0, len(hosts))
+
+	var maxWeight uint64
+	for _, host := range hosts {
+		address := host.AddressString()
+		w := hostWeight(host)
+		if w > maxWeight {
+			maxWeight = w
+		}
+
+		entries = append(entries, &maglevEntry{
+			host:   host,
+			offset: hashKey(address+"_offset") % tableSize,
+			skip:   hashKey(address+"_skip")%(tableSize-1) + 1,
+			weight: w,
+		})
+	}
+
+	m := &maglevTable{
+		table: make([]types.Host, tableSize),
+	}
+
+	var filled uint64
+	for iteration := uint64(1); filled < tableSize; iteration++ {
+		for _, entry := range entries {
+			// a host with max weight is picked in every iteration, and a host with
+			// a third of max weight is picked every three iterations
+			if iteration*entry.weight < entry.target {
+				continue
+			}
+			entry.target += maxWeight
+
+			c := (entry.offset + entry.next*entry.skip) % tableSize
+			for m.table[c] != nil {
+				entry.next++
+				c = (entry.offset + entry.next*entry.skip) % tableSize
+			}
+
+			m.table[c] = entry.host
+			entry.next++
+			filled++
+
+			if filled == tableSize {
+				break
+			}
+		}
+	}
+
+	return m
+}
+
+func (m *maglevTable) chooseHost(hash uint64) types.Host {
+	if len(m.table) == 0 {
+		return nil
+	}
+
+	return m.table[hash%uint64(len(m.table))]
+}
+
+// leastRequestLoadBalancer picks two hosts randomly in proportion to their weights (power of two choices),
+// and chooses the one with fewer active requests per weight.
+type leastRequestLoadBalancer struct {
+	loadbalancer
+	// tables stores []*leastRequestTable, indexed by priority
+	tables       atomic.Value
+	randInstance *rand.Rand
+	randMutex    sync.Mutex
+}
+
+// weightedHosts is used to pick a host randomly in proportion to weights
+type weightedHosts struct {
+	hosts []types.Host
+	// cumulativeWeights[i] is the sum of weights of hosts[0:i+1]
+	cumulativeWeights []uint64
+}
+
+func newWeightedHosts(hosts []types.Host) *weightedHosts {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	wh := &weightedHosts{
+		hosts:             hosts,
+		cumulativeWeights: make([]uint64, len(hosts)),
+	}
+
+	var total uint64
+	for i, host := range hosts {
+		total += hostWeight(host)
+		wh.cumulativeWeights[i] = total
+	}
+
+	return wh
+}
+
+// leastRequestTable holds the weighted healthy hosts of a priority, and of each locality in the priority
+type leastRequestTable struct {
+	hosts      *weightedHosts
+	localities []*weightedHosts
+}
+
+func newLeastRequestLoadBalancer(prioritySet types.PrioritySet) types.LoadBalancer {
+	lb := &leastRequestLoadBalancer{
+		loadbalancer: loadbalancer{
+			prioritySet: prioritySet,
+		},
+		randInstance: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	lb.refresh()
+	prioritySet.AddMemberUpdateCb(func(priority uint32, hostsAdded []types.Host, hostsRemoved []types.Host) {
+		lb.refresh()
+	})
+
+	return lb
+}
+
+func (lb *leastRequestLoadBalancer) refresh() {
+	hostSets := lb.prioritySet.HostSetsByPriority()
+	tables := make([]*leastRequestTable, len(hostSets))
+
+	for i, hostSet := range hostSets {
+		table := &leastRequestTable{
+			hosts: newWeightedHosts(hostSet.HealthyHosts()),
+		}
+
+		for _, hosts := range hostSet.HealthHostsPerLocality() {
+			table.localities = append(table.localities, newWeightedHosts(hosts))
+		}
+		tables[i] = table
+	}
+
+	lb.tables.Store(tables)
+}
+
+func (lb *leastRequestLoadBalancer) ChooseHost(context types.LoadBalancerContext) types.Host {
+	tables, _ := lb.tables.Load().([]*leastRequestTable)
+	hostSets := lb.prioritySet.HostSetsByPriority()
+
+	lb.randMutex.Lock()
+	defer lb.randMutex.Unlock()
+
+	priority := choosePriority(hostSets, uint64(lb.randInstance.Int63()))
+	if priority < 0 || priority >= len(tables) {
+		return nil
+	}
+
+	table := tables[priority]
+	wh := table.hosts
+
+	hostSet := hostSets[priority]
+	locality := chooseLocality(hostSet.HostsPerLocality(), hostSet.HealthHostsPerLocality(), uint64(lb.randInstance.Int63()))
+	if locality >= 0 && locality < len(table.localities) && table.localities[locality] != nil {
+		wh = table.localities[locality]
+	}
+
+	if wh == nil {
+		return nil
+	}
+
+	if len(wh.hosts) == 1 {
+		return wh.hosts[0]
+	}
+
+	total := wh.cumulativeWeights[len(wh.cumulativeWeights)-1]
+	first := wh.pick(uint64(lb.randInstance.Int63n(int64(total))))
+	second := wh.pick(uint64(lb.randInstance.Int63n(int64(total))))
+
+	// compare active1 / weight1 with active2 / weight2
+	if activeRequests(second)*hostWeight(first) < activeRequests(first)*hostWeight(second) {
+		return second
+	}
+
+	return first
+}
+
+func activeRequests(host types.Host) uint64 {
+	if active := host.HostStats().UpstreamRequestActive.Count(); active > 0 {
+		return uint64(active)
+	}
+	return 0
+}
+
+func (wh *weightedHosts) pick(value uint64) types.Host {
+	idx := sort.Search(len(wh.cumulativeWeights), func(i int) bool {
+		return wh.cumulativeWeights[i] > value
+	})
+
+	return wh.hosts[idx]
+}
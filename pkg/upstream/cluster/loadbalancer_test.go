@@ -18,6 +18,8 @@
 package cluster
 
 import (
+	"fmt"
+	"strconv"
 	"testing"
 
 	"math"
@@ -460,3 +462,294 @@ func Benchmark_RouteAndLB(b *testing.B) {
 		}
 	}
 }
+
+func newConsistentHashTestHosts(count int) (*prioritySet, []types.Host) {
+	var hosts []types.Host
+	for i := 0; i < count; i++ {
+		hosts = append(hosts, NewHost(v2.Host{Address: fmt.Sprintf("127.0.0.%d:8080", i+1), Weight: 1}, nil))
+	}
+
+	ps := &prioritySet{}
+	ps.GetOrCreateHostSet(0).UpdateHosts(hosts, hosts, nil, nil, hosts, nil)
+
+	return ps, hosts
+}
+
+func testConsistentHashLoadBalancer(t *testing.T, lbType types.LoadBalancerType) {
+	ps, hosts := newConsistentHashTestHosts(5)
+	lb := NewLoadBalancer(lbType, ps)
+
+	// the same key is always routed to the same host
+	chosen := make(map[string]types.Host)
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		host := lb.ChooseHost(&ContextImplMock{hashKey: types.HashedValue(key)})
+		if host == nil {
+			t.Fatalf("no host chosen for %s", key)
+		}
+		if again := lb.ChooseHost(&ContextImplMock{hashKey: types.HashedValue(key)}); again != host {
+			t.Fatalf("key %s is routed to different hosts", key)
+		}
+		chosen[key] = host
+	}
+
+	// remove a host, only the keys on the removed host should be moved
+	removed := hosts[0]
+	remains := hosts[1:]
+	ps.GetOrCreateHostSet(0).UpdateHosts(remains, remains, nil, nil, nil, []types.Host{removed})
+
+	moved := 0
+	for key, host := range chosen {
+		got := lb.ChooseHost(&ContextImplMock{hashKey: types.HashedValue(key)})
+		if got == removed {
+			t.Fatalf("key %s is routed to the removed host", key)
+		}
+		if host != removed && got != host {
+			moved++
+		}
+	}
+	// maglev may move a few keys of the remaining hosts
+	if float64(moved)/float64(len(chosen)) > 0.05 {
+		t.Errorf("too many keys moved after a host removed: %d", moved)
+	}
+}
+
+func TestRingHashLoadBalancer(t *testing.T) {
+	testConsistentHashLoadBalancer(t, types.RingHash)
+}
+
+func TestMaglevLoadBalancer(t *testing.T) {
+	testConsistentHashLoadBalancer(t, types.Maglev)
+}
+
+func TestConsistentHashTableWeight(t *testing.T) {
+	hosts := []types.Host{
+		NewHost(v2.Host{Address: "127.0.0.1:8080", Weight: 1}, nil),
+		NewHost(v2.Host{Address: "127.0.0.2:8080", Weight: 3}, nil),
+	}
+
+	tables := map[string]consistentHashTable{
+		"ringhash": newHashRing(hosts, defaultRingHashReplicas, defaultMaxRingSize),
+		"maglev":   newMaglevTable(hosts, maglevTableSize),
+	}
+
+	for name, table := range tables {
+		count := make(map[types.Host]int)
+		for i := 0; i < 10000; i++ {
+			count[table.chooseHost(hashKey(strconv.Itoa(i)))]++
+		}
+
+		ratio := float64(count[hosts[1]]) / float64(count[hosts[0]])
+		if ratio < 2 || ratio > 4 {
+			t.Errorf("%s: expect the ratio of weighted hosts close to 3, but got %f", name, ratio)
+		}
+	}
+}
+
+func TestConsistentHashLoadBalancerNoHealthyHost(t *testing.T) {
+	ps := &prioritySet{}
+	ps.GetOrCreateHostSet(0)
+
+	lb := NewLoadBalancer(types.RingHash, ps)
+	if host := lb.ChooseHost(&ContextImplMock{hashKey: "key"}); host != nil {
+		t.Errorf("expect no host chosen, but got %s", host.AddressString())
+	}
+}
+
+func TestLeastRequestLoadBalancer(t *testing.T) {
+	var hosts []types.Host
+	for i := 0; i < 2; i++ {
+		hosts = append(hosts, NewHost(v2.Host{Address: fmt.Sprintf("127.0.1.%d:8080", i+1), Weight: 1}, nil))
+	}
+	ps := &prioritySet{}
+	ps.GetOrCreateHostSet(0).UpdateHosts(hosts, hosts, nil, nil, hosts, nil)
+
+	lb := NewLoadBalancer(types.LeastRequest, ps)
+
+	// the host with fewer active requests is chosen if both hosts are picked
+	hosts[0].HostStats().UpstreamRequestActive.Inc(10)
+	defer hosts[0].HostStats().UpstreamRequestActive.Dec(10)
+
+	count := make(map[types.Host]int)
+	for i := 0; i < 1000; i++ {
+		count[lb.ChooseHost(nil)]++
+	}
+	// host0 is chosen only if it is picked twice
+	if count[hosts[0]] > 350 {
+		t.Errorf("the busy host is chosen too many times: %d", count[hosts[0]])
+	}
+}
+
+func TestLeastRequestLoadBalancerWeight(t *testing.T) {
+	hosts := []types.Host{
+		NewHost(v2.Host{Address: "127.0.2.1:8080", Weight: 1}, nil),
+		NewHost(v2.Host{Address: "127.0.2.2:8080", Weight: 4}, nil),
+	}
+	ps := &prioritySet{}
+	ps.GetOrCreateHostSet(0).UpdateHosts(hosts, hosts, nil, nil, hosts, nil)
+
+	lb := NewLoadBalancer(types.LeastRequest, ps)
+
+	// no active requests, hosts are picked in proportion to weights
+	count := make(map[types.Host]int)
+	for i := 0; i < 10000; i++ {
+		count[lb.ChooseHost(nil)]++
+	}
+	if count[hosts[1]] < count[hosts[0]]*3 {
+		t.Errorf("expect the weighted host chosen more, but got %d vs %d", count[hosts[1]], count[hosts[0]])
+	}
+
+	// the weighted host has the same load per weight
+	hosts[0].HostStats().UpstreamRequestActive.Inc(1)
+	hosts[1].HostStats().UpstreamRequestActive.Inc(8)
+	defer hosts[0].HostStats().UpstreamRequestActive.Dec(1)
+	defer hosts[1].HostStats().UpstreamRequestActive.Dec(8)
+
+	count = make(map[types.Host]int)
+	for i := 0; i < 10000; i++ {
+		count[lb.ChooseHost(nil)]++
+	}
+	if count[hosts[0]] < 2000 {
+		t.Errorf("expect the less loaded host chosen more, but got %d", count[hosts[0]])
+	}
+}
+
+func newPriorityTestHostSet(priority uint32, total int, healthy int) types.HostSet {
+	var hosts []types.Host
+	for i := 0; i < total; i++ {
+		hosts = append(hosts, NewHost(v2.Host{Address: fmt.Sprintf("127.0.%d.%d:8080", priority+3, i+1)}, nil))
+	}
+
+	return &hostSet{
+		priority:     priority,
+		hosts:        hosts,
+		healthyHosts: hosts[:healthy],
+	}
+}
+
+func TestChoosePriority(t *testing.T) {
+	testCases := []struct {
+		p0Healthy int
+		p1Healthy int
+		wantP0    int
+	}{
+		{10, 10, 100},
+		// 80% * 1.4 > 100%, spillover is not triggered
+		{8, 10, 100},
+		// 50% * 1.4 = 70%, the other 30% spills over to priority 1
+		{5, 10, 70},
+		{0, 10, 0},
+		// the total health 70% + 28% is normalized to 100%, the rounding load goes to priority 0
+		{5, 2, 72},
+	}
+
+	for _, tc := range testCases {
+		hostSets := []types.HostSet{
+			newPriorityTestHostSet(0, 10, tc.p0Healthy),
+			newPriorityTestHostSet(1, 10, tc.p1Healthy),
+		}
+
+		count := make([]int, 2)
+		for value := uint64(0); value < 100; value++ {
+			count[choosePriority(hostSets, value)]++
+		}
+
+		if count[0] != tc.wantP0 {
+			t.Errorf("healthy hosts %d/%d, expect priority 0 load %d, but got %d", tc.p0Healthy, tc.p1Healthy, tc.wantP0, count[0])
+		}
+	}
+
+	if p := choosePriority([]types.HostSet{newPriorityTestHostSet(0, 10, 0), newPriorityTestHostSet(1, 10, 0)}, 0); p != -1 {
+		t.Errorf("expect no priority chosen, but got %d", p)
+	}
+}
+
+func TestLocalityWeightedLoadBalancer(t *testing.T) {
+	sc := newSimpleInMemCluster(v2.Cluster{
+		Name:        "locality_weighted",
+		ClusterType: v2.SIMPLE_CLUSTER,
+		LbType:      v2.LB_RANDOM,
+	}, nil, true)
+
+	var hosts []types.Host
+	for i := 0; i < 4; i++ {
+		hosts = append(hosts, NewHost(v2.Host{
+			Address:        fmt.Sprintf("127.0.5.%d:8080", i+1),
+			Locality:       "region/zone1/",
+			LocalityWeight: 1,
+		}, sc.Info()))
+		hosts = append(hosts, NewHost(v2.Host{
+			Address:        fmt.Sprintf("127.0.6.%d:8080", i+1),
+			Locality:       "region/zone2/",
+			LocalityWeight: 3,
+		}, sc.Info()))
+	}
+	backup := NewHost(v2.Host{Address: "127.0.7.1:8080", Priority: 1}, sc.Info())
+	sc.UpdateHosts(append(hosts, backup))
+
+	hostSets := sc.PrioritySet().HostSetsByPriority()
+	if len(hostSets) != 2 || len(hostSets[0].HostsPerLocality()) != 2 || len(hostSets[1].Hosts()) != 1 {
+		t.Fatalf("unexpected host sets: %d", len(hostSets))
+	}
+
+	countLocality := func() map[string]int {
+		count := make(map[string]int)
+		for i := 0; i < 10000; i++ {
+			count[sc.Info().LBInstance().ChooseHost(nil).Locality()]++
+		}
+		return count
+	}
+
+	count := countLocality()
+	if count["region/zone1/"] < 2000 || count["region/zone1/"] > 3000 {
+		t.Errorf("expect zone1 takes 1/4 traffic, but got %d", count["region/zone1/"])
+	}
+
+	// half of zone2 is unhealthy, its weight is scaled to 3 * 70%
+	for i := 0; i < 2; i++ {
+		hosts[2*i+1].SetHealthFlag(types.FAILED_ACTIVE_HC)
+		sc.refreshHealthHosts(hosts[2*i+1])
+	}
+
+	count = countLocality()
+	if count["region/zone1/"] < 2800 || count["region/zone1/"] > 3800 {
+		t.Errorf("expect zone1 takes 1/3.1 traffic, but got %d", count["region/zone1/"])
+	}
+	if count[""] != 0 {
+		t.Errorf("expect no traffic to the lower priority, but got %d", count[""])
+	}
+}
+
+// hostPredicateContextMock rejects the hosts in rejected
+type hostPredicateContextMock struct {
+	ContextImplMock
+	rejected map[string]bool
+}
+
+func (ci *hostPredicateContextMock) ShouldSelectAnotherHost(host types.Host) bool {
+	return ci.rejected[host.AddressString()]
+}
+
+func (ci *hostPredicateContextMock) HostSelectionRetryCount() int {
+	return 3
+}
+
+func TestChooseHostWithPredicate(t *testing.T) {
+	ps, hosts := newConsistentHashTestHosts(2)
+	lb := NewLoadBalancer(types.RoundRobin, ps)
+
+	ctx := &hostPredicateContextMock{
+		rejected: map[string]bool{hosts[0].AddressString(): true},
+	}
+	for i := 0; i < 10; i++ {
+		if host := chooseHost(lb, ctx); host != hosts[1] {
+			t.Fatalf("expected the host not rejected, got %s", host.AddressString())
+		}
+	}
+
+	// the last selected host is used if all the hosts are rejected
+	ctx.rejected[hosts[1].AddressString()] = true
+	if host := chooseHost(lb, ctx); host == nil {
+		t.Error("expected a host even if all the hosts are rejected")
+	}
+}
@@ -0,0 +1,498 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// Default values of outlier detection, used if the config value is zero
+const (
+	defaultConsecutive5xx            = 5
+	defaultInterval                  = 10 * time.Second
+	defaultBaseEjectionTime          = 30 * time.Second
+	defaultMaxEjectionPercent        = 10
+	defaultConsecutiveGatewayFailure = 5
+	defaultEnforcingConsecutive5xx   = 100
+	defaultEnforcingSuccessRate      = 100
+	defaultSuccessRateMinimumHosts   = 5
+	defaultSuccessRateRequestVolume  = 100
+	defaultSuccessRateStdevFactor    = 1900
+)
+
+// ejectType is the reason of an ejection
+type ejectType string
+
+const (
+	ejectConsecutive5xx            ejectType = "consecutive_5xx"
+	ejectConsecutiveGatewayFailure ejectType = "consecutive_gateway_failure"
+	ejectSuccessRate               ejectType = "success_rate"
+)
+
+func isOutlierDetectionEnabled(config v2.OutlierDetection) bool {
+	return config != v2.OutlierDetection{}
+}
+
+func setOutlierDetectionDefaults(config *v2.OutlierDetection) {
+	if config.Consecutive5xx == 0 {
+		config.Consecutive5xx = defaultConsecutive5xx
+	}
+	if config.Interval == 0 {
+		config.Interval = defaultInterval
+	}
+	if config.BaseEjectionTime == 0 {
+		config.BaseEjectionTime = defaultBaseEjectionTime
+	}
+	if config.MaxEjectionPercent == 0 {
+		config.MaxEjectionPercent = defaultMaxEjectionPercent
+	}
+	if config.ConsecutiveGatewayFailure == 0 {
+		config.ConsecutiveGatewayFailure = defaultConsecutiveGatewayFailure
+	}
+	if config.EnforcingConsecutive5xx == 0 {
+		config.EnforcingConsecutive5xx = defaultEnforcingConsecutive5xx
+	}
+	// consecutive gateway failure is not enforced by default
+	if config.EnforcingSuccessRate == 0 {
+		config.EnforcingSuccessRate = defaultEnforcingSuccessRate
+	}
+	if config.SuccessRateMinimumHosts == 0 {
+		config.SuccessRateMinimumHosts = defaultSuccessRateMinimumHosts
+	}
+	if config.SuccessRateRequestVolume == 0 {
+		config.SuccessRateRequestVolume = defaultSuccessRateRequestVolume
+	}
+	if config.SuccessRateStdevFactor == 0 {
+		config.SuccessRateStdevFactor = defaultSuccessRateStdevFactor
+	}
+}
+
+// types.Detector
+// outlierDetector ejects the hosts by consecutive 5xx, consecutive gateway failure and success rate.
+// An ejected host is brought back after BaseEjectionTime * NumEjections, and NumEjections decreases
+// on each interval the host is not ejected.
+type outlierDetector struct {
+	cluster *cluster
+	config  v2.OutlierDetection
+
+	// mux guards the host monitors and the ejection state of them
+	mux                          sync.Mutex
+	hostMonitors                 map[types.Host]*detectorHostMonitor
+	callbacks                    []func(host types.Host)
+	successRateAverage           float64
+	successRateEjectionThreshold float64
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+func newOutlierDetector(cluster *cluster, config v2.OutlierDetection) *outlierDetector {
+	setOutlierDetectionDefaults(&config)
+
+	d := &outlierDetector{
+		cluster:                      cluster,
+		config:                       config,
+		hostMonitors:                 make(map[types.Host]*detectorHostMonitor),
+		successRateAverage:           -1,
+		successRateEjectionThreshold: -1,
+		stopChan:                     make(chan struct{}),
+	}
+
+	for _, hostSet := range cluster.prioritySet.HostSetsByPriority() {
+		d.addHosts(hostSet.Hosts())
+	}
+
+	cluster.prioritySet.AddMemberUpdateCb(func(priority uint32, hostsAdded []types.Host, hostsRemoved []types.Host) {
+		d.addHosts(hostsAdded)
+		d.removeHosts(hostsRemoved)
+	})
+
+	return d
+}
+
+func (d *outlierDetector) start() {
+	go func() {
+		ticker := time.NewTicker(d.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				d.onInterval()
+			case <-d.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+// stop stops the detection, brings back the ejected hosts and detaches the host monitors,
+// it is called when the cluster is removed or replaced
+func (d *outlierDetector) stop() {
+	d.stopOnce.Do(func() {
+		close(d.stopChan)
+
+		d.mux.Lock()
+		var unejected []types.Host
+		for host, monitor := range d.hostMonitors {
+			if monitor.ejected {
+				d.unejectLocked(monitor)
+				unejected = append(unejected, host)
+			}
+			detachMonitor(host, monitor)
+		}
+		d.mux.Unlock()
+
+		d.notify(unejected)
+	})
+}
+
+func (d *outlierDetector) AddChangedStateCb(cb func(host types.Host)) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	d.callbacks = append(d.callbacks, cb)
+}
+
+func (d *outlierDetector) SuccessRateAverage() float64 {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	return d.successRateAverage
+}
+
+func (d *outlierDetector) SuccessRateEjectionThreshold() float64 {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	return d.successRateEjectionThreshold
+}
+
+func (d *outlierDetector) addHosts(hosts []types.Host) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	for _, host := range hosts {
+		if _, ok := d.hostMonitors[host]; ok {
+			continue
+		}
+
+		monitor := &detectorHostMonitor{
+			detector:    d,
+			host:        host,
+			successRate: -1,
+		}
+		d.hostMonitors[host] = monitor
+		host.SetOutlierDetector(monitor)
+	}
+}
+
+func (d *outlierDetector) removeHosts(hosts []types.Host) {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+
+	for _, host := range hosts {
+		if monitor, ok := d.hostMonitors[host]; ok {
+			if monitor.ejected {
+				d.unejectLocked(monitor)
+			}
+			detachMonitor(host, monitor)
+			delete(d.hostMonitors, host)
+		}
+	}
+}
+
+// detachMonitor clears the host monitor, unless the host is monitored by another detector already
+func detachMonitor(host types.Host, monitor *detectorHostMonitor) {
+	if current, ok := host.OutlierDetector().(*detectorHostMonitor); ok && current == monitor {
+		host.SetOutlierDetector(nil)
+	}
+}
+
+// stopped returns true if the detector is stopped, a stopped detector never ejects hosts
+func (d *outlierDetector) stopped() bool {
+	select {
+	case <-d.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// onConsecutiveFailure is called by host monitor when the consecutive failures reach the threshold
+func (d *outlierDetector) onConsecutiveFailure(monitor *detectorHostMonitor, reason ejectType) {
+	if d.stopped() {
+		return
+	}
+
+	enforcing := d.config.EnforcingConsecutive5xx
+	if reason == ejectConsecutiveGatewayFailure {
+		enforcing = d.config.EnforcingConsecutiveGatewayFailure
+	}
+
+	d.mux.Lock()
+	ejected := d.ejectLocked(monitor, reason, enforcing)
+	d.mux.Unlock()
+
+	if ejected {
+		d.notify([]types.Host{monitor.host})
+	}
+}
+
+func (d *outlierDetector) onInterval() {
+	now := time.Now()
+	var changed []types.Host
+
+	d.mux.Lock()
+
+	var validRates []float64
+	var validMonitors []*detectorHostMonitor
+
+	for host, monitor := range d.hostMonitors {
+		monitor.updateSuccessRate(d.config.SuccessRateRequestVolume)
+
+		if monitor.ejected {
+			ejectionTime := d.config.BaseEjectionTime * time.Duration(monitor.numEjections)
+			if now.Sub(monitor.lastEjectionTime) >= ejectionTime {
+				d.unejectLocked(monitor)
+				changed = append(changed, host)
+			}
+		} else {
+			if monitor.numEjections > 0 {
+				monitor.numEjections--
+			}
+
+			if monitor.successRate >= 0 {
+				validRates = append(validRates, monitor.successRate)
+				validMonitors = append(validMonitors, monitor)
+			}
+		}
+	}
+
+	d.successRateAverage = -1
+	d.successRateEjectionThreshold = -1
+
+	if len(validRates) > 0 && len(validRates) >= int(d.config.SuccessRateMinimumHosts) {
+		mean, stdev := meanAndStdev(validRates)
+		threshold := mean - stdev*float64(d.config.SuccessRateStdevFactor)/1000
+
+		d.successRateAverage = mean
+		d.successRateEjectionThreshold = threshold
+
+		for _, monitor := range validMonitors {
+			if monitor.successRate < threshold &&
+				d.ejectLocked(monitor, ejectSuccessRate, d.config.EnforcingSuccessRate) {
+				changed = append(changed, monitor.host)
+			}
+		}
+	}
+
+	d.mux.Unlock()
+
+	d.notify(changed)
+}
+
+// ejectLocked ejects the host if the enforcing percentage is hit and the max ejection percent is not reached
+func (d *outlierDetector) ejectLocked(monitor *detectorHostMonitor, reason ejectType, enforcing uint32) bool {
+	if monitor.ejected || d.stopped() {
+		return false
+	}
+
+	if enforcing < 100 && uint32(rand.Intn(100)) >= enforcing {
+		return false
+	}
+
+	stats := d.cluster.info.stats
+
+	ejectedCount := 0
+	for _, m := range d.hostMonitors {
+		if m.ejected {
+			ejectedCount++
+		}
+	}
+	if ejectedCount*100/len(d.hostMonitors) >= int(d.config.MaxEjectionPercent) {
+		stats.OutlierDetectionEjectionsOverflow.Inc(1)
+		return false
+	}
+
+	monitor.ejected = true
+	monitor.numEjections++
+	monitor.lastEjectionTime = time.Now()
+	monitor.host.SetHealthFlag(types.FAILED_OUTLIER_CHECK)
+
+	stats.OutlierDetectionEjectionsTotal.Inc(1)
+	stats.OutlierDetectionEjectionsActive.Inc(1)
+	switch reason {
+	case ejectConsecutive5xx:
+		stats.OutlierDetectionEjectionsConsecutive5xx.Inc(1)
+	case ejectConsecutiveGatewayFailure:
+		stats.OutlierDetectionEjectionsGatewayFailure.Inc(1)
+	case ejectSuccessRate:
+		stats.OutlierDetectionEjectionsSuccessRate.Inc(1)
+	}
+	monitor.host.HostStats().UpstreamRequestFailureEject.Inc(1)
+
+	log.DefaultLogger.Infof("outlier detection ejects host %s of cluster %s, reason = %s, ejections = %d",
+		monitor.host.AddressString(), d.cluster.info.name, reason, monitor.numEjections)
+
+	return true
+}
+
+func (d *outlierDetector) unejectLocked(monitor *detectorHostMonitor) {
+	monitor.ejected = false
+	monitor.resetConsecutive()
+	monitor.host.ClearHealthFlag(types.FAILED_OUTLIER_CHECK)
+
+	d.cluster.info.stats.OutlierDetectionEjectionsActive.Dec(1)
+
+	log.DefaultLogger.Infof("outlier detection brings back host %s of cluster %s",
+		monitor.host.AddressString(), d.cluster.info.name)
+}
+
+// notify updates the healthy hosts and runs the callbacks, it is called without lock,
+// as the member update callbacks of the priority set are called on updating the healthy hosts
+func (d *outlierDetector) notify(hosts []types.Host) {
+	if len(hosts) == 0 {
+		return
+	}
+
+	d.mux.Lock()
+	callbacks := d.callbacks
+	d.mux.Unlock()
+
+	for _, host := range hosts {
+		d.cluster.refreshHealthHosts(host)
+
+		for _, cb := range callbacks {
+			cb(host)
+		}
+	}
+}
+
+func meanAndStdev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+
+	return mean, math.Sqrt(variance)
+}
+
+// types.DetectorHostMonitor
+type detectorHostMonitor struct {
+	detector *outlierDetector
+	host     types.Host
+
+	consecutive5xx            uint32
+	consecutiveGatewayFailure uint32
+	requestTotal              uint64
+	requestSuccess            uint64
+
+	// guarded by detector.mux
+	ejected          bool
+	numEjections     uint32
+	lastEjectionTime time.Time
+	successRate      float64
+}
+
+func (m *detectorHostMonitor) PutResponseCode(code int) {
+	atomic.AddUint64(&m.requestTotal, 1)
+
+	if code < http.StatusInternalServerError {
+		atomic.AddUint64(&m.requestSuccess, 1)
+		m.resetConsecutive()
+		return
+	}
+
+	if code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout {
+		if atomic.AddUint32(&m.consecutiveGatewayFailure, 1) == m.detector.config.ConsecutiveGatewayFailure {
+			m.detector.onConsecutiveFailure(m, ejectConsecutiveGatewayFailure)
+		}
+	} else {
+		atomic.StoreUint32(&m.consecutiveGatewayFailure, 0)
+	}
+
+	if atomic.AddUint32(&m.consecutive5xx, 1) == m.detector.config.Consecutive5xx {
+		m.detector.onConsecutiveFailure(m, ejectConsecutive5xx)
+	}
+}
+
+// PutResult treats the failures as gateway failures
+func (m *detectorHostMonitor) PutResult(result types.OutlierResult) {
+	switch result {
+	case types.ResultConnectFailed, types.ResultRequestFailed:
+		m.PutResponseCode(http.StatusServiceUnavailable)
+	case types.ResultRequestTimeout:
+		m.PutResponseCode(http.StatusGatewayTimeout)
+	}
+}
+
+func (m *detectorHostMonitor) NumEjections() uint32 {
+	m.detector.mux.Lock()
+	defer m.detector.mux.Unlock()
+
+	return m.numEjections
+}
+
+func (m *detectorHostMonitor) LastEjectionTime() time.Time {
+	m.detector.mux.Lock()
+	defer m.detector.mux.Unlock()
+
+	return m.lastEjectionTime
+}
+
+func (m *detectorHostMonitor) SuccessRate() float64 {
+	m.detector.mux.Lock()
+	defer m.detector.mux.Unlock()
+
+	return m.successRate
+}
+
+func (m *detectorHostMonitor) resetConsecutive() {
+	atomic.StoreUint32(&m.consecutive5xx, 0)
+	atomic.StoreUint32(&m.consecutiveGatewayFailure, 0)
+}
+
+// updateSuccessRate calculates the success rate of last interval, -1 if the request volume is not enough
+func (m *detectorHostMonitor) updateSuccessRate(requestVolume uint32) {
+	total := atomic.SwapUint64(&m.requestTotal, 0)
+	success := atomic.SwapUint64(&m.requestSuccess, 0)
+
+	if total == 0 || total < uint64(requestVolume) {
+		m.successRate = -1
+		return
+	}
+
+	m.successRate = float64(success) * 100 / float64(total)
+}
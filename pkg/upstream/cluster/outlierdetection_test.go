@@ -0,0 +1,256 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+func newOutlierTestCluster(name string, hostCount int, config v2.OutlierDetection) (*simpleInMemCluster, []types.Host) {
+	// use a large interval, the intervals are triggered by the test cases
+	config.Interval = time.Hour
+
+	sc := newSimpleInMemCluster(v2.Cluster{
+		Name:             name,
+		ClusterType:      v2.SIMPLE_CLUSTER,
+		LbType:           v2.LB_RANDOM,
+		OutlierDetection: config,
+	}, nil, true)
+
+	var hosts []types.Host
+	for i := 0; i < hostCount; i++ {
+		hosts = append(hosts, NewHost(v2.Host{Address: fmt.Sprintf("127.0.0.%d:8080", i+1)}, sc.Info()))
+	}
+	sc.UpdateHosts(hosts)
+
+	return sc, hosts
+}
+
+func isHealthyHost(sc *simpleInMemCluster, host types.Host) bool {
+	for _, h := range sc.PrioritySet().HostSetsByPriority()[0].HealthyHosts() {
+		if h == host {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestOutlierDetectionConsecutive5xx(t *testing.T) {
+	sc, hosts := newOutlierTestCluster("outlier_consecutive_5xx", 4, v2.OutlierDetection{
+		Consecutive5xx:     3,
+		MaxEjectionPercent: 50,
+	})
+	defer sc.stopOutlierDetector()
+
+	host := hosts[0]
+	monitor := host.OutlierDetector()
+	if monitor == nil {
+		t.Fatal("host monitor is not set")
+	}
+
+	// a success resets the consecutive counter
+	monitor.PutResponseCode(http.StatusInternalServerError)
+	monitor.PutResponseCode(http.StatusInternalServerError)
+	monitor.PutResponseCode(http.StatusOK)
+	monitor.PutResponseCode(http.StatusInternalServerError)
+	if !host.Health() {
+		t.Fatal("host should not be ejected after a success")
+	}
+
+	monitor.PutResponseCode(http.StatusInternalServerError)
+	monitor.PutResponseCode(http.StatusInternalServerError)
+	if host.Health() || !host.ContainHealthFlag(types.FAILED_OUTLIER_CHECK) {
+		t.Fatal("host should be ejected after consecutive 5xx")
+	}
+	if isHealthyHost(sc, host) {
+		t.Error("ejected host should be removed from healthy hosts")
+	}
+	if monitor.NumEjections() != 1 {
+		t.Errorf("expect 1 ejection, but got %d", monitor.NumEjections())
+	}
+
+	stats := sc.Info().Stats()
+	if stats.OutlierDetectionEjectionsActive.Count() != 1 || stats.OutlierDetectionEjectionsConsecutive5xx.Count() != 1 {
+		t.Errorf("unexpected ejection stats, active = %d, consecutive_5xx = %d",
+			stats.OutlierDetectionEjectionsActive.Count(), stats.OutlierDetectionEjectionsConsecutive5xx.Count())
+	}
+
+	// not reached the ejection time
+	sc.outlierDetector.onInterval()
+	if host.Health() {
+		t.Fatal("host should not be brought back before base ejection time")
+	}
+
+	sc.outlierDetector.mux.Lock()
+	sc.outlierDetector.hostMonitors[host].lastEjectionTime = time.Now().Add(-defaultBaseEjectionTime)
+	sc.outlierDetector.mux.Unlock()
+
+	sc.outlierDetector.onInterval()
+	if !host.Health() || !isHealthyHost(sc, host) {
+		t.Error("host should be brought back after base ejection time")
+	}
+	if stats.OutlierDetectionEjectionsActive.Count() != 0 {
+		t.Errorf("expect no active ejection, but got %d", stats.OutlierDetectionEjectionsActive.Count())
+	}
+}
+
+func TestOutlierDetectionGatewayFailure(t *testing.T) {
+	sc, hosts := newOutlierTestCluster("outlier_gateway_failure", 4, v2.OutlierDetection{
+		ConsecutiveGatewayFailure:          2,
+		EnforcingConsecutiveGatewayFailure: 100,
+		MaxEjectionPercent:                 50,
+	})
+	defer sc.stopOutlierDetector()
+
+	monitor := hosts[0].OutlierDetector()
+	monitor.PutResult(types.ResultConnectFailed)
+	monitor.PutResult(types.ResultRequestTimeout)
+
+	if hosts[0].Health() {
+		t.Fatal("host should be ejected after consecutive gateway failures")
+	}
+	if sc.Info().Stats().OutlierDetectionEjectionsGatewayFailure.Count() != 1 {
+		t.Error("gateway failure ejection is not recorded")
+	}
+}
+
+func TestOutlierDetectionMaxEjectionPercent(t *testing.T) {
+	sc, hosts := newOutlierTestCluster("outlier_max_ejection_percent", 4, v2.OutlierDetection{
+		Consecutive5xx:     1,
+		MaxEjectionPercent: 25,
+	})
+	defer sc.stopOutlierDetector()
+
+	hosts[0].OutlierDetector().PutResponseCode(http.StatusInternalServerError)
+	hosts[1].OutlierDetector().PutResponseCode(http.StatusInternalServerError)
+
+	if hosts[0].Health() {
+		t.Error("first host should be ejected")
+	}
+	if !hosts[1].Health() {
+		t.Error("second host should not be ejected as max ejection percent is reached")
+	}
+	if sc.Info().Stats().OutlierDetectionEjectionsOverflow.Count() != 1 {
+		t.Errorf("expect 1 overflow, but got %d", sc.Info().Stats().OutlierDetectionEjectionsOverflow.Count())
+	}
+
+	// all ejected hosts are brought back when the detector stopped
+	sc.stopOutlierDetector()
+	if !hosts[0].Health() || !isHealthyHost(sc, hosts[0]) {
+		t.Error("ejected host should be brought back after detector stopped")
+	}
+}
+
+func TestOutlierDetectionSuccessRate(t *testing.T) {
+	sc, hosts := newOutlierTestCluster("outlier_success_rate", 5, v2.OutlierDetection{
+		MaxEjectionPercent:       50,
+		SuccessRateRequestVolume: 10,
+	})
+	defer sc.stopOutlierDetector()
+
+	for i, host := range hosts {
+		monitor := host.OutlierDetector()
+		for j := 0; j < 10; j++ {
+			if i == 0 && j%2 == 0 {
+				monitor.PutResponseCode(http.StatusInternalServerError)
+			} else {
+				monitor.PutResponseCode(http.StatusOK)
+			}
+		}
+	}
+
+	sc.outlierDetector.onInterval()
+
+	// rates are [50, 100, 100, 100, 100], average is 90 and stdev is 20
+	if avg := sc.OutlierDetector().SuccessRateAverage(); avg != 90 {
+		t.Errorf("expect success rate average 90, but got %f", avg)
+	}
+	if hosts[0].OutlierDetector().SuccessRate() != 50 {
+		t.Errorf("expect success rate 50, but got %f", hosts[0].OutlierDetector().SuccessRate())
+	}
+	if hosts[0].Health() {
+		t.Error("host with low success rate should be ejected")
+	}
+	for _, host := range hosts[1:] {
+		if !host.Health() {
+			t.Errorf("host %s should not be ejected", host.AddressString())
+		}
+	}
+	if sc.Info().Stats().OutlierDetectionEjectionsSuccessRate.Count() != 1 {
+		t.Error("success rate ejection is not recorded")
+	}
+}
+
+func TestOutlierDetectionClusterUpdated(t *testing.T) {
+	config := v2.Cluster{
+		Name:        "outlier_cluster_updated",
+		ClusterType: v2.SIMPLE_CLUSTER,
+		LbType:      v2.LB_RANDOM,
+		OutlierDetection: v2.OutlierDetection{
+			Consecutive5xx:     3,
+			Interval:           time.Hour,
+			MaxEjectionPercent: 50,
+		},
+	}
+
+	cm := &clusterManager{}
+	if !cm.AddOrUpdatePrimaryCluster(config) {
+		t.Fatal("add cluster failed")
+	}
+	v, _ := cm.primaryClusters.Load(config.Name)
+	sc := v.(*primaryCluster).cluster.(*simpleInMemCluster)
+
+	var hosts []types.Host
+	for i := 0; i < 4; i++ {
+		hosts = append(hosts, NewHost(v2.Host{Address: fmt.Sprintf("127.0.0.%d:8080", i+1)}, sc.Info()))
+	}
+	sc.UpdateHosts(hosts)
+
+	host := hosts[0]
+	monitor := host.OutlierDetector()
+	if monitor == nil {
+		t.Fatal("host monitor is not set")
+	}
+
+	// update the cluster without outlier detection
+	config.OutlierDetection = v2.OutlierDetection{}
+	if !cm.AddOrUpdatePrimaryCluster(config) {
+		t.Fatal("update cluster failed")
+	}
+	if host.OutlierDetector() != nil {
+		t.Fatal("host monitor should be detached after the cluster is updated")
+	}
+
+	// the monitor held before the update should not eject the host any more
+	for i := 0; i < 5; i++ {
+		monitor.PutResponseCode(http.StatusInternalServerError)
+	}
+	if !host.Health() {
+		t.Error("host should not be ejected by a stopped detector")
+	}
+	if sc.Info().Stats().OutlierDetectionEjectionsTotal.Count() != 0 {
+		t.Error("stopped detector should not record ejections")
+	}
+}
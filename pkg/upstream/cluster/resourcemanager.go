@@ -113,3 +113,7 @@ func (r *resource) Decrease() {
 func (r *resource) Max() uint64 {
 	return r.max
 }
+
+func (r *resource) Cur() int64 {
+	return atomic.LoadInt64(&r.current)
+}
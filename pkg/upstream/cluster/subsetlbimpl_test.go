@@ -791,12 +791,12 @@ func HostsMock() []types.Host {
 }
 
 type ContextImplMock struct {
-	mmc *router.MetadataMatchCriteriaImpl
+	mmc     *router.MetadataMatchCriteriaImpl
+	hashKey types.HashedValue
 }
 
 func (ci *ContextImplMock) ComputeHashKey() types.HashedValue {
-	//return [16]byte{}
-	return ""
+	return ci.hashKey
 }
 
 func (ci *ContextImplMock) MetadataMatchCriteria() types.MetadataMatchCriteria {
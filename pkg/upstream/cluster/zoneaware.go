@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// HostZoneKey is the host metadata key of the zone which the host deployed in
+const HostZoneKey = "zone"
+
+// defaultZoneAwareFallbackThreshold is the minimum healthy percentage of hosts in local zone by default
+const defaultZoneAwareFallbackThreshold = 50
+
+var localZone atomic.Value
+
+// SetLocalZone sets the zone mosn deployed in, it takes effect on the next host update of clusters
+func SetLocalZone(zone string) {
+	localZone.Store(zone)
+}
+
+// GetLocalZone returns the zone mosn deployed in
+func GetLocalZone() string {
+	zone, _ := localZone.Load().(string)
+	return zone
+}
+
+// newZoneAwarePrioritySet creates a priority set derived from the cluster's priority set, which
+// contains only the hosts in the local zone if the healthy percentage of them reaches the threshold,
+// otherwise the traffic falls back to the hosts in all zones
+func newZoneAwarePrioritySet(original types.PrioritySet, config v2.ZoneAware) types.PrioritySet {
+	threshold := config.FallbackThreshold
+	if threshold == 0 {
+		threshold = defaultZoneAwareFallbackThreshold
+	}
+
+	ps := &prioritySet{}
+	update := func(priority uint32, hostsAdded []types.Host, hostsRemoved []types.Host) {
+		hostSet := original.GetOrCreateHostSet(priority)
+		hosts, healthyHosts := hostSet.Hosts(), hostSet.HealthyHosts()
+		hostsPerLocality, healthyHostsPerLocality := hostSet.HostsPerLocality(), hostSet.HealthHostsPerLocality()
+
+		if zone := GetLocalZone(); zone != "" {
+			localHosts := filterHostsByZone(hosts, zone)
+			localHealthyHosts := filterHostsByZone(healthyHosts, zone)
+
+			if len(localHosts) > 0 && uint64(len(localHealthyHosts))*100 >= uint64(threshold)*uint64(len(localHosts)) {
+				// locality weights make no sense in a single zone
+				hosts, healthyHosts = localHosts, localHealthyHosts
+				hostsPerLocality, healthyHostsPerLocality = nil, nil
+			}
+		}
+
+		ps.GetOrCreateHostSet(priority).UpdateHosts(hosts, healthyHosts, hostsPerLocality,
+			healthyHostsPerLocality, hostsAdded, hostsRemoved)
+	}
+
+	for i := range original.HostSetsByPriority() {
+		update(uint32(i), nil, nil)
+	}
+	original.AddMemberUpdateCb(update)
+
+	return ps
+}
+
+func filterHostsByZone(hosts []types.Host, zone string) []types.Host {
+	var zoneHosts []types.Host
+	for _, host := range hosts {
+		if hostInZone(host, zone) {
+			zoneHosts = append(zoneHosts, host)
+		}
+	}
+
+	return zoneHosts
+}
+
+// hostInZone checks the zone in host metadata, or the zone of the host's locality
+func hostInZone(host types.Host, zone string) bool {
+	// the metadata values are hashed, so the zone is compared by its hashed value
+	if hostZone, ok := host.Metadata()[HostZoneKey]; ok {
+		return types.EqualHashValue(hostZone, types.GenerateHashedValue(zone))
+	}
+
+	// locality is in the form of region/zone/sub_zone
+	if locality := strings.Split(host.Locality(), "/"); len(locality) > 1 {
+		return locality[1] == zone
+	}
+
+	return false
+}
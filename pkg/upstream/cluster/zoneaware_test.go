@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+func TestZoneAwareLoadBalancer(t *testing.T) {
+	SetLocalZone("GZ00A")
+	defer SetLocalZone("")
+
+	sc := newSimpleInMemCluster(v2.Cluster{
+		Name:        "zone_aware",
+		ClusterType: v2.SIMPLE_CLUSTER,
+		LbType:      v2.LB_ROUNDROBIN,
+		ZoneAware: v2.ZoneAware{
+			Enable: true,
+		},
+	}, nil, true)
+
+	var localHosts, hosts []types.Host
+	for i := 0; i < 4; i++ {
+		localHosts = append(localHosts, NewHost(v2.Host{
+			Address:  fmt.Sprintf("127.0.8.%d:8080", i+1),
+			Weight:   1,
+			MetaData: v2.Metadata{HostZoneKey: "GZ00A"},
+		}, sc.Info()))
+		hosts = append(hosts, NewHost(v2.Host{
+			Address:  fmt.Sprintf("127.0.9.%d:8080", i+1),
+			Weight:   1,
+			Locality: "region/GZ00B/",
+		}, sc.Info()))
+	}
+	sc.UpdateHosts(append(hosts, localHosts...))
+
+	countLocal := func() int {
+		count := 0
+		for i := 0; i < 100; i++ {
+			if hostInZone(sc.Info().LBInstance().ChooseHost(nil), "GZ00A") {
+				count++
+			}
+		}
+		return count
+	}
+
+	if count := countLocal(); count != 100 {
+		t.Errorf("expect all requests routed to local zone, but got %d", count)
+	}
+
+	// 2 of 4 local hosts are healthy, the threshold is reached
+	for _, host := range localHosts[:2] {
+		host.SetHealthFlag(types.FAILED_ACTIVE_HC)
+		sc.refreshHealthHosts(host)
+	}
+	if count := countLocal(); count != 100 {
+		t.Errorf("expect all requests routed to local zone, but got %d", count)
+	}
+
+	// fall back to all zones
+	localHosts[2].SetHealthFlag(types.FAILED_ACTIVE_HC)
+	sc.refreshHealthHosts(localHosts[2])
+	if count := countLocal(); count == 0 || count == 100 {
+		t.Errorf("expect requests routed to all zones, but got %d in local zone", count)
+	}
+}
@@ -37,6 +37,8 @@ func (nhc *healthCheckerFactory) New(config v2.HealthCheck) types.HealthChecker
 		return newSofaRPCHealthChecker(config)
 	case string(protocol.HTTP2):
 		return newHTTPHealthCheck(config)
+	case v2.TCP_HEALTH_CHECK:
+		return newTCPHealthChecker(config)
 	default:
 		// todo: http1
 		return nil
@@ -19,6 +19,7 @@ package healthcheck
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/alipay/sofa-mosn/pkg/api/v2"
@@ -35,6 +36,7 @@ type sessionFactory interface {
 type healthChecker struct {
 	serviceName         string
 	healthCheckCbs      []types.HealthCheckCb
+	cbsMux              *sync.RWMutex // a pointer, as the health checker is copied into the concrete checkers
 	cluster             types.Cluster
 	healthCheckSessions map[types.Host]types.HealthCheckSession
 
@@ -54,6 +56,7 @@ type healthChecker struct {
 func newHealthChecker(config v2.HealthCheck) *healthChecker {
 	hc := &healthChecker{
 		healthCheckSessions: make(map[types.Host]types.HealthCheckSession),
+		cbsMux:              new(sync.RWMutex),
 		timeout:             config.Timeout,
 		interval:            config.Interval,
 		intervalJitter:      config.IntervalJitter,
@@ -84,6 +87,9 @@ func (c *healthChecker) Stop() {
 }
 
 func (c *healthChecker) AddHostCheckCompleteCb(cb types.HealthCheckCb) {
+	c.cbsMux.Lock()
+	defer c.cbsMux.Unlock()
+
 	c.healthCheckCbs = append(c.healthCheckCbs, cb)
 }
 
@@ -180,16 +186,26 @@ func (c *healthChecker) getTimeoutDuration() time.Duration {
 func (c *healthChecker) runCallbacks(host types.Host, changed bool) {
 	c.refreshHealthyStat()
 
-	for _, cb := range c.healthCheckCbs {
+	c.cbsMux.RLock()
+	cbs := c.healthCheckCbs
+	c.cbsMux.RUnlock()
+
+	for _, cb := range cbs {
 		cb(host, changed)
 	}
 }
 
+// sessionTimer schedules the checks and the timeouts of a session
+type sessionTimer interface {
+	start(interval time.Duration)
+	stop()
+}
+
 type healthCheckSession struct {
 	healthChecker *healthChecker
 
-	intervalTimer *timer
-	timeoutTimer  *timer
+	intervalTimer sessionTimer
+	timeoutTimer  sessionTimer
 
 	numHealthy   uint32
 	numUnHealthy uint32
@@ -0,0 +1,326 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/buffer"
+	"github.com/alipay/sofa-mosn/pkg/log"
+	"github.com/alipay/sofa-mosn/pkg/types"
+)
+
+// tcpHealthChecker checks the host by a new connection in each interval. If the send payload is empty,
+// the check succeeds once connected, otherwise the payload is sent and the response is expected to
+// contain all the receive payloads in order.
+type tcpHealthChecker struct {
+	healthChecker
+	send    []byte
+	receive [][]byte
+	// receiveLen is the max length of the response buffered, the check fails once it is reached without a match
+	receiveLen int
+
+	mux      sync.Mutex
+	sessions []*tcpHealthCheckSession
+	stopped  bool
+}
+
+func newTCPHealthChecker(config v2.HealthCheck) types.HealthChecker {
+	hc := newHealthChecker(config)
+
+	thc := &tcpHealthChecker{
+		healthChecker: *hc,
+		send:          config.TCPSend,
+		receive:       config.TCPReceive,
+	}
+
+	for _, block := range thc.receive {
+		thc.receiveLen += len(block)
+	}
+
+	thc.sessionFactory = thc
+
+	return thc
+}
+
+func (c *tcpHealthChecker) newSession(host types.Host) types.HealthCheckSession {
+	thcs := &tcpHealthCheckSession{
+		healthChecker:      c,
+		healthCheckSession: *newHealthCheckSession(&c.healthChecker, host),
+	}
+
+	thcs.intervalTimer = newTCPCheckTimer(thcs.onInterval)
+	thcs.timeoutTimer = newTCPCheckTimer(thcs.onTimeout)
+
+	c.mux.Lock()
+	if c.stopped {
+		thcs.stopped = 1
+	}
+	c.sessions = append(c.sessions, thcs)
+	c.mux.Unlock()
+
+	return thcs
+}
+
+// Stop stops the checks of all hosts, the running checks are closed
+func (c *tcpHealthChecker) Stop() {
+	c.mux.Lock()
+	c.stopped = true
+	sessions := c.sessions
+	c.mux.Unlock()
+
+	for _, s := range sessions {
+		s.Stop()
+	}
+}
+
+type tcpHealthCheckSession struct {
+	healthCheckSession
+
+	healthChecker *tcpHealthChecker
+
+	// current is the connection of the running check, it is set to nil when the check is completed
+	current *tcpHealthCheckConn
+	mux     sync.Mutex
+	stopped int32
+}
+
+// tcpHealthCheckConn receives the response and events of a check connection
+type tcpHealthCheckConn struct {
+	session   *tcpHealthCheckSession
+	conn      types.ClientConnection
+	connected int32
+	response  []byte
+}
+
+// overload healthCheckSession, the first check is scheduled on the interval timer instead of running inline
+func (s *tcpHealthCheckSession) Start() {
+	if atomic.LoadInt32(&s.stopped) == 1 {
+		return
+	}
+
+	s.intervalTimer.start(0)
+}
+
+// overload healthCheckSession, the running check is closed without handling the result
+func (s *tcpHealthCheckSession) Stop() {
+	atomic.StoreInt32(&s.stopped, 1)
+	s.intervalTimer.stop()
+	s.timeoutTimer.stop()
+
+	s.mux.Lock()
+	c := s.current
+	s.current = nil
+	s.mux.Unlock()
+
+	// the connection is closed by onInterval if it is still connecting
+	if c != nil && atomic.LoadInt32(&c.connected) == 1 {
+		c.close()
+	}
+}
+
+func (s *tcpHealthCheckSession) onInterval() {
+	// the interval timer may be restarted by the check completed after stopping
+	if atomic.LoadInt32(&s.stopped) == 1 {
+		return
+	}
+
+	// start timeout interval
+	s.healthCheckSession.onInterval()
+
+	connData := s.host.CreateConnection(nil)
+	c := &tcpHealthCheckConn{
+		session: s,
+		conn:    connData.Connection,
+	}
+	c.conn.AddConnectionEventListener(c)
+	c.conn.FilterManager().AddReadFilter(c)
+
+	s.mux.Lock()
+	s.current = c
+	s.mux.Unlock()
+
+	if err := c.conn.Connect(true); err != nil {
+		log.DefaultLogger.Debugf("tcp health check connect to %s failed: %v", s.host.AddressString(), err)
+		if s.finish(c) {
+			s.handleFailure(types.FailureNetwork)
+		}
+		return
+	}
+
+	atomic.StoreInt32(&c.connected, 1)
+	if !s.running(c) {
+		// timeout while connecting
+		c.close()
+		return
+	}
+
+	if len(s.healthChecker.send) == 0 {
+		// connect only
+		if s.finish(c) {
+			c.close()
+			s.handleSuccess()
+		}
+		return
+	}
+
+	if err := c.conn.Write(buffer.NewIoBufferBytes(s.healthChecker.send)); err != nil {
+		if s.finish(c) {
+			c.close()
+			s.handleFailure(types.FailureNetwork)
+		}
+		return
+	}
+
+	if len(s.healthChecker.receive) == 0 && s.finish(c) {
+		c.close()
+		s.handleSuccess()
+	}
+}
+
+func (s *tcpHealthCheckSession) onTimeout() {
+	s.mux.Lock()
+	c := s.current
+	s.mux.Unlock()
+
+	if c == nil || !s.finish(c) {
+		return
+	}
+
+	// the connection is closed by onInterval if it is still connecting
+	if atomic.LoadInt32(&c.connected) == 1 {
+		c.close()
+	}
+
+	log.DefaultLogger.Errorf("tcp health check timeout for remote host = %s", s.host.AddressString())
+	s.healthCheckSession.onTimeout()
+}
+
+// finish completes the check of the connection. It returns false if the check is already completed,
+// so the result is handled only once
+func (s *tcpHealthCheckSession) finish(c *tcpHealthCheckConn) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.current != c {
+		return false
+	}
+	s.current = nil
+
+	return true
+}
+
+func (s *tcpHealthCheckSession) running(c *tcpHealthCheckConn) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.current == c
+}
+
+func (c *tcpHealthCheckConn) close() {
+	c.conn.Close(types.NoFlush, types.LocalClose)
+}
+
+func (c *tcpHealthCheckConn) OnData(buf types.IoBuffer) types.FilterStatus {
+	receiveLen := c.session.healthChecker.receiveLen
+
+	data := buf.Bytes()
+	if remain := receiveLen - len(c.response); len(data) > remain {
+		data = data[:remain]
+	}
+	c.response = append(c.response, data...)
+	buf.Drain(buf.Len())
+
+	if containsInOrder(c.response, c.session.healthChecker.receive) {
+		if c.session.finish(c) {
+			c.close()
+			c.session.handleSuccess()
+		}
+	} else if len(c.response) >= receiveLen {
+		// the expected payloads can not be matched with more data
+		if c.session.finish(c) {
+			c.close()
+			c.session.handleFailure(types.FailureActive)
+		}
+	}
+
+	return types.StopIteration
+}
+
+func (c *tcpHealthCheckConn) OnNewConnection() types.FilterStatus {
+	return types.Continue
+}
+
+func (c *tcpHealthCheckConn) InitializeReadFilterCallbacks(cb types.ReadFilterCallbacks) {}
+
+func (c *tcpHealthCheckConn) OnEvent(event types.ConnectionEvent) {
+	if (event.IsClose() || event.ConnectFailure()) && c.session.finish(c) {
+		c.session.handleFailure(types.FailureNetwork)
+	}
+}
+
+// containsInOrder returns true if each block can be found in data in order, the blocks are not necessarily contiguous
+func containsInOrder(data []byte, blocks [][]byte) bool {
+	for _, block := range blocks {
+		idx := bytes.Index(data, block)
+		if idx < 0 {
+			return false
+		}
+		data = data[idx+len(block):]
+	}
+
+	return true
+}
+
+// tcpCheckTimer runs the callback in its own goroutine, and the pending one is replaced on restarting,
+// so the next check can be scheduled while handling the result of the current one
+type tcpCheckTimer struct {
+	callback   func()
+	innerTimer *time.Timer
+	mux        sync.Mutex
+}
+
+func newTCPCheckTimer(callback func()) *tcpCheckTimer {
+	return &tcpCheckTimer{
+		callback: callback,
+	}
+}
+
+func (t *tcpCheckTimer) start(interval time.Duration) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if t.innerTimer != nil {
+		t.innerTimer.Stop()
+	}
+
+	t.innerTimer = time.AfterFunc(interval, t.callback)
+}
+
+func (t *tcpCheckTimer) stop() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if t.innerTimer != nil {
+		t.innerTimer.Stop()
+	}
+}
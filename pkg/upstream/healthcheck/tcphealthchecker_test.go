@@ -0,0 +1,204 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package healthcheck
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alipay/sofa-mosn/pkg/api/v2"
+	"github.com/alipay/sofa-mosn/pkg/types"
+	"github.com/alipay/sofa-mosn/pkg/upstream/cluster"
+)
+
+// startPingPongServer replies "pong" for each "ping"
+func startPingPongServer(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 16)
+				n, err := conn.Read(buf)
+				if err != nil {
+					return
+				}
+				if bytes.Equal(buf[:n], []byte("ping")) {
+					conn.Write([]byte("po"))
+					conn.Write([]byte("ng"))
+				}
+			}()
+		}
+	}()
+
+	return ln
+}
+
+// startStreamServer keeps writing the data which never matches
+func startStreamServer(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				data := bytes.Repeat([]byte("x"), 1024)
+				for {
+					if _, err := conn.Write(data); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return ln
+}
+
+// testTCPHealthCheck returns the health of the host after checking for a while
+func testTCPHealthCheck(t *testing.T, address string, config v2.HealthCheck) bool {
+	config.Protocol = v2.TCP_HEALTH_CHECK
+	config.Timeout = 200 * time.Millisecond
+	config.Interval = 50 * time.Millisecond
+	config.HealthyThreshold = 1
+	config.UnhealthyThreshold = 2
+
+	c := cluster.NewCluster(v2.Cluster{
+		Name:        "tcp_health_check",
+		ClusterType: v2.SIMPLE_CLUSTER,
+		LbType:      v2.LB_RANDOM,
+	}, nil, true)
+
+	host := cluster.NewHost(v2.Host{Address: address}, c.Info())
+	hosts := []types.Host{host}
+	c.PrioritySet().GetOrCreateHostSet(0).UpdateHosts(hosts, hosts, nil, nil, nil, nil)
+
+	hc := factory.New(config)
+	defer hc.Stop()
+
+	// the health flags are read in the checker goroutine after each check, as they are not guarded
+	var mux sync.Mutex
+	healthy := host.Health()
+	hc.AddHostCheckCompleteCb(func(host types.Host, changed bool) {
+		mux.Lock()
+		healthy = host.Health()
+		mux.Unlock()
+	})
+
+	c.SetHealthChecker(hc)
+	time.Sleep(time.Second)
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	return healthy
+}
+
+func TestTCPHealthCheck(t *testing.T) {
+	ln := startPingPongServer(t)
+	defer ln.Close()
+
+	// connect only
+	if !testTCPHealthCheck(t, ln.Addr().String(), v2.HealthCheck{}) {
+		t.Error("expect host healthy with connect only check")
+	}
+
+	// send and expect payload
+	if !testTCPHealthCheck(t, ln.Addr().String(), v2.HealthCheck{
+		TCPSend:    []byte("ping"),
+		TCPReceive: [][]byte{[]byte("po"), []byte("ng")},
+	}) {
+		t.Error("expect host healthy with expected response")
+	}
+
+	// unexpected response
+	if testTCPHealthCheck(t, ln.Addr().String(), v2.HealthCheck{
+		TCPSend:    []byte("ping"),
+		TCPReceive: [][]byte{[]byte("pang")},
+	}) {
+		t.Error("expect host unhealthy with unexpected response")
+	}
+
+	// connect failed
+	addr := ln.Addr().String()
+	ln.Close()
+	if testTCPHealthCheck(t, addr, v2.HealthCheck{}) {
+		t.Error("expect host unhealthy when connect failed")
+	}
+}
+
+func TestTCPHealthCheckResponseLimit(t *testing.T) {
+	ln := startStreamServer(t)
+	defer ln.Close()
+
+	config := v2.HealthCheck{
+		ServiceName: "tcp_health_check_response_limit",
+		TCPSend:     []byte("ping"),
+		TCPReceive:  [][]byte{[]byte("pong")},
+	}
+	if testTCPHealthCheck(t, ln.Addr().String(), config) {
+		t.Error("expect host unhealthy with unexpected response")
+	}
+
+	// the check fails once the response exceeds the expected length, instead of buffering until timeout
+	stats := newHealthCheckStats(config.ServiceName)
+	if stats.failure.Count() == 0 || stats.networkFailure.Count() != 0 {
+		t.Errorf("expect failures without timeout, failure = %d, network failure = %d",
+			stats.failure.Count(), stats.networkFailure.Count())
+	}
+}
+
+func TestContainsInOrder(t *testing.T) {
+	data := []byte("0123456789")
+
+	testCases := []struct {
+		blocks [][]byte
+		want   bool
+	}{
+		{nil, true},
+		{[][]byte{[]byte("123"), []byte("78")}, true},
+		{[][]byte{[]byte("78"), []byte("123")}, false},
+		{[][]byte{[]byte("ab")}, false},
+	}
+
+	for i, tc := range testCases {
+		if got := containsInOrder(data, tc.blocks); got != tc.want {
+			t.Errorf("case %d: expect %t, but got %t", i, tc.want, got)
+		}
+	}
+}
@@ -76,17 +76,43 @@ func (adsClient *ADSClient) receiveThread() {
 				continue
 			}
 			typeURL := resp.TypeUrl
-			if typeURL == "type.googleapis.com/envoy.api.v2.Listener" {
+			if typeURL == ListenerType {
 				log.DefaultLogger.Tracef("get lds resp,handle it")
-				listeners := adsClient.V2Client.handleListenersResp(resp)
-				log.DefaultLogger.Infof("get %d listeners from LDS", len(listeners))
-				adsClient.MosnConfig.OnAddOrUpdateListeners(listeners)
+				listeners, err := adsClient.V2Client.handleListenersResp(resp)
+				if err == nil {
+					log.DefaultLogger.Infof("get %d listeners from LDS", len(listeners))
+					err = adsClient.MosnConfig.OnAddOrUpdateListeners(listeners)
+				}
+				adsClient.V2Client.ackResponse(adsClient.StreamClient, resp, err)
 
-			} else if typeURL == "type.googleapis.com/envoy.api.v2.Cluster" {
+				// the routes are subscribed separately, so they are updated without rebuilding the listeners
+				if routerConfigNames := config.GetRouterConfigNames(listeners); len(routerConfigNames) > 0 {
+					log.DefaultLogger.Tracef("send thread request rds")
+					err = adsClient.V2Client.reqRoutes(adsClient.StreamClient, routerConfigNames)
+					if err != nil {
+						log.DefaultLogger.Warnf("send thread request rds fail!auto retry next period")
+					}
+				}
+
+			} else if typeURL == RouteType {
+				log.DefaultLogger.Tracef("get rds resp,handle it")
+				routeConfigs, err := adsClient.V2Client.handleRoutesResp(resp)
+				if err == nil {
+					log.DefaultLogger.Infof("get %d route configs from RDS", len(routeConfigs))
+					err = adsClient.MosnConfig.OnUpdateRoutes(routeConfigs)
+				}
+				adsClient.V2Client.ackResponse(adsClient.StreamClient, resp, err)
+
+			} else if typeURL == ClusterType {
 				log.DefaultLogger.Tracef("get cds resp,handle it")
-				clusters := adsClient.V2Client.handleClustersResp(resp)
+				clusters, err := adsClient.V2Client.handleClustersResp(resp)
+				if err != nil {
+					adsClient.V2Client.ackResponse(adsClient.StreamClient, resp, err)
+					continue
+				}
 				log.DefaultLogger.Infof("get %d clusters from CDS", len(clusters))
-				adsClient.MosnConfig.OnUpdateClusters(clusters)
+				err = adsClient.MosnConfig.OnUpdateClusters(clusters)
+				adsClient.V2Client.ackResponse(adsClient.StreamClient, resp, err)
 				clusterNames := make([]string, 0)
 
 				for _, cluster := range clusters {
@@ -101,11 +127,14 @@ func (adsClient *ADSClient) receiveThread() {
 					log.DefaultLogger.Warnf("send thread request eds fail!auto retry next period")
 				}
 
-			} else if typeURL == "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment" {
+			} else if typeURL == EndpointType {
 				log.DefaultLogger.Tracef("get eds resp,handle it ")
-				endpoints := adsClient.V2Client.handleEndpointsResp(resp)
-				log.DefaultLogger.Infof("get %d endpoints from EDS", len(endpoints))
-				adsClient.MosnConfig.OnUpdateEndpoints(endpoints)
+				endpoints, err := adsClient.V2Client.handleEndpointsResp(resp)
+				if err == nil {
+					log.DefaultLogger.Infof("get %d endpoints from EDS", len(endpoints))
+					err = adsClient.MosnConfig.OnUpdateEndpoints(endpoints)
+				}
+				adsClient.V2Client.ackResponse(adsClient.StreamClient, resp, err)
 				log.DefaultLogger.Tracef("send thread request lds")
 				err = adsClient.V2Client.reqListeners(adsClient.StreamClient)
 				if err != nil {
@@ -18,41 +18,24 @@
 package v2
 
 import (
-	"errors"
+	"fmt"
 
-	"github.com/alipay/sofa-mosn/pkg/log"
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 )
 
 func (c *ClientV2) reqClusters(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient) error {
-	if streamClient == nil {
-		return errors.New("stream client is nil")
-	}
-	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
-		ResourceNames: []string{},
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.Cluster",
-		ResponseNonce: "",
-		ErrorDetail:   nil,
-		Node: &envoy_api_v2_core1.Node{
-			Id: c.ServiceNode,
-		},
-	})
-	if err != nil {
-		log.DefaultLogger.Fatalf("get clusters fail: %v", err)
-		return err
-	}
-	return nil
+	return c.reqResources(streamClient, ClusterType, nil)
 }
 
-func (c *ClientV2) handleClustersResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.Cluster {
+func (c *ClientV2) handleClustersResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.Cluster, error) {
 	clusters := make([]*envoy_api_v2.Cluster, 0)
 	for _, res := range resp.Resources {
 		cluster := envoy_api_v2.Cluster{}
-		cluster.Unmarshal(res.GetValue())
+		if err := cluster.Unmarshal(res.GetValue()); err != nil {
+			return nil, fmt.Errorf("unmarshal Cluster failed: %v", err)
+		}
 		clusters = append(clusters, &cluster)
 	}
-	return clusters
+	return clusters, nil
 }
@@ -18,42 +18,24 @@
 package v2
 
 import (
-	"errors"
+	"fmt"
 
-	"github.com/alipay/sofa-mosn/pkg/log"
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 )
 
 func (c *ClientV2) reqEndpoints(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, clusterNames []string) error {
-	if streamClient == nil {
-		return errors.New("stream client is nil")
-	}
-	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
-		ResourceNames: clusterNames,
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment",
-		ResponseNonce: "",
-		ErrorDetail:   nil,
-		Node: &envoy_api_v2_core1.Node{
-			Id:      c.ServiceNode,
-			Cluster: c.ServiceCluster,
-		},
-	})
-	if err != nil {
-		log.DefaultLogger.Fatalf("get endpoints fail: %v", err)
-		return err
-	}
-	return nil
+	return c.reqResources(streamClient, EndpointType, clusterNames)
 }
 
-func (c *ClientV2) handleEndpointsResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.ClusterLoadAssignment {
+func (c *ClientV2) handleEndpointsResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.ClusterLoadAssignment, error) {
 	lbAssignments := make([]*envoy_api_v2.ClusterLoadAssignment, 0)
 	for _, res := range resp.Resources {
 		lbAssignment := envoy_api_v2.ClusterLoadAssignment{}
-		lbAssignment.Unmarshal(res.GetValue())
+		if err := lbAssignment.Unmarshal(res.GetValue()); err != nil {
+			return nil, fmt.Errorf("unmarshal ClusterLoadAssignment failed: %v", err)
+		}
 		lbAssignments = append(lbAssignments, &lbAssignment)
 	}
-	return lbAssignments
+	return lbAssignments, nil
 }
@@ -18,41 +18,24 @@
 package v2
 
 import (
-	"errors"
+	"fmt"
 
-	"github.com/alipay/sofa-mosn/pkg/log"
 	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
-	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
 )
 
 func (c *ClientV2) reqListeners(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient) error {
-	if streamClient == nil {
-		return errors.New("stream client is nil")
-	}
-	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
-		VersionInfo:   "",
-		ResourceNames: []string{},
-		TypeUrl:       "type.googleapis.com/envoy.api.v2.Listener",
-		ResponseNonce: "",
-		ErrorDetail:   nil,
-		Node: &envoy_api_v2_core1.Node{
-			Id: c.ServiceNode,
-		},
-	})
-	if err != nil {
-		log.DefaultLogger.Fatalf("get listener fail: %v", err)
-		return err
-	}
-	return nil
+	return c.reqResources(streamClient, ListenerType, nil)
 }
 
-func (c *ClientV2) handleListenersResp(resp *envoy_api_v2.DiscoveryResponse) []*envoy_api_v2.Listener {
+func (c *ClientV2) handleListenersResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.Listener, error) {
 	listeners := make([]*envoy_api_v2.Listener, 0)
 	for _, res := range resp.Resources {
 		listener := envoy_api_v2.Listener{}
-		listener.Unmarshal(res.GetValue())
+		if err := listener.Unmarshal(res.GetValue()); err != nil {
+			return nil, fmt.Errorf("unmarshal Listener failed: %v", err)
+		}
 		listeners = append(listeners, &listener)
 	}
-	return listeners
+	return listeners, nil
 }
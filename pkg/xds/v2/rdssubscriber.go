@@ -0,0 +1,43 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"fmt"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+)
+
+// reqRoutes subscribes the route configs referenced by the listeners, the subscribed names
+// are replaced by the request, so all the referenced names should be requested together
+func (c *ClientV2) reqRoutes(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, routerConfigNames []string) error {
+	return c.reqResources(streamClient, RouteType, routerConfigNames)
+}
+
+func (c *ClientV2) handleRoutesResp(resp *envoy_api_v2.DiscoveryResponse) ([]*envoy_api_v2.RouteConfiguration, error) {
+	routeConfigs := make([]*envoy_api_v2.RouteConfiguration, 0)
+	for _, res := range resp.Resources {
+		routeConfig := envoy_api_v2.RouteConfiguration{}
+		if err := routeConfig.Unmarshal(res.GetValue()); err != nil {
+			return nil, fmt.Errorf("unmarshal RouteConfiguration failed: %v", err)
+		}
+		routeConfigs = append(routeConfigs, &routeConfig)
+	}
+	return routeConfigs, nil
+}
@@ -0,0 +1,163 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/alipay/sofa-mosn/pkg/log"
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoy_api_v2_core1 "github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+	google_rpc "github.com/gogo/googleapis/google/rpc"
+)
+
+// Resource type urls subscribed by the ads client
+const (
+	ListenerType = "type.googleapis.com/envoy.api.v2.Listener"
+	RouteType    = "type.googleapis.com/envoy.api.v2.RouteConfiguration"
+	ClusterType  = "type.googleapis.com/envoy.api.v2.Cluster"
+	EndpointType = "type.googleapis.com/envoy.api.v2.ClusterLoadAssignment"
+)
+
+// ResourceVersion is the discovery state of a resource type.
+// Version is the version of the last accepted response, Nonce is the nonce of the last received response.
+// ErrorDetail is the reason why the last response is rejected, it is cleared once a response is accepted.
+type ResourceVersion struct {
+	TypeURL     string
+	Version     string
+	Nonce       string
+	ErrorDetail string
+}
+
+type resourceState struct {
+	ResourceVersion
+	resourceNames []string
+}
+
+// the states are shared by the clients, as mosn runs one xds client only.
+// mux also serializes the requests, as the stream is not safe for concurrent sending
+var (
+	mux    sync.Mutex
+	states = make(map[string]*resourceState)
+)
+
+// GetResourceVersions returns the discovery states of the subscribed resource types, sorted by type url
+func GetResourceVersions() []ResourceVersion {
+	mux.Lock()
+	defer mux.Unlock()
+
+	versions := make([]ResourceVersion, 0, len(states))
+	for _, state := range states {
+		versions = append(versions, state.ResourceVersion)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].TypeURL < versions[j].TypeURL
+	})
+
+	return versions
+}
+
+// getState should be called with the lock held
+func getState(typeURL string) *resourceState {
+	state, ok := states[typeURL]
+	if !ok {
+		state = &resourceState{
+			ResourceVersion: ResourceVersion{TypeURL: typeURL},
+		}
+		states[typeURL] = state
+	}
+
+	return state
+}
+
+// reqResources subscribes the resources, the version and nonce of the type are carried,
+// so the request acknowledges the last response if any
+func (c *ClientV2) reqResources(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, typeURL string, resourceNames []string) error {
+	if streamClient == nil {
+		return errors.New("stream client is nil")
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	state := getState(typeURL)
+	state.resourceNames = resourceNames
+
+	return c.send(streamClient, state, nil)
+}
+
+// ackResponse acknowledges the response if it is applied without error, the version and nonce
+// of the response are echoed. Otherwise the response is rejected with the error detail and the
+// version of the last accepted response, the last good config is kept.
+func (c *ClientV2) ackResponse(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, resp *envoy_api_v2.DiscoveryResponse, err error) error {
+	if streamClient == nil {
+		return errors.New("stream client is nil")
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	state := getState(resp.TypeUrl)
+	state.Nonce = resp.Nonce
+
+	var errorDetail *google_rpc.Status
+	if err == nil {
+		state.Version = resp.VersionInfo
+		state.ErrorDetail = ""
+		log.DefaultLogger.Infof("xds ack %s, version = %s, nonce = %s", resp.TypeUrl, resp.VersionInfo, resp.Nonce)
+	} else {
+		state.ErrorDetail = err.Error()
+		errorDetail = &google_rpc.Status{
+			Code:    int32(google_rpc.INVALID_ARGUMENT),
+			Message: err.Error(),
+		}
+		log.DefaultLogger.Errorf("xds nack %s, version = %s, nonce = %s, error: %v", resp.TypeUrl, resp.VersionInfo, resp.Nonce, err)
+	}
+
+	return c.send(streamClient, state, errorDetail)
+}
+
+// send should be called with the lock held
+func (c *ClientV2) send(streamClient ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient, state *resourceState, errorDetail *google_rpc.Status) error {
+	resourceNames := state.resourceNames
+	if resourceNames == nil {
+		resourceNames = []string{}
+	}
+
+	err := streamClient.Send(&envoy_api_v2.DiscoveryRequest{
+		VersionInfo:   state.Version,
+		ResourceNames: resourceNames,
+		TypeUrl:       state.TypeURL,
+		ResponseNonce: state.Nonce,
+		ErrorDetail:   errorDetail,
+		Node: &envoy_api_v2_core1.Node{
+			Id:      c.ServiceNode,
+			Cluster: c.ServiceCluster,
+		},
+	})
+	if err != nil {
+		log.DefaultLogger.Errorf("send %s request fail: %v", state.TypeURL, err)
+		return err
+	}
+
+	return nil
+}
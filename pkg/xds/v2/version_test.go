@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v2
+
+import (
+	"errors"
+	"testing"
+
+	envoy_api_v2 "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	ads "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v2"
+)
+
+type mockStreamClient struct {
+	ads.AggregatedDiscoveryService_StreamAggregatedResourcesClient
+	requests []*envoy_api_v2.DiscoveryRequest
+}
+
+func (m *mockStreamClient) Send(req *envoy_api_v2.DiscoveryRequest) error {
+	m.requests = append(m.requests, req)
+	return nil
+}
+
+func (m *mockStreamClient) lastRequest() *envoy_api_v2.DiscoveryRequest {
+	return m.requests[len(m.requests)-1]
+}
+
+func TestAckResponse(t *testing.T) {
+	c := &ClientV2{ServiceCluster: "cluster", ServiceNode: "node"}
+	stream := &mockStreamClient{}
+
+	if err := c.reqEndpoints(stream, []string{"c1"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := stream.lastRequest(); req.VersionInfo != "" || req.ResponseNonce != "" {
+		t.Errorf("initial request should carry no version and nonce, got %+v", req)
+	}
+
+	// ack echoes the version and nonce
+	c.ackResponse(stream, &envoy_api_v2.DiscoveryResponse{TypeUrl: EndpointType, VersionInfo: "v1", Nonce: "n1"}, nil)
+	req := stream.lastRequest()
+	if req.TypeUrl != EndpointType || req.VersionInfo != "v1" || req.ResponseNonce != "n1" || req.ErrorDetail != nil {
+		t.Errorf("unexpected ack: %+v", req)
+	}
+	if len(req.ResourceNames) != 1 || req.ResourceNames[0] != "c1" {
+		t.Errorf("ack should keep the subscribed resource names, got %v", req.ResourceNames)
+	}
+
+	// nack keeps the accepted version and carries the error detail
+	c.ackResponse(stream, &envoy_api_v2.DiscoveryResponse{TypeUrl: EndpointType, VersionInfo: "v2", Nonce: "n2"}, errors.New("bad config"))
+	req = stream.lastRequest()
+	if req.VersionInfo != "v1" || req.ResponseNonce != "n2" || req.ErrorDetail == nil || req.ErrorDetail.Message != "bad config" {
+		t.Errorf("unexpected nack: %+v", req)
+	}
+
+	// the later subscription carries the state too
+	c.reqEndpoints(stream, []string{"c1", "c2"})
+	if req := stream.lastRequest(); req.VersionInfo != "v1" || req.ResponseNonce != "n2" {
+		t.Errorf("subscription should carry the version and nonce, got %+v", req)
+	}
+
+	found := false
+	for _, v := range GetResourceVersions() {
+		if v.TypeURL == EndpointType {
+			found = true
+			if v.Version != "v1" || v.Nonce != "n2" || v.ErrorDetail != "bad config" {
+				t.Errorf("unexpected resource version: %+v", v)
+			}
+		}
+	}
+	if !found {
+		t.Error("resource version of endpoints not found")
+	}
+}
@@ -194,7 +194,7 @@ func UnmarshalResources(config *config.MOSNConfig) (dynamicResources *bootstrap.
 	return dynamicResources, staticResources, nil
 }
 
-// Start used to fetch listeners/clusters/clusterloadassignment config from pilot in cycle,
+// Start used to fetch listeners/routes/clusters/clusterloadassignment config from pilot in cycle,
 // usually called when mosn start
 func (c *Client) Start(config *config.MOSNConfig, serviceCluster, serviceNode string) error {
 	log.DefaultLogger.Infof("xds client start")